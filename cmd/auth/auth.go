@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AuthCmd represents the auth command
+var AuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect GitHub authentication",
+	Long:  `Inspect how gong authenticates against the GitHub API.`,
+}
+
+// statusCmd represents the auth status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which GitHub token is in use",
+	Long:  `Show where the GitHub token used by gong comes from, which account it belongs to and which scopes it grants.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, source, err := githubclient.NewClientFromConfig()
+		if err != nil {
+			log.Fatal().Msgf("%v", err)
+		}
+
+		status, err := githubclient.GetAuthStatus(client)
+		if err != nil {
+			log.Fatal().Msgf("Error checking GitHub token from %s: %v", source, err)
+		}
+
+		scopes := "none reported"
+		if len(status.Scopes) > 0 {
+			scopes = strings.Join(status.Scopes, ", ")
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Logged in to %s as %s\n", githubclient.Host(viper.GetString("github-url")), status.Login)
+		fmt.Fprintf(out, "Token source: %s\n", source)
+		fmt.Fprintf(out, "Token scopes: %s\n", scopes)
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(statusCmd)
+}
@@ -3,13 +3,18 @@ package ping
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/identity"
 	"github.com/Djiit/gong/internal/integrations"
 	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/provider"
 	"github.com/Djiit/gong/internal/rules"
+	"github.com/Djiit/gong/internal/state"
 	"github.com/google/go-github/v69/github"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -34,8 +39,11 @@ var PingCmd = &cobra.Command{
 		isDryRun := viper.GetBool("dry-run")
 
 		repository := viper.GetString("repository")
-		// If repository is not specified, try to detect it
+		// If repository is not specified, try to detect it from the GitHub remote of the current directory
 		if repository == "" {
+			if name := viper.GetString("provider"); name != "" && name != "github" {
+				log.Fatal().Msgf("The repository can only be detected on GitHub. Please specify a %s repository using the --repository flag.", name)
+			}
 			detectedRepo, err := githubclient.GetCurrentRepository()
 			if err != nil {
 				log.Fatal().Msgf("Error detecting current repository: %v. Please specify a repository using the --repository flag.", err)
@@ -52,12 +60,14 @@ var PingCmd = &cobra.Command{
 			log.Fatal().Msg("PR number must be specified")
 		}
 
-		repoParts := strings.Split(repository, "/")
-		if len(repoParts) != 2 {
+		// GitLab projects may live in nested groups, so only the last segment is the repository name
+		separator := strings.LastIndex(repository, "/")
+		if separator <= 0 || separator == len(repository)-1 ||
+			(viper.GetString("provider") != "gitlab" && strings.Count(repository, "/") != 1) {
 			log.Fatal().Msgf("Invalid repository format. Expected owner/repo, got %s", repository)
 		}
-		repoOwner = repoParts[0]
-		repoName = repoParts[1]
+		repoOwner = repository[:separator]
+		repoName = repository[separator+1:]
 
 		// Create context with all necessary values
 		ctx := context.WithValue(cmd.Context(), "dry-run", isDryRun)
@@ -66,6 +76,18 @@ var PingCmd = &cobra.Command{
 		ctx = context.WithValue(ctx, "repoOwner", repoOwner)
 		ctx = context.WithValue(ctx, "repoName", repoName)
 		ctx = context.WithValue(ctx, "pr", pr)
+		ctx = context.WithValue(ctx, "skipIfTeamMemberReviewed", viper.GetBool("skipIfTeamMemberReviewed"))
+		ctx = context.WithValue(ctx, "resetDelayOnActivity", viper.GetBool("resetDelayOnActivity"))
+		ctx = context.WithValue(ctx, "requireChecksPassing", viper.GetBool("requireChecksPassing"))
+		ctx = context.WithValue(ctx, "requireRequiredChecks", viper.GetBool("requireRequiredChecks"))
+		ctx = context.WithValue(ctx, "skipIfConflicting", viper.GetBool("skipIfConflicting"))
+
+		// Authors are nudged after the same delay as reviewers unless told otherwise
+		authorDelay := delay
+		if viper.IsSet("authorDelay") {
+			authorDelay = viper.GetInt("authorDelay")
+		}
+		ctx = context.WithValue(ctx, "authorDelay", authorDelay)
 
 		// Parse global integrations from config
 		globalIntegrations := rules.ParseGlobalIntegrations()
@@ -81,18 +103,35 @@ var PingCmd = &cobra.Command{
 		}
 
 		ctx = context.WithValue(ctx, "integrations", globalIntegrations)
-		client := githubclient.NewClient(viper.GetString("github-token"))
 
-		prState, err := githubclient.GetPullRequestState(client, repoOwner, repoName, pr)
+		prov, err := provider.NewFromConfig()
+		if err != nil {
+			log.Fatal().Msgf("%v", err)
+		}
+		ctx = context.WithValue(ctx, "provider", prov)
+
+		// The state remembers what previous runs did, e.g. the Slack messages to update in place and snoozed reviewers
+		if store, err := state.FromConfig(); err != nil {
+			log.Warn().Msgf("Could not open the state: %v", err)
+		} else {
+			ctx = context.WithValue(ctx, "state", store)
+		}
+
+		// Fetch the PR state and its review requests
+		prData, err := prov.GetPullRequest(ctx, repoOwner, repoName, pr)
 		if err != nil {
 			// Check if the error is because the PR was not found
 			var githubErr *github.ErrorResponse
-			if errors.As(err, &githubErr) && githubErr.Response.StatusCode == http.StatusNotFound {
+			if errors.Is(err, githubclient.ErrPullRequestNotFound) ||
+				(errors.As(err, &githubErr) && githubErr.Response.StatusCode == http.StatusNotFound) {
 				log.Info().Msgf("Pull Request #%s was not found in %s/%s. Please check if the PR number and repository are correct.", pr, repoOwner, repoName)
 				return
 			}
-			log.Fatal().Msgf("Error retrieving pull request state: %v", err)
+			log.Fatal().Msgf("Error retrieving pull request: %v", err)
 		}
+		prState := prData.State
+		ctx = context.WithValue(ctx, "prURL", prData.URL)
+		ctx = context.WithValue(ctx, "labels", prData.Labels)
 
 		if prState.IsClosed || prState.IsMerged {
 			statusMsg := "merged"
@@ -100,41 +139,105 @@ var PingCmd = &cobra.Command{
 				statusMsg = "closed"
 			}
 			log.Info().Msgf("Pull Request #%s is %s. No need to ping reviewers.", pr, statusMsg)
+			resolve(ctx, fmt.Sprintf("PR #%s was %s", pr, statusMsg))
 			return
 		}
 
 		if prState.IsDraft {
 			log.Info().Msgf("Pull Request #%s is in draft mode. Skipping pinging reviewers.", pr)
+			resolve(ctx, fmt.Sprintf("PR #%s went back to draft", pr))
 			return
 		}
 
 		log.Debug().Msgf("Pull Request #%s is open. Proceeding with reviewer checks.", pr)
 
-		// Get review requests
-		reviewRequests, err := githubclient.GetReviewRequests(client, repoOwner, repoName, pr)
-		if err != nil {
-			// Check if the error is because the PR was not found
-			var githubErr *github.ErrorResponse
-			if errors.As(err, &githubErr) && githubErr.Response.StatusCode == http.StatusNotFound {
-				log.Info().Msgf("Pull Request #%s was not found in %s/%s. Please check if the PR number and repository are correct.", pr, repoOwner, repoName)
-				return
+		// Parse rules from config
+		ruleset := rules.ParseRules()
+
+		// Summarize the CI status when rules gate pings on it or authors are nudged about failing
+		// checks, which templates can then show too
+		checksProvider, ok := prov.(githubclient.ChecksProvider)
+		if ok && (rules.GatesOnChecks(ctx, ruleset) || viper.GetBool("nudgeAuthor")) {
+			checks, err := checksProvider.GetChecksStatus(ctx, repoOwner, repoName, prData)
+			if err != nil {
+				log.Warn().Msgf("Could not retrieve checks status, pinging regardless of CI: %v", err)
+			} else {
+				log.Debug().Msgf("Checks are %s (required: %s), mergeable state is %s", checks.State, checks.RequiredState, checks.MergeableState)
+				ctx = context.WithValue(ctx, "checks", checks)
+			}
+		}
+
+		// Ping the members of requested teams rather than the teams themselves
+		if viper.GetBool("expandTeams") {
+			if teamsProvider, ok := prov.(githubclient.TeamsProvider); ok {
+				if err := teamsProvider.ExpandTeams(ctx, repoOwner, prData); err != nil {
+					log.Warn().Msgf("Could not expand teams into their members, pinging teams instead: %v", err)
+				}
+			} else {
+				log.Warn().Msgf("Team expansion is not supported by the %s provider", prov.Name())
+			}
+		}
+
+		// When the pull request is waiting on its author, nudge them instead of the reviewers it
+		// waits on: everybody, except for unresolved threads which only hold back their starters
+		var authorNudge *ping.PingRequest
+		if viper.GetBool("nudgeAuthor") {
+			checks, _ := ctx.Value("checks").(*githubclient.ChecksStatus)
+			if reasons, since := prData.WaitingOnAuthor(checks); len(reasons) > 0 {
+				log.Info().Msgf("Pull Request #%s is waiting on its author: %s", pr, strings.Join(reasons, ", "))
+				ctx = context.WithValue(ctx, "waitingOnAuthor", reasons)
+				ctx = context.WithValue(ctx, "unresolvedThreadAuthors", prData.UnresolvedThreadAuthors)
+				nudge := rules.NudgeAuthor(ctx, githubclient.ReviewRequest{
+					From:     prData.Author,
+					On:       since,
+					PRTitle:  prData.Title,
+					PRAuthor: prData.Author,
+				}, reasons, ruleset)
+				authorNudge = &nudge
 			}
-			log.Fatal().Msgf("Error retrieving review requests: %v", err)
 		}
 
-		if len(reviewRequests) == 0 {
+		reviewRequests := prData.ReviewRequests
+		if len(reviewRequests) == 0 && authorNudge == nil {
 			log.Info().Msgf("No reviewers found for PR #%s.\n", pr)
+			resolve(ctx, fmt.Sprintf("All reviews on PR #%s landed", pr))
 			return
 		}
 
-		// Parse rules from config
-		ruleset := rules.ParseRules()
-
 		// Enrich review requests data with rules
 		pingRequests := rules.ApplyRules(ctx, reviewRequests, ruleset)
 
-		// Store all ping requests in context
+		// Reviews are still outstanding when nobody is due yet, e.g. snoozed or within their delay,
+		// so previous notifications are kept as they are
+		if !slices.ContainsFunc(pingRequests, func(req ping.PingRequest) bool { return req.ShouldPing }) &&
+			(authorNudge == nil || !authorNudge.ShouldPing) {
+			log.Info().Msgf("Nobody needs to be pinged on PR #%s yet.", pr)
+			return
+		}
+
+		// Resolve the chat identities of the people to notify so that integrations can mention them
+		directories, err := identity.FromConfig()
+		if err != nil {
+			log.Fatal().Msgf("Error loading identities: %v", err)
+		}
+		if githubProvider, ok := prov.(*githubclient.GitHubProvider); ok {
+			for _, directory := range directories {
+				directory.EmailLookup = githubProvider.UserEmail
+			}
+		}
+		for i := range pingRequests {
+			if pingRequests[i].ShouldPing {
+				pingRequests[i].ChatIDs = directories.Lookup(ctx, pingRequests[i].Req)
+			}
+		}
+		if authorNudge != nil && authorNudge.ShouldPing {
+			authorNudge.ChatIDs = directories.Lookup(ctx, authorNudge.Req)
+		}
+
+		// Store all ping requests in context. Integrations get the requests they notify in
+		// "pingRequests", and can report on everybody else with "allPingRequests".
 		ctx = context.WithValue(ctx, "pingRequests", pingRequests)
+		ctx = context.WithValue(ctx, "allPingRequests", pingRequests)
 
 		// Group ping requests by integration type
 		integrationGroups := make(map[string][]ping.PingRequest)
@@ -159,24 +262,53 @@ var PingCmd = &cobra.Command{
 				continue
 			}
 
-			// Create a new context with just the requests for this integration
-			integrationCtx := context.WithValue(ctx, "pingRequests", requests)
+			// Create a new context with just the requests for this integration, and the chat IDs
+			// of its platform
+			integrationCtx := context.WithValue(ctx, "pingRequests", ping.ForIntegration(requests, integrationType))
+			integrationCtx = context.WithValue(integrationCtx, "allPingRequests", ping.ForIntegration(pingRequests, integrationType))
 
 			// Execute the integration
 			integrationFunc.Run(integrationCtx)
 		}
+
+		// The author is notified separately so that integrations can use their author template
+		if authorNudge != nil && authorNudge.ShouldPing {
+			for _, integration := range authorNudge.Integrations {
+				integrationFunc, ok := integrations.Integrations[integration.Type]
+				if !ok {
+					log.Printf("Warning: Unknown integration: %s, skipping author nudge", integration.Type)
+					continue
+				}
+				integrationFunc.Run(context.WithValue(ctx, "pingRequests", ping.ForIntegration([]ping.PingRequest{*authorNudge}, integration.Type)))
+			}
+		}
 	},
 }
 
+// resolve lets the integrations that keep track of their notifications know that the pull request
+// no longer waits on reviews
+func resolve(ctx context.Context, resolution string) {
+	ctx = context.WithValue(ctx, "resolution", resolution)
+	for _, integration := range integrations.Integrations {
+		if integration.Resolve != nil {
+			integration.Resolve(ctx)
+		}
+	}
+}
+
 func init() {
 	PingCmd.PersistentFlags().StringVarP(&repository, "repository", "r", repository, "Repository in the format owner/repo (auto-detected if not specified)")
 	PingCmd.PersistentFlags().StringVar(&pr, "pr", pr, "Pull Request number")
 	PingCmd.PersistentFlags().IntVarP(&delay, "delay", "d", 0, "Delay in seconds before pinging reviewers (default: 0, ping immediately)")
 	PingCmd.PersistentFlags().BoolVar(&enabled, "enabled", true, "Enable or disable pinging functionality (default: true)")
+	PingCmd.PersistentFlags().Bool("nudge-author", false, "Notify the PR author instead of reviewers when the PR is waiting on them")
 	err := viper.BindPFlags(PingCmd.PersistentFlags())
 	if err != nil {
 		log.Fatal().Msgf("Error binding flags: %v", err)
 	}
+	if err := viper.BindPFlag("nudgeAuthor", PingCmd.PersistentFlags().Lookup("nudge-author")); err != nil {
+		log.Fatal().Msgf("Error binding flags: %v", err)
+	}
 	viper.SetDefault("delay", 0)
 	viper.SetDefault("enabled", true)
 }
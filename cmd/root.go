@@ -5,7 +5,9 @@ import (
 	"os"
 	"strings"
 
+	"github.com/Djiit/gong/cmd/auth"
 	"github.com/Djiit/gong/cmd/ping"
+	"github.com/Djiit/gong/cmd/serve"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -13,11 +15,21 @@ import (
 )
 
 var (
-	cfgFile     string
-	logLevel    string
-	dryRun      bool
-	githubToken string
-	rootCmd     = &cobra.Command{
+	cfgFile      string
+	logLevel     string
+	dryRun       bool
+	githubToken  string
+	githubURL    string
+	providerName string
+	gitlabToken  string
+	gitlabURL    string
+	giteaToken   string
+	giteaURL     string
+	bbToken      string
+	bbURL        string
+	maxAPICalls  int
+	httpCache    bool
+	rootCmd      = &cobra.Command{
 		Use:     "gong",
 		Long:    "gong is a CLI tool to ping reviewers.",
 		Example: "gong",
@@ -54,8 +66,19 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "Config file (default is $HOME/.gong.yaml)")
-	rootCmd.PersistentFlags().StringVar(&githubToken, "github-token", "", "GitHub token")
+	rootCmd.PersistentFlags().StringVar(&providerName, "provider", "github", "Code host provider: github, gitlab, gitea, forgejo or bitbucket")
+	rootCmd.PersistentFlags().StringVar(&gitlabToken, "gitlab-token", "", "GitLab token (defaults to GITLAB_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&gitlabURL, "gitlab-url", "https://gitlab.com", "GitLab instance URL")
+	rootCmd.PersistentFlags().StringVar(&giteaToken, "gitea-token", "", "Gitea/Forgejo token (defaults to GITEA_TOKEN or FORGEJO_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&giteaURL, "gitea-url", "", "Gitea/Forgejo instance URL")
+	rootCmd.PersistentFlags().StringVar(&bbToken, "bitbucket-token", "", "Bitbucket Server/Data Center HTTP access token (defaults to BITBUCKET_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&bbURL, "bitbucket-url", "", "Bitbucket Server/Data Center instance URL")
+	rootCmd.PersistentFlags().StringVar(&githubToken, "github-token", "", "GitHub token (defaults to the gh CLI credentials, GH_TOKEN or GITHUB_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&githubURL, "github-url", "", "GitHub Enterprise Server instance URL (default: github.com)")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "info", "Log level. (default: info)")
+	rootCmd.PersistentFlags().IntVar(&maxAPICalls, "max-api-calls", 0, "Maximum number of GitHub API calls per run (default: 0, unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&httpCache, "http-cache", false, "Cache GitHub API responses on disk and revalidate them with ETags (default: false)")
+	rootCmd.PersistentFlags().String("state-file", "", "File where gong remembers what previous runs did (default: gong/state.json in the user cache directory)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Run in dry-run mode. (default: false)")
 	err := viper.BindPFlags(rootCmd.PersistentFlags())
 	if err != nil {
@@ -67,6 +90,8 @@ func init() {
 
 	// Add subcommands
 	rootCmd.AddCommand(ping.PingCmd)
+	rootCmd.AddCommand(auth.AuthCmd)
+	rootCmd.AddCommand(serve.ServeCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,105 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Djiit/gong/internal/identity"
+	"github.com/Djiit/gong/internal/integrations/slack"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// SlackInteractivePath is where Slack sends interactive payloads, to set as the Request URL of
+// the Slack app
+const SlackInteractivePath = "/slack/interactive"
+
+// ServeCmd represents the serve command
+var ServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Handle the buttons of Slack messages",
+	Long: `Run an HTTP server receiving the Slack interactive payloads sent when reviewers click the
+snooze and acknowledge buttons of gong messages. Snoozes are saved in the state file, which must
+be shared with the ping runs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		secret := viper.GetString("slack-signing-secret")
+		if secret == "" {
+			log.Fatal().Msg("No Slack signing secret configured: use --slack-signing-secret or set GONG_SLACK_SIGNING_SECRET")
+		}
+
+		directories, err := identity.FromConfig()
+		if err != nil {
+			log.Fatal().Msgf("Error loading identities: %v", err)
+		}
+
+		// Fail early if the state cannot be opened rather than on the first click
+		if _, err := state.FromConfig(); err != nil {
+			log.Fatal().Msgf("Error opening the state: %v", err)
+		}
+
+		interactions := NewInteractionHandler(secret, directories.Platform("slack"))
+		server := &http.Server{
+			Addr:              viper.GetString("listen"),
+			Handler:           NewHandler(interactions),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		shutdown := make(chan struct{})
+		go func() {
+			defer close(shutdown)
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				log.Error().Err(err).Msg("Error shutting down the server")
+			}
+		}()
+
+		log.Info().Msgf("Listening on %s, Slack interactive payloads are expected on %s", server.Addr, SlackInteractivePath)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Msgf("Error serving: %v", err)
+		}
+
+		// Clicks are saved in the background once acknowledged, let them finish
+		<-shutdown
+		interactions.Wait()
+	},
+}
+
+// NewInteractionHandler returns the handler of the Slack buttons, saving clicks to the configured state
+func NewInteractionHandler(signingSecret string, directory *identity.Directory) *slack.InteractionHandler {
+	return &slack.InteractionHandler{
+		SigningSecret: signingSecret,
+		Directory:     directory,
+		OpenState:     state.FromConfig,
+	}
+}
+
+// NewHandler returns the routes served by gong serve
+func NewHandler(interactions *slack.InteractionHandler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(SlackInteractivePath, interactions)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func init() {
+	ServeCmd.PersistentFlags().String("listen", ":8080", "Address to listen on")
+	ServeCmd.PersistentFlags().String("slack-signing-secret", "", "Signing secret of the Slack app, used to verify interactive payloads")
+	err := viper.BindPFlags(ServeCmd.PersistentFlags())
+	if err != nil {
+		log.Fatal().Msgf("Error binding flags: %v", err)
+	}
+}
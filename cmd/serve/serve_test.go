@@ -0,0 +1,24 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Djiit/gong/internal/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandler(t *testing.T) {
+	handler := NewHandler(NewInteractionHandler("secret", identity.New()))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Unsigned interactive payloads are rejected
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, SlackInteractivePath, strings.NewReader("payload={}")))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,279 @@
+package bitbucketclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/restclient"
+	"github.com/rs/zerolog/log"
+)
+
+// pageSize is the number of items requested per page on paged endpoints
+const pageSize = 100
+
+// Client implements githubclient.Provider for Bitbucket Server and Data Center pull requests.
+// The repository owner is the Bitbucket project key and the repository name its slug.
+type Client struct {
+	api *restclient.Client
+}
+
+// NewClient creates a Bitbucket client for the instance at instanceURL (e.g. https://bitbucket.example.com)
+func NewClient(instanceURL, token string, opts githubclient.TransportOptions) (*Client, error) {
+	if instanceURL == "" {
+		return nil, fmt.Errorf("no Bitbucket URL configured: use --bitbucket-url or set GONG_BITBUCKET_URL")
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(instanceURL, "/") + "/rest/api/1.0/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid Bitbucket URL %q: %w", instanceURL, err)
+	}
+
+	return &Client{api: &restclient.Client{
+		Service:      "bitbucket",
+		BaseURL:      baseURL,
+		HTTPClient:   restclient.NewHTTPClient(opts),
+		Authenticate: func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) },
+		ErrorMessage: errorMessage,
+	}}, nil
+}
+
+type user struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type participant struct {
+	User   user   `json:"user"`
+	Status string `json:"status"` // UNAPPROVED, APPROVED or NEEDS_WORK
+}
+
+type pullRequest struct {
+	ID          int           `json:"id"`
+	Title       string        `json:"title"`
+	State       string        `json:"state"` // OPEN, MERGED or DECLINED
+	Draft       bool          `json:"draft"`
+	CreatedDate int64         `json:"createdDate"`
+	UpdatedDate int64         `json:"updatedDate"`
+	Author      participant   `json:"author"`
+	Reviewers   []participant `json:"reviewers"`
+	Links       struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+type activity struct {
+	Action           string `json:"action"`
+	CreatedDate      int64  `json:"createdDate"`
+	User             user   `json:"user"`
+	AddedReviewers   []user `json:"addedReviewers"`
+	RemovedReviewers []user `json:"removedReviewers"`
+	Comment          *struct {
+		Text string `json:"text"`
+	} `json:"comment"`
+}
+
+type page struct {
+	Values        json.RawMessage `json:"values"`
+	IsLastPage    bool            `json:"isLastPage"`
+	NextPageStart int             `json:"nextPageStart"`
+}
+
+// reviewStates maps Bitbucket participant statuses onto the GitHub review states used by gong
+var reviewStates = map[string]string{
+	"APPROVED":   "APPROVED",
+	"NEEDS_WORK": "CHANGES_REQUESTED",
+}
+
+func (c *Client) Name() string {
+	return "bitbucket"
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo, prNumber string) (*githubclient.PullRequestData, error) {
+	prPath := repoPath(owner, repo) + "/pull-requests/" + url.PathEscape(prNumber)
+
+	var pr pullRequest
+	if err := c.api.Do(ctx, http.MethodGet, prPath, nil, &pr); err != nil {
+		return nil, restclient.NotFound(err)
+	}
+
+	log.Debug().Msgf("Working on PR %s/%s#%d : '%s' by %s", owner, repo, pr.ID, pr.Title, pr.Author.User.Name)
+
+	activities, err := c.listActivities(ctx, prPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &githubclient.PullRequestData{
+		Number: pr.ID,
+		Title:  pr.Title,
+		Author: pr.Author.User.Name,
+		State: githubclient.PullRequestState{
+			IsOpen:    pr.State == "OPEN",
+			IsMerged:  pr.State == "MERGED",
+			IsClosed:  pr.State == "DECLINED",
+			IsDraft:   pr.Draft,
+			CreatedAt: fromMillis(pr.CreatedDate),
+			UpdatedAt: fromMillis(pr.UpdatedDate),
+		},
+	}
+	if len(pr.Links.Self) > 0 {
+		data.URL = pr.Links.Self[0].Href
+	}
+
+	requestTimestamps, reviewTimestamps := activityTimestamps(activities)
+
+	for _, reviewer := range pr.Reviewers {
+		if state, reviewed := reviewStates[reviewer.Status]; reviewed {
+			submittedAt, exists := reviewTimestamps[reviewer.User.Name]
+			if !exists {
+				submittedAt = data.State.UpdatedAt
+			}
+			data.Reviews = append(data.Reviews, githubclient.Review{
+				Author:      reviewer.User.Name,
+				State:       state,
+				SubmittedAt: submittedAt,
+			})
+			continue
+		}
+
+		// Reviewers added when the pull request was opened have no activity of their own
+		timestamp, exists := requestTimestamps[reviewer.User.Name]
+		if !exists {
+			timestamp = data.State.CreatedAt
+		}
+		data.ReviewRequests = append(data.ReviewRequests, githubclient.ReviewRequest{
+			From:     reviewer.User.Name,
+			On:       timestamp,
+			PRTitle:  pr.Title,
+			PRAuthor: pr.Author.User.Name,
+		})
+	}
+
+	for _, a := range activities {
+		if a.Action == "COMMENTED" {
+			data.Comments = append(data.Comments, githubclient.Comment{Author: a.User.Name, CreatedAt: fromMillis(a.CreatedDate)})
+		}
+	}
+
+	data.AnnotateReviewRequests()
+	return data, nil
+}
+
+func (c *Client) ListComments(ctx context.Context, owner, repo, prNumber string) ([]string, error) {
+	activities, err := c.listActivities(ctx, repoPath(owner, repo)+"/pull-requests/"+url.PathEscape(prNumber))
+	if err != nil {
+		return nil, restclient.NotFound(err)
+	}
+
+	var bodies []string
+	for _, a := range activities {
+		if a.Action == "COMMENTED" && a.Comment != nil {
+			bodies = append(bodies, a.Comment.Text)
+		}
+	}
+
+	return bodies, nil
+}
+
+func (c *Client) PostComment(ctx context.Context, owner, repo, prNumber, body string) error {
+	path := repoPath(owner, repo) + "/pull-requests/" + url.PathEscape(prNumber) + "/comments"
+	return c.api.Do(ctx, http.MethodPost, path, map[string]string{"text": body}, nil)
+}
+
+func (c *Client) listActivities(ctx context.Context, prPath string) ([]activity, error) {
+	var activities []activity
+
+	err := c.getPaged(ctx, prPath+"/activities", func(values json.RawMessage) error {
+		var page []activity
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		activities = append(activities, page...)
+		return nil
+	})
+
+	return activities, err
+}
+
+// activityTimestamps returns when each reviewer was last added to the pull request and when
+// they last approved or reviewed it. Bitbucket lists activities newest first, so the first
+// matching activity wins.
+func activityTimestamps(activities []activity) (map[string]time.Time, map[string]time.Time) {
+	requestTimestamps := make(map[string]time.Time)
+	reviewTimestamps := make(map[string]time.Time)
+	removed := make(map[string]bool)
+
+	for _, a := range activities {
+		switch a.Action {
+		case "UPDATED":
+			for _, r := range a.RemovedReviewers {
+				if _, seen := requestTimestamps[r.Name]; !seen {
+					removed[r.Name] = true
+				}
+			}
+			for _, r := range a.AddedReviewers {
+				if _, seen := requestTimestamps[r.Name]; !seen && !removed[r.Name] {
+					requestTimestamps[r.Name] = fromMillis(a.CreatedDate)
+				}
+			}
+		case "APPROVED", "REVIEWED":
+			if _, seen := reviewTimestamps[a.User.Name]; !seen {
+				reviewTimestamps[a.User.Name] = fromMillis(a.CreatedDate)
+			}
+		}
+	}
+
+	return requestTimestamps, reviewTimestamps
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+func repoPath(owner, repo string) string {
+	return "projects/" + url.PathEscape(owner) + "/repos/" + url.PathEscape(repo)
+}
+
+// getPaged calls handle with the values of every page of a paged endpoint
+func (c *Client) getPaged(ctx context.Context, path string, handle func(json.RawMessage) error) error {
+	return c.api.Paginate(ctx, pagePath(path, 0), func(_ *http.Response, data []byte) (string, error) {
+		var p page
+		if err := json.Unmarshal(data, &p); err != nil {
+			return "", err
+		}
+		if err := handle(p.Values); err != nil {
+			return "", err
+		}
+		if p.IsLastPage {
+			return "", nil
+		}
+		return pagePath(path, p.NextPageStart), nil
+	})
+}
+
+func pagePath(path string, start int) string {
+	return restclient.WithQuery(path, fmt.Sprintf("limit=%d&start=%d", pageSize, start))
+}
+
+// errorMessage joins the messages of an error response
+func errorMessage(body []byte) string {
+	var apiErr struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	var messages []string
+	for _, e := range apiErr.Errors {
+		messages = append(messages, e.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,120 @@
+package bitbucketclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/stretchr/testify/assert"
+)
+
+const prAPIPath = "/rest/api/1.0/projects/PROJ/repos/repo/pull-requests"
+
+func newTestClient(t *testing.T, posted *[]string) *Client {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body string
+		switch {
+		case r.Method == "GET" && r.URL.Path == prAPIPath+"/8":
+			body = `{
+				"id": 8,
+				"title": "Add feature",
+				"state": "OPEN",
+				"createdDate": 1680350400000,
+				"updatedDate": 1680354000000,
+				"author": {"user": {"name": "author"}},
+				"reviewers": [
+					{"user": {"name": "alice"}, "status": "UNAPPROVED"},
+					{"user": {"name": "bob"}, "status": "UNAPPROVED"},
+					{"user": {"name": "carol"}, "status": "NEEDS_WORK"}
+				],
+				"links": {"self": [{"href": "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/8"}]}
+			}`
+		case r.Method == "GET" && r.URL.Path == prAPIPath+"/8/activities" && r.URL.Query().Get("start") == "0":
+			body = `{"isLastPage": false, "nextPageStart": 2, "values": [
+				{"action": "COMMENTED", "createdDate": 1680444000000, "user": {"name": "dave"}, "comment": {"text": "Any update?"}},
+				{"action": "REVIEWED", "createdDate": 1680440400000, "user": {"name": "carol"}}
+			]}`
+		case r.Method == "GET" && r.URL.Path == prAPIPath+"/8/activities":
+			body = `{"isLastPage": true, "values": [
+				{"action": "UPDATED", "createdDate": 1680426000000, "user": {"name": "author"}, "addedReviewers": [{"name": "bob"}]},
+				{"action": "UPDATED", "createdDate": 1680357600000, "user": {"name": "author"}, "removedReviewers": [{"name": "bob"}]},
+				{"action": "OPENED", "createdDate": 1680350400000, "user": {"name": "author"}}
+			]}`
+		case r.Method == "POST" && r.URL.Path == prAPIPath+"/8/comments":
+			var comment map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+				t.Fatalf("Failed to decode comment: %v", err)
+			}
+			*posted = append(*posted, comment["text"])
+			w.WriteHeader(http.StatusCreated)
+			body = `{}`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"errors": [{"message": "Pull request 99 does not exist in PROJ/repo."}]}`
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("Failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client, err := NewClient(mockServer.URL, "test-token", githubclient.TransportOptions{})
+	assert.NoError(t, err)
+	return client
+}
+
+func TestGetPullRequest(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	data, err := client.GetPullRequest(context.Background(), "PROJ", "repo", "8")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Add feature", data.Title)
+	assert.Equal(t, "author", data.Author)
+	assert.Equal(t, "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/8", data.URL)
+	assert.True(t, data.State.IsOpen)
+	assert.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), data.State.CreatedAt)
+
+	assert.Len(t, data.ReviewRequests, 2)
+	// alice was a reviewer from the start
+	assert.Equal(t, "alice", data.ReviewRequests[0].From)
+	assert.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), data.ReviewRequests[0].On)
+	// bob was removed then added back
+	assert.Equal(t, "bob", data.ReviewRequests[1].From)
+	assert.Equal(t, time.Date(2023, 4, 2, 9, 0, 0, 0, time.UTC), data.ReviewRequests[1].On)
+
+	assert.Equal(t, []githubclient.Review{
+		{Author: "carol", State: "CHANGES_REQUESTED", SubmittedAt: time.Date(2023, 4, 2, 13, 0, 0, 0, time.UTC)},
+	}, data.Reviews)
+}
+
+func TestGetPullRequestNotFound(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	_, err := client.GetPullRequest(context.Background(), "PROJ", "repo", "99")
+	assert.ErrorIs(t, err, githubclient.ErrPullRequestNotFound)
+}
+
+func TestComments(t *testing.T) {
+	var posted []string
+	client := newTestClient(t, &posted)
+
+	comments, err := client.ListComments(context.Background(), "PROJ", "repo", "8")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Any update?"}, comments)
+
+	err = client.PostComment(context.Background(), "PROJ", "repo", "8", "Please review\n<!-- gong -->")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Please review\n<!-- gong -->"}, posted)
+}
@@ -1,9 +1,12 @@
 package format
 
 import (
+	"bytes"
 	"fmt"
+	"text/template"
 	"time"
 
+	"github.com/Djiit/gong/internal/githubclient"
 	"github.com/Djiit/gong/internal/ping"
 )
 
@@ -31,6 +34,13 @@ func FormatDuration(d time.Duration) string {
 	return "just now"
 }
 
+// Mention is a reviewer to notify, with their chat ID when the identity directory knows them
+type Mention struct {
+	Name   string // Reviewer as displayed in ActiveReviewers
+	ChatID string // Chat user ID or group handle, empty if unknown
+	IsTeam bool
+}
+
 // TemplateData holds the data for template rendering across all integrations
 type TemplateData struct {
 	PingRequests      []ping.PingRequest
@@ -41,14 +51,49 @@ type TemplateData struct {
 	RepoOwner string
 	RepoName  string
 	PRURL     string
+	// Title and author of the pull request, and how long its oldest review request has been waiting
+	PRTitle  string
+	PRAuthor string
+	Age      string
+	// Labels of the pull request, set by the integrations that display them
+	Labels []string
+	// CI status and mergeability of the pull request, nil when unknown
+	Checks *githubclient.ChecksStatus
+	// Active reviewers along with their chat IDs, so that templates can emit real mentions
+	Mentions []Mention
+	// PR author and why the PR is waiting on them, set when nudging the author
+	Author          string
+	WaitingOnAuthor []string
 }
 
 // PrepareTemplateData prepares template data from ping requests and optional PR metadata
 func PrepareTemplateData(pingRequests []ping.PingRequest, repoOwner, repoName, prNumber, prURL string, includeFullInfo bool) TemplateData {
 	var activeReviewers []string
+	var mentions []Mention
 	var disabledReviewers []string
+	var checks *githubclient.ChecksStatus
+	var author string
+	var waitingOnAuthor []string
+	var prTitle, prAuthor string
+	var oldest time.Time
 
 	for _, req := range pingRequests {
+		if prTitle == "" {
+			prTitle, prAuthor = req.Req.PRTitle, req.Req.PRAuthor
+		}
+		if oldest.IsZero() || req.Req.On.Before(oldest) {
+			oldest = req.Req.On
+		}
+		if req.Checks != nil {
+			checks = req.Checks
+		}
+		if req.AuthorNudge {
+			author = req.Req.From
+		}
+		if len(req.WaitingOnAuthor) > 0 {
+			waitingOnAuthor = req.WaitingOnAuthor
+		}
+
 		timeSinceRequest := time.Since(req.Req.On).Round(time.Hour)
 		formattedDuration := FormatDuration(timeSinceRequest)
 
@@ -67,15 +112,17 @@ func PrepareTemplateData(pingRequests []ping.PingRequest, repoOwner, repoName, p
 			} else {
 				activeReviewers = append(activeReviewers, reviewer)
 			}
+			mentions = append(mentions, Mention{Name: reviewer, ChatID: req.ChatID, IsTeam: req.Req.IsTeam})
 		} else {
-			status := "waiting"
-			if !req.Enabled {
-				status = "disabled"
-			}
-			disabledReviewers = append(disabledReviewers, fmt.Sprintf("%s, status: %s", reviewerInfo, status))
+			disabledReviewers = append(disabledReviewers, fmt.Sprintf("%s, status: %s", reviewerInfo, req.Status()))
 		}
 	}
 
+	var age string
+	if !oldest.IsZero() {
+		age = FormatDuration(time.Since(oldest).Round(time.Hour))
+	}
+
 	return TemplateData{
 		PingRequests:      pingRequests,
 		ActiveReviewers:   activeReviewers,
@@ -84,5 +131,28 @@ func PrepareTemplateData(pingRequests []ping.PingRequest, repoOwner, repoName, p
 		RepoOwner:         repoOwner,
 		RepoName:          repoName,
 		PRURL:             prURL,
+		PRTitle:           prTitle,
+		PRAuthor:          prAuthor,
+		Age:               age,
+		Checks:            checks,
+		Mentions:          mentions,
+		Author:            author,
+		WaitingOnAuthor:   waitingOnAuthor,
+	}
+}
+
+// FormatTemplate renders a message template with the mention function of a chat platform. It is
+// shared by the integrations whose messages only differ in how they mention reviewers.
+func FormatTemplate(templateStr string, data TemplateData, mention func(Mention) string) (string, error) {
+	tmpl, err := template.New("message").Funcs(template.FuncMap{"mention": mention}).Parse(templateStr)
+	if err != nil {
+		return "", fmt.Errorf("template parsing error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("template execution error: %w", err)
 	}
+
+	return buf.String(), nil
 }
@@ -103,3 +103,26 @@ func TestPrepareTemplateData(t *testing.T) {
 		assert.Contains(t, data.DisabledReviewers[0], "team1 (team)")
 	})
 }
+
+func TestFormatTemplate(t *testing.T) {
+	data := TemplateData{
+		PRNumber: "7",
+		Mentions: []Mention{{Name: "alice", ChatID: "42"}, {Name: "bob"}},
+	}
+	mention := func(m Mention) string {
+		if m.ChatID == "" {
+			return m.Name
+		}
+		return "@" + m.ChatID
+	}
+
+	text, err := FormatTemplate(`#{{.PRNumber}}: {{ range .Mentions }}{{ mention . }} {{ end }}`, data, mention)
+	assert.NoError(t, err)
+	assert.Equal(t, "#7: @42 bob ", text)
+
+	_, err = FormatTemplate("{{ .Unknown", data, mention)
+	assert.ErrorContains(t, err, "template parsing error")
+
+	_, err = FormatTemplate("{{ .Unknown }}", data, mention)
+	assert.ErrorContains(t, err, "template execution error")
+}
@@ -0,0 +1,283 @@
+package giteaclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/restclient"
+	"github.com/rs/zerolog/log"
+)
+
+// pageSize is the number of items requested per page on list endpoints, which instances may lower
+const pageSize = 50
+
+// Client implements githubclient.Provider for Gitea and Forgejo pull requests
+type Client struct {
+	api *restclient.Client
+}
+
+// NewClient creates a Gitea client for the instance at instanceURL (e.g. https://codeberg.org)
+func NewClient(instanceURL, token string, opts githubclient.TransportOptions) (*Client, error) {
+	if instanceURL == "" {
+		return nil, fmt.Errorf("no Gitea URL configured: use --gitea-url or set GONG_GITEA_URL")
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(instanceURL, "/") + "/api/v1/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid Gitea URL %q: %w", instanceURL, err)
+	}
+
+	return &Client{api: &restclient.Client{
+		Service:      "gitea",
+		BaseURL:      baseURL,
+		HTTPClient:   restclient.NewHTTPClient(opts),
+		Authenticate: func(req *http.Request) { req.Header.Set("Authorization", "token "+token) },
+		ErrorMessage: errorMessage,
+	}}, nil
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+type team struct {
+	Name string `json:"name"`
+}
+
+type pullRequest struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	Merged    bool      `json:"merged"`
+	Draft     bool      `json:"draft"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      user      `json:"user"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	RequestedReviewers      []user `json:"requested_reviewers"`
+	RequestedReviewersTeams []team `json:"requested_reviewers_teams"`
+}
+
+type timelineEvent struct {
+	Type            string    `json:"type"`
+	CreatedAt       time.Time `json:"created_at"`
+	Assignee        *user     `json:"assignee"`
+	AssigneeTeam    *team     `json:"assignee_team"`
+	RemovedAssignee bool      `json:"removed_assignee"`
+}
+
+type review struct {
+	User        user      `json:"user"`
+	State       string    `json:"state"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type comment struct {
+	Body string `json:"body"`
+}
+
+// reviewStates maps Gitea review states onto the GitHub ones used by gong
+var reviewStates = map[string]string{
+	"APPROVED":        "APPROVED",
+	"REQUEST_CHANGES": "CHANGES_REQUESTED",
+	"COMMENT":         "COMMENTED",
+	"PENDING":         "PENDING",
+	"REQUEST_REVIEW":  "PENDING",
+}
+
+func (c *Client) Name() string {
+	return "gitea"
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo, prNumber string) (*githubclient.PullRequestData, error) {
+	var pr pullRequest
+	if err := c.api.Do(ctx, http.MethodGet, repoPath(owner, repo)+"/pulls/"+url.PathEscape(prNumber), nil, &pr); err != nil {
+		return nil, restclient.NotFound(err)
+	}
+
+	log.Debug().Msgf("Working on PR %s/%s#%d : '%s' by %s", owner, repo, pr.Number, pr.Title, pr.User.Login)
+
+	var timeline []timelineEvent
+	err := c.getPaginated(ctx, repoPath(owner, repo)+"/issues/"+url.PathEscape(prNumber)+"/timeline", func(data []byte) (int, error) {
+		var page []timelineEvent
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, err
+		}
+		timeline = append(timeline, page...)
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []review
+	err = c.getPaginated(ctx, repoPath(owner, repo)+"/pulls/"+url.PathEscape(prNumber)+"/reviews", func(data []byte) (int, error) {
+		var page []review
+		if err := json.Unmarshal(data, &page); err != nil {
+			return 0, err
+		}
+		reviews = append(reviews, page...)
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &githubclient.PullRequestData{
+		Number: pr.Number,
+		Title:  pr.Title,
+		Author: pr.User.Login,
+		URL:    pr.HTMLURL,
+		State: githubclient.PullRequestState{
+			IsOpen:    pr.State == "open",
+			IsMerged:  pr.Merged,
+			IsClosed:  pr.State == "closed" && !pr.Merged,
+			IsDraft:   pr.Draft,
+			CreatedAt: pr.CreatedAt,
+			UpdatedAt: pr.UpdatedAt,
+		},
+	}
+
+	for _, label := range pr.Labels {
+		data.Labels = append(data.Labels, label.Name)
+	}
+
+	for _, r := range reviews {
+		data.Reviews = append(data.Reviews, githubclient.Review{
+			Author:      r.User.Login,
+			State:       reviewStates[r.State],
+			SubmittedAt: r.SubmittedAt,
+		})
+	}
+
+	reviewerTimestamps, teamTimestamps := reviewRequestTimestamps(timeline)
+
+	for _, reviewer := range pr.RequestedReviewers {
+		data.ReviewRequests = append(data.ReviewRequests, newReviewRequest(reviewer.Login, false, reviewerTimestamps, pr))
+	}
+	for _, t := range pr.RequestedReviewersTeams {
+		data.ReviewRequests = append(data.ReviewRequests, newReviewRequest(t.Name, true, teamTimestamps, pr))
+	}
+
+	data.AnnotateReviewRequests()
+	return data, nil
+}
+
+func (c *Client) ListComments(ctx context.Context, owner, repo, prNumber string) ([]string, error) {
+	var bodies []string
+
+	err := c.getPaginated(ctx, repoPath(owner, repo)+"/issues/"+url.PathEscape(prNumber)+"/comments", func(data []byte) (int, error) {
+		var comments []comment
+		if err := json.Unmarshal(data, &comments); err != nil {
+			return 0, err
+		}
+		for _, c := range comments {
+			bodies = append(bodies, c.Body)
+		}
+		return len(comments), nil
+	})
+
+	return bodies, restclient.NotFound(err)
+}
+
+func (c *Client) PostComment(ctx context.Context, owner, repo, prNumber, body string) error {
+	path := repoPath(owner, repo) + "/issues/" + url.PathEscape(prNumber) + "/comments"
+	return c.api.Do(ctx, http.MethodPost, path, comment{Body: body}, nil)
+}
+
+func newReviewRequest(from string, isTeam bool, timestamps map[string]time.Time, pr pullRequest) githubclient.ReviewRequest {
+	timestamp, exists := timestamps[from]
+	if !exists {
+		// If we couldn't find a timestamp, use current time as fallback
+		timestamp = time.Now()
+	}
+
+	return githubclient.ReviewRequest{
+		From:     from,
+		On:       timestamp,
+		IsTeam:   isTeam,
+		PRTitle:  pr.Title,
+		PRAuthor: pr.User.Login,
+	}
+}
+
+// reviewRequestTimestamps returns when each user and team was last asked for a review.
+// Gitea records both requests and removals as review_request events, the latter flagged
+// with removed_assignee.
+func reviewRequestTimestamps(timeline []timelineEvent) (map[string]time.Time, map[string]time.Time) {
+	reviewerTimestamps := make(map[string]time.Time)
+	teamTimestamps := make(map[string]time.Time)
+
+	for _, event := range timeline {
+		if event.Type != "review_request" {
+			continue
+		}
+
+		timestamps, key := reviewerTimestamps, ""
+		if event.AssigneeTeam != nil {
+			timestamps, key = teamTimestamps, event.AssigneeTeam.Name
+		} else if event.Assignee != nil {
+			key = event.Assignee.Login
+		} else {
+			continue
+		}
+
+		if event.RemovedAssignee {
+			delete(timestamps, key)
+		} else {
+			timestamps[key] = event.CreatedAt
+		}
+	}
+
+	return reviewerTimestamps, teamTimestamps
+}
+
+func repoPath(owner, repo string) string {
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// getPaginated calls handle with the body of every page of a list endpoint. Instances cap the page
+// size with MAX_RESPONSE_ITEMS, so pages are followed through the Link header, or the total count
+// when an endpoint does not send one, rather than by comparing their size with the one requested.
+func (c *Client) getPaginated(ctx context.Context, path string, handle func([]byte) (int, error)) error {
+	page, seen := 1, 0
+	return c.api.Paginate(ctx, pagePath(path, page), func(resp *http.Response, data []byte) (string, error) {
+		count, err := handle(data)
+		if err != nil {
+			return "", err
+		}
+		seen += count
+
+		if next := restclient.NextLink(resp); next != "" {
+			return next, nil
+		}
+		if total, err := strconv.Atoi(resp.Header.Get("X-Total-Count")); err == nil && count > 0 && seen < total {
+			page++
+			return pagePath(path, page), nil
+		}
+		return "", nil
+	})
+}
+
+func pagePath(path string, page int) string {
+	return restclient.WithQuery(path, fmt.Sprintf("limit=%d&page=%d", pageSize, page))
+}
+
+// errorMessage extracts the message of an error response
+func errorMessage(body []byte) string {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	return apiErr.Message
+}
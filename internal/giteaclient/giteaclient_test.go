@@ -0,0 +1,154 @@
+package giteaclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// Responses recorded from a Forgejo instance, trimmed down to the fields gong reads
+var recordedResponses = map[string]string{
+	"/api/v1/repos/testowner/testrepo/pulls/4": `{
+		"number": 4,
+		"title": "Add feature",
+		"state": "open",
+		"merged": false,
+		"draft": false,
+		"html_url": "https://forgejo.example.com/testowner/testrepo/pulls/4",
+		"created_at": "2023-04-01T12:00:00Z",
+		"updated_at": "2023-04-01T13:00:00Z",
+		"user": {"login": "author"},
+		"labels": [{"name": "enhancement"}],
+		"requested_reviewers": [{"login": "alice"}],
+		"requested_reviewers_teams": [{"name": "reviewers"}]
+	}`,
+	"/api/v1/repos/testowner/testrepo/issues/4/timeline": `[
+		{"type": "review_request", "created_at": "2023-04-01T12:00:00Z", "assignee": {"login": "alice"}, "removed_assignee": false},
+		{"type": "review_request", "created_at": "2023-04-01T12:30:00Z", "assignee": {"login": "alice"}, "removed_assignee": true},
+		{"type": "comment", "created_at": "2023-04-01T12:40:00Z"},
+		{"type": "review_request", "created_at": "2023-04-02T09:00:00Z", "assignee": {"login": "alice"}, "removed_assignee": false},
+		{"type": "review_request", "created_at": "2023-04-01T12:00:00Z", "assignee_team": {"name": "reviewers"}, "removed_assignee": false}
+	]`,
+	"/api/v1/repos/testowner/testrepo/pulls/4/reviews": `[
+		{"user": {"login": "bob"}, "state": "REQUEST_CHANGES", "submitted_at": "2023-04-01T15:00:00Z"}
+	]`,
+	"/api/v1/repos/testowner/testrepo/issues/4/comments": `[{"body": "Nice work"}]`,
+}
+
+func newTestClient(t *testing.T, posted *[]string) *Client {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == "POST" && r.URL.Path == "/api/v1/repos/testowner/testrepo/issues/4/comments" {
+			var c comment
+			if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+				t.Fatalf("Failed to decode comment: %v", err)
+			}
+			*posted = append(*posted, c.Body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		body, ok := recordedResponses[r.URL.Path]
+		if !ok || r.Method != "GET" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "The target couldn't be found."}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("Failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client, err := NewClient(mockServer.URL, "test-token", githubclient.TransportOptions{})
+	assert.NoError(t, err)
+	return client
+}
+
+func TestGetPullRequest(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	data, err := client.GetPullRequest(context.Background(), "testowner", "testrepo", "4")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Add feature", data.Title)
+	assert.Equal(t, "author", data.Author)
+	assert.Equal(t, []string{"enhancement"}, data.Labels)
+	assert.Equal(t, "https://forgejo.example.com/testowner/testrepo/pulls/4", data.URL)
+	assert.True(t, data.State.IsOpen)
+
+	assert.Len(t, data.ReviewRequests, 2)
+	assert.Equal(t, "alice", data.ReviewRequests[0].From)
+	assert.Equal(t, time.Date(2023, 4, 2, 9, 0, 0, 0, time.UTC), data.ReviewRequests[0].On)
+	assert.Equal(t, "reviewers", data.ReviewRequests[1].From)
+	assert.True(t, data.ReviewRequests[1].IsTeam)
+	assert.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), data.ReviewRequests[1].On)
+
+	assert.Equal(t, []githubclient.Review{
+		{Author: "bob", State: "CHANGES_REQUESTED", SubmittedAt: time.Date(2023, 4, 1, 15, 0, 0, 0, time.UTC)},
+	}, data.Reviews)
+}
+
+func TestGetPullRequestNotFound(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	_, err := client.GetPullRequest(context.Background(), "testowner", "testrepo", "99")
+	assert.ErrorIs(t, err, githubclient.ErrPullRequestNotFound)
+}
+
+func TestComments(t *testing.T) {
+	var posted []string
+	client := newTestClient(t, &posted)
+
+	comments, err := client.ListComments(context.Background(), "testowner", "testrepo", "4")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Nice work"}, comments)
+
+	err = client.PostComment(context.Background(), "testowner", "testrepo", "4", "Please review\n<!-- gong -->")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Please review\n<!-- gong -->"}, posted)
+}
+
+func TestPaginationFollowsInstanceLimit(t *testing.T) {
+	// The instance caps pages at two items, below the page size gong asks for
+	pages := map[string]string{
+		"1": `[{"body": "1"}, {"body": "2"}]`,
+		"2": `[{"body": "3"}, {"body": "4"}]`,
+		"3": `[{"body": "5"}]`,
+	}
+
+	for _, withLink := range []bool{true, false} {
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("page")
+			if withLink && page != "3" {
+				next := *r.URL
+				query := next.Query()
+				query.Set("page", map[string]string{"1": "2", "2": "3"}[page])
+				next.RawQuery = query.Encode()
+				w.Header().Set("Link", `<http://`+r.Host+next.String()+`>; rel="next", <http://`+r.Host+`/last>; rel="last"`)
+			}
+			w.Header().Set("X-Total-Count", "5")
+			_, _ = w.Write([]byte(pages[page]))
+		}))
+		t.Cleanup(mockServer.Close)
+
+		client, err := NewClient(mockServer.URL, "test-token", githubclient.TransportOptions{})
+		assert.NoError(t, err)
+
+		comments, err := client.ListComments(context.Background(), "testowner", "testrepo", "4")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, comments, "with Link header: %v", withLink)
+	}
+}
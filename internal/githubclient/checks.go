@@ -0,0 +1,169 @@
+package githubclient
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Check states, from the most to the least blocking
+const (
+	CheckStateFailure = "failure"
+	CheckStatePending = "pending"
+	CheckStateSuccess = "success"
+	CheckStateNone    = "none" // No check reported
+)
+
+// Mergeable states reported in ChecksStatus.MergeableState
+const (
+	MergeableStateClean   = "clean"
+	MergeableStateDirty   = "dirty" // The pull request has merge conflicts
+	MergeableStateUnknown = "unknown"
+)
+
+// Check is a commit status or a check run reported on the head commit of a pull request
+type Check struct {
+	Name     string
+	State    string // One of the CheckState constants
+	Required bool   // Whether branch protection requires this check to pass
+}
+
+// ChecksStatus summarizes the CI status and mergeability of a pull request
+type ChecksStatus struct {
+	HeadSHA        string
+	State          string // Combined state of all checks
+	RequiredState  string // Combined state of the checks required by branch protection
+	MergeableState string // One of the MergeableState constants
+	Checks         []Check
+}
+
+// Conflicting reports whether the pull request has merge conflicts with its base branch
+func (s *ChecksStatus) Conflicting() bool {
+	return s.MergeableState == MergeableStateDirty
+}
+
+// ChecksProvider is implemented by providers that can report the CI status of a pull request
+type ChecksProvider interface {
+	GetChecksStatus(ctx context.Context, owner, repo string, data *PullRequestData) (*ChecksStatus, error)
+}
+
+func (p *GitHubProvider) GetChecksStatus(ctx context.Context, owner, repo string, data *PullRequestData) (*ChecksStatus, error) {
+	return GetChecksStatus(ctx, p.Client, owner, repo, data), nil
+}
+
+// GetChecksStatus summarizes the checks and the mergeable state fetched along with the pull
+// request. Checks are matched against the required status checks of the base branch when branch
+// protection is readable with the current token, which is the only API call it makes.
+func GetChecksStatus(ctx context.Context, client *github.Client, owner, repo string, data *PullRequestData) *ChecksStatus {
+	status := &ChecksStatus{
+		HeadSHA:        data.HeadSHA,
+		State:          data.ChecksState,
+		MergeableState: mergeableState(data.Mergeable),
+		Checks:         slices.Clone(data.Checks),
+	}
+	if status.State == "" {
+		status.State = CheckStateNone
+	}
+
+	required := requiredChecks(ctx, client, owner, repo, data.BaseRef)
+	var requiredStates []string
+	for name := range required {
+		state := CheckStatePending // Required checks that did not report yet are expected
+		for i := range status.Checks {
+			if status.Checks[i].Name == name {
+				status.Checks[i].Required = true
+				state = status.Checks[i].State
+			}
+		}
+		requiredStates = append(requiredStates, state)
+	}
+	status.RequiredState = combineStates(requiredStates)
+
+	return status
+}
+
+// requiredChecks returns the names of the status checks required on a branch. Reading branch
+// protection needs more permissions than reading pull requests, so failures are not fatal.
+func requiredChecks(ctx context.Context, client *github.Client, owner, repo, branch string) map[string]bool {
+	required := make(map[string]bool)
+
+	b, _, err := client.Repositories.GetBranch(ctx, owner, repo, branch, 1)
+	if err != nil {
+		log.Debug().Msgf("Could not read protection of branch %s: %v", branch, err)
+		return required
+	}
+	if b.Protection == nil || b.Protection.RequiredStatusChecks == nil {
+		return required
+	}
+
+	checks := b.Protection.RequiredStatusChecks
+	if checks.Contexts != nil {
+		for _, name := range *checks.Contexts {
+			required[name] = true
+		}
+	}
+	if checks.Checks != nil {
+		for _, check := range *checks.Checks {
+			required[check.Context] = true
+		}
+	}
+
+	return required
+}
+
+// mergeableState maps the mergeable field of the GraphQL API onto a MergeableState constant
+func mergeableState(mergeable string) string {
+	switch mergeable {
+	case "MERGEABLE":
+		return MergeableStateClean
+	case "CONFLICTING":
+		return MergeableStateDirty
+	default: // UNKNOWN while GitHub computes it
+		return MergeableStateUnknown
+	}
+}
+
+// commitStatusState maps a lowercased commit status or status rollup state onto a CheckState
+func commitStatusState(state string) string {
+	switch state {
+	case "success":
+		return CheckStateSuccess
+	case "pending", "expected":
+		return CheckStatePending
+	default: // failure, error
+		return CheckStateFailure
+	}
+}
+
+// checkRunState maps the status and conclusion of a check run onto a CheckState
+func checkRunState(status, conclusion string) string {
+	if !strings.EqualFold(status, "completed") {
+		return CheckStatePending
+	}
+
+	switch strings.ToLower(conclusion) {
+	case "success", "neutral", "skipped":
+		return CheckStateSuccess
+	default: // failure, cancelled, timed_out, action_required, startup_failure, stale
+		return CheckStateFailure
+	}
+}
+
+// combineStates returns the most blocking of the given states, or CheckStateNone if there is none
+func combineStates(states []string) string {
+	combined := CheckStateNone
+	for _, state := range states {
+		switch {
+		case state == CheckStateFailure:
+			return CheckStateFailure
+		case state == CheckStatePending:
+			combined = CheckStatePending
+		case combined == CheckStateNone:
+			combined = CheckStateSuccess
+		}
+	}
+	return combined
+}
@@ -0,0 +1,99 @@
+package githubclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func newChecksMockClient(t *testing.T, responses map[string]string) *github.Client {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf(writeResponseErrMsg, err)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(mockServer.URL + "/")
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestGetChecksStatus(t *testing.T) {
+	client := newChecksMockClient(t, map[string]string{
+		"/repos/testowner/testrepo/branches/main": `{"name": "main", "protected": true, "protection": {
+			"required_status_checks": {"checks": [{"context": "build"}, {"context": "ci/lint"}]}
+		}}`,
+	})
+	data := &PullRequestData{
+		HeadSHA:     "abc123",
+		BaseRef:     "main",
+		Mergeable:   "CONFLICTING",
+		ChecksState: CheckStateFailure,
+		Checks: []Check{
+			{Name: "ci/lint", State: CheckStateSuccess},
+			{Name: "build", State: CheckStateSuccess},
+			{Name: "e2e", State: CheckStateFailure},
+		},
+	}
+
+	status := GetChecksStatus(context.Background(), client, "testowner", "testrepo", data)
+
+	assert.Equal(t, "abc123", status.HeadSHA)
+	assert.Equal(t, CheckStateFailure, status.State)
+	assert.Equal(t, CheckStateSuccess, status.RequiredState)
+	assert.True(t, status.Conflicting())
+	assert.Equal(t, []Check{
+		{Name: "ci/lint", State: CheckStateSuccess, Required: true},
+		{Name: "build", State: CheckStateSuccess, Required: true},
+		{Name: "e2e", State: CheckStateFailure},
+	}, status.Checks)
+	// The checks of the pull request are left untouched
+	assert.False(t, data.Checks[0].Required)
+}
+
+func TestGetChecksStatusWithoutBranchProtection(t *testing.T) {
+	client := newChecksMockClient(t, map[string]string{})
+	data := &PullRequestData{
+		HeadSHA:     "abc123",
+		BaseRef:     "main",
+		Mergeable:   "MERGEABLE",
+		ChecksState: CheckStatePending,
+		Checks:      []Check{{Name: "build", State: CheckStatePending}},
+	}
+
+	status := GetChecksStatus(context.Background(), client, "testowner", "testrepo", data)
+
+	assert.Equal(t, CheckStatePending, status.State)
+	assert.Equal(t, CheckStateNone, status.RequiredState)
+	assert.Equal(t, MergeableStateClean, status.MergeableState)
+	assert.False(t, status.Conflicting())
+}
+
+func TestGetChecksStatusRequiredCheckMissing(t *testing.T) {
+	client := newChecksMockClient(t, map[string]string{
+		"/repos/testowner/testrepo/branches/main": `{"name": "main", "protected": true, "protection": {
+			"required_status_checks": {"contexts": ["deploy"]}
+		}}`,
+	})
+	data := &PullRequestData{BaseRef: "main", Mergeable: "UNKNOWN"}
+
+	status := GetChecksStatus(context.Background(), client, "testowner", "testrepo", data)
+
+	assert.Equal(t, CheckStateNone, status.State)
+	assert.Equal(t, CheckStatePending, status.RequiredState)
+	assert.Equal(t, MergeableStateUnknown, status.MergeableState)
+}
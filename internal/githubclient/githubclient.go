@@ -2,17 +2,30 @@ package githubclient
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/cli/go-gh/v2/pkg/repository"
 	"github.com/google/go-github/v69/github"
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
 
 func NewClient(githubToken string) *github.Client {
-	ctx := context.Background()
+	return NewClientWithOptions(githubToken, TransportOptions{MaxRetries: defaultMaxRetries})
+}
+
+// NewClientWithOptions creates a GitHub client whose transport retries, caches and budgets requests
+func NewClientWithOptions(githubToken string, opts TransportOptions) *github.Client {
+	base := &http.Client{Transport: newTransport(http.DefaultTransport, opts)}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: githubToken},
 	)
@@ -21,12 +34,122 @@ func NewClient(githubToken string) *github.Client {
 	return github.NewClient(tc)
 }
 
+// DefaultHost is the host of github.com, used when no GitHub Enterprise Server URL is configured
+const DefaultHost = "github.com"
+
+// Host returns the host of the GitHub instance at instanceURL, which may be the URL of the
+// instance or of its API (e.g. https://ghe.example.com/api/v3/), github.com when it is empty
+func Host(instanceURL string) string {
+	if instanceURL == "" {
+		return DefaultHost
+	}
+	if !strings.Contains(instanceURL, "://") {
+		instanceURL = "https://" + instanceURL
+	}
+
+	u, err := url.Parse(instanceURL)
+	if err != nil || u.Hostname() == "" {
+		return DefaultHost
+	}
+	return auth.NormalizeHostname(u.Hostname())
+}
+
+// WithInstanceURL points a client at the GitHub Enterprise Server instance at instanceURL, and
+// leaves it on github.com when instanceURL is empty or a github.com URL
+func WithInstanceURL(client *github.Client, instanceURL string) (*github.Client, error) {
+	if Host(instanceURL) == DefaultHost {
+		return client, nil
+	}
+	if !strings.Contains(instanceURL, "://") {
+		instanceURL = "https://" + instanceURL
+	}
+	return client.WithEnterpriseURLs(instanceURL, instanceURL)
+}
+
+// NewClientFromConfig creates a client for the configured GitHub instance (github-url, github.com
+// by default) authenticated with the token ResolveToken finds for it, and returns the token source
+func NewClientFromConfig() (*github.Client, string, error) {
+	instanceURL := viper.GetString("github-url")
+	token, source, err := ResolveToken(viper.GetString("github-token"), Host(instanceURL))
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := WithInstanceURL(NewClientWithOptions(token, TransportOptionsFromConfig()), instanceURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid GitHub URL %q: %w", instanceURL, err)
+	}
+	return client, source, nil
+}
+
+// TokenSourceConfig is the token source reported when the token comes from gong's own
+// configuration (the --github-token flag, the GONG_GITHUB_TOKEN variable or the config file)
+const TokenSourceConfig = "gong config"
+
+// ErrNoToken is returned when no GitHub token could be found in any of the supported sources
+var ErrNoToken = errors.New("no GitHub token found: use --github-token, set GONG_GITHUB_TOKEN, GH_TOKEN or GITHUB_TOKEN " +
+	"(GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN for GitHub Enterprise Server), or run 'gh auth login'")
+
+// Variable to allow the gh auth lookup to be mocked in tests
+var tokenForHost = auth.TokenForHost
+
+// ResolveToken returns the token to use for the GitHub instance at host along with a description
+// of its source. An explicitly configured token wins; otherwise the token is resolved the same way
+// the gh CLI does it: GH_TOKEN and GITHUB_TOKEN on github.com, GH_ENTERPRISE_TOKEN and
+// GITHUB_ENTERPRISE_TOKEN on other hosts, then the gh hosts config and the system keyring.
+func ResolveToken(configToken, host string) (string, string, error) {
+	if configToken != "" {
+		return configToken, TokenSourceConfig, nil
+	}
+
+	token, source := tokenForHost(host)
+	if token == "" {
+		if host != DefaultHost {
+			return "", "", fmt.Errorf("%w (host %s)", ErrNoToken, host)
+		}
+		return "", "", ErrNoToken
+	}
+
+	log.Debug().Msgf("Using GitHub token for %s from %s", host, source)
+	return token, source, nil
+}
+
+// AuthStatus describes the identity and permissions attached to a GitHub token
+type AuthStatus struct {
+	Login  string
+	Scopes []string
+}
+
+// GetAuthStatus fetches the authenticated user and the OAuth scopes granted to the token.
+// Fine-grained and GitHub App tokens do not report scopes, in which case Scopes is empty.
+func GetAuthStatus(client *github.Client) (*AuthStatus, error) {
+	ctx := context.Background()
+
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &AuthStatus{Login: user.GetLogin()}
+	for _, scope := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			status.Scopes = append(status.Scopes, scope)
+		}
+	}
+
+	return status, nil
+}
+
 type ReviewRequest struct {
-	From     string
-	On       time.Time
-	IsTeam   bool
-	PRTitle  string
-	PRAuthor string
+	From         string
+	On           time.Time
+	IsTeam       bool
+	Slug         string // Team slug, used to match reviews submitted on behalf of the team
+	PRTitle      string
+	PRAuthor     string
+	ReviewState  string    // Latest review from the reviewer (or on behalf of the team): one of the ReviewState* constants
+	LastActivity time.Time // When the reviewer last reviewed or commented, zero if never
+	Team         string    // Name of the team this member was expanded from, empty otherwise
 }
 
 type PullRequestState struct {
@@ -73,87 +196,3 @@ func GetPullRequestState(client *github.Client, owner, repo string, prNumber str
 
 	return state, nil
 }
-
-func GetReviewRequests(client *github.Client, owner, repo string, prNumber string) ([]ReviewRequest, error) {
-	ctx := context.Background()
-
-	prNum, err := strconv.Atoi(prNumber)
-	if err != nil {
-		return nil, err
-	}
-
-	pr, _, err := client.PullRequests.Get(ctx, owner, repo, prNum)
-	if err != nil {
-		return nil, err
-	}
-	prTitle := pr.GetTitle()
-	prAuthor := pr.GetUser().GetLogin()
-
-	log.Debug().Msgf("Working on PR %s/%s#%d : '%s' by %s", owner, repo, prNum, prTitle, prAuthor)
-
-	reviewRequests, _, err := client.PullRequests.ListReviewers(ctx, owner, repo, prNum, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create a map to store reviewer logins and their request times
-	reviewerTimestamps := make(map[string]time.Time)
-	teamTimestamps := make(map[string]time.Time)
-
-	// Fetch the timeline to get the request timestamps
-	timeline, _, err := client.Issues.ListIssueTimeline(ctx, owner, repo, prNum, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Process timeline events to extract timestamps
-	for _, event := range timeline {
-		if *event.Event == "review_requested" {
-			if event.GetRequestedTeam() != nil {
-				teamName := event.GetRequestedTeam().GetName()
-				teamTimestamps[teamName] = event.GetCreatedAt().Time
-			} else if event.GetReviewer() != nil {
-				login := event.GetReviewer().GetLogin()
-				reviewerTimestamps[login] = event.GetCreatedAt().Time
-			}
-		}
-	}
-
-	var reviewRequestsArray []ReviewRequest
-
-	// Add individual users with their timestamps
-	for _, user := range reviewRequests.Users {
-		login := *user.Login
-		timestamp, exists := reviewerTimestamps[login]
-		if !exists {
-			// If we couldn't find a timestamp, use current time as fallback
-			timestamp = time.Now()
-		}
-		reviewRequestsArray = append(reviewRequestsArray, ReviewRequest{
-			From:     login,
-			On:       timestamp,
-			IsTeam:   false,
-			PRTitle:  prTitle,
-			PRAuthor: prAuthor,
-		})
-	}
-
-	// Add teams with their timestamps
-	for _, team := range reviewRequests.Teams {
-		teamName := *team.Name
-		timestamp, exists := teamTimestamps[teamName]
-		if !exists {
-			// If we couldn't find a timestamp, use current time as fallback
-			timestamp = time.Now()
-		}
-		reviewRequestsArray = append(reviewRequestsArray, ReviewRequest{
-			From:     teamName,
-			On:       timestamp,
-			IsTeam:   true,
-			PRTitle:  prTitle,
-			PRAuthor: prAuthor,
-		})
-	}
-
-	return reviewRequestsArray, nil
-}
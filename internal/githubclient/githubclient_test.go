@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/google/go-github/v69/github"
 	"github.com/stretchr/testify/assert"
 )
@@ -188,3 +189,112 @@ func testDraftPR(t *testing.T, client *github.Client) {
 		assert.Equal(t, expectedUpdatedAt, state.UpdatedAt)
 	})
 }
+
+func TestResolveToken(t *testing.T) {
+	defer func() { tokenForHost = auth.TokenForHost }()
+
+	t.Run("Configured token wins", func(t *testing.T) {
+		tokenForHost = func(string) (string, string) { return "gh-token", "GH_TOKEN" }
+		token, source, err := ResolveToken("config-token", DefaultHost)
+		assert.NoError(t, err)
+		assert.Equal(t, "config-token", token)
+		assert.Equal(t, TokenSourceConfig, source)
+	})
+
+	t.Run("Falls back to gh credentials", func(t *testing.T) {
+		tokenForHost = func(string) (string, string) { return "gh-token", "oauth_token" }
+		token, source, err := ResolveToken("", DefaultHost)
+		assert.NoError(t, err)
+		assert.Equal(t, "gh-token", token)
+		assert.Equal(t, "oauth_token", source)
+	})
+
+	t.Run("Looks up the token of the configured host", func(t *testing.T) {
+		var host string
+		tokenForHost = func(h string) (string, string) { host = h; return "ghe-token", "GH_ENTERPRISE_TOKEN" }
+		token, source, err := ResolveToken("", Host("https://ghe.example.com/api/v3/"))
+		assert.NoError(t, err)
+		assert.Equal(t, "ghe.example.com", host)
+		assert.Equal(t, "ghe-token", token)
+		assert.Equal(t, "GH_ENTERPRISE_TOKEN", source)
+	})
+
+	t.Run("No token found", func(t *testing.T) {
+		tokenForHost = func(string) (string, string) { return "", "default" }
+		_, _, err := ResolveToken("", DefaultHost)
+		assert.ErrorIs(t, err, ErrNoToken)
+	})
+}
+
+func TestResolveTokenEnterpriseVariables(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("GH_PATH", "/nonexistent/gh")
+	t.Setenv("GH_TOKEN", "github-token")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "")
+	t.Setenv("GITHUB_ENTERPRISE_TOKEN", "ghe-token")
+
+	token, source, err := ResolveToken("", DefaultHost)
+	assert.NoError(t, err)
+	assert.Equal(t, "github-token", token)
+	assert.Equal(t, "GH_TOKEN", source)
+
+	token, source, err = ResolveToken("", "ghe.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "ghe-token", token)
+	assert.Equal(t, "GITHUB_ENTERPRISE_TOKEN", source)
+}
+
+func TestHost(t *testing.T) {
+	testCases := map[string]string{
+		"":                                "github.com",
+		"https://api.github.com/":         "github.com",
+		"https://ghe.example.com":         "ghe.example.com",
+		"https://GHE.example.com/api/v3/": "ghe.example.com",
+		"ghe.example.com":                 "ghe.example.com",
+	}
+	for instanceURL, expected := range testCases {
+		assert.Equal(t, expected, Host(instanceURL), instanceURL)
+	}
+}
+
+func TestWithInstanceURL(t *testing.T) {
+	client, err := WithInstanceURL(github.NewClient(nil), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.github.com/", client.BaseURL.String())
+	assert.Equal(t, "graphql", graphQLPath(client))
+
+	client, err = WithInstanceURL(github.NewClient(nil), "https://ghe.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ghe.example.com/api/v3/", client.BaseURL.String())
+
+	// GraphQL is served next to the REST API root rather than under it
+	graphQL, err := client.BaseURL.Parse(graphQLPath(client))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ghe.example.com/api/graphql", graphQL.String())
+}
+
+func TestGetAuthStatus(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+		_, err := w.Write([]byte(`{"login": "octocat"}`))
+		if err != nil {
+			t.Fatalf(writeResponseErrMsg, err)
+		}
+	}))
+	defer mockServer.Close()
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(mockServer.URL + "/")
+	client.BaseURL = baseURL
+
+	status, err := GetAuthStatus(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "octocat", status.Login)
+	assert.Equal(t, []string{"repo", "read:org"}, status.Scopes)
+}
@@ -0,0 +1,528 @@
+package githubclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrPullRequestNotFound is returned when the GraphQL API does not know the requested pull request
+var ErrPullRequestNotFound = errors.New("pull request not found")
+
+// Connections of a pull request that may not fit in a single page. The first query reads the
+// first page of review requests and the last page of the others, then earlier (or later) pages
+// are fetched one query at a time, passing the cursor of the previous page as $cursor.
+const (
+	reviewRequestsSelection = `reviewRequests(first: 100, after: $cursor) { ...gongReviewRequests }`
+	timelineItemsSelection  = `timelineItems(last: 100, before: $cursor, itemTypes: [REVIEW_REQUESTED_EVENT, REVIEW_REQUEST_REMOVED_EVENT]) { ...gongTimelineItems }`
+	reviewsSelection        = `reviews(last: 100, before: $cursor) { ...gongReviews }`
+	commentsSelection       = `comments(last: 100, before: $cursor) { ...gongComments }`
+)
+
+const reviewRequestsFragment = `fragment gongReviewRequests on ReviewRequestConnection {
+  pageInfo { hasNextPage endCursor }
+  nodes {
+    requestedReviewer {
+      __typename
+      ... on User { login }
+      ... on Bot { login }
+      ... on Mannequin { login }
+      ... on Team { name slug }
+    }
+  }
+}`
+
+const timelineItemsFragment = `fragment gongTimelineItems on PullRequestTimelineItemsConnection {
+  pageInfo { hasPreviousPage startCursor }
+  nodes {
+    __typename
+    ... on ReviewRequestedEvent { createdAt requestedReviewer { ...gongReviewer } }
+    ... on ReviewRequestRemovedEvent { createdAt requestedReviewer { ...gongReviewer } }
+  }
+}
+
+fragment gongReviewer on RequestedReviewer {
+  __typename
+  ... on User { login }
+  ... on Bot { login }
+  ... on Mannequin { login }
+  ... on Team { slug }
+}`
+
+const reviewsFragment = `fragment gongReviews on PullRequestReviewConnection {
+  pageInfo { hasPreviousPage startCursor }
+  nodes {
+    author { login }
+    state
+    submittedAt
+    onBehalfOf(first: 10) { nodes { slug } }
+  }
+}`
+
+const commentsFragment = `fragment gongComments on IssueCommentConnection {
+  pageInfo { hasPreviousPage startCursor }
+  nodes {
+    author { login }
+    createdAt
+  }
+}`
+
+// pullRequestFragment selects everything gong needs about a pull request
+const pullRequestFragment = `fragment gongPullRequest on PullRequest {
+  number
+  title
+  url
+  state
+  isDraft
+  merged
+  createdAt
+  updatedAt
+  author { login }
+  labels(first: 100) { nodes { name } }
+  ` + reviewRequestsSelection + `
+  ` + timelineItemsSelection + `
+  ` + reviewsSelection + `
+  ` + commentsSelection + `
+  headRefOid
+  baseRefName
+  mergeable
+  commits(last: 1) {
+    nodes {
+      commit {
+        committedDate
+        statusCheckRollup {
+          state
+          contexts(first: 100) {
+            nodes {
+              __typename
+              ... on CheckRun { name status conclusion }
+              ... on StatusContext { context state }
+            }
+          }
+        }
+      }
+    }
+  }
+  reviewThreads(first: 100) { nodes { isResolved comments(first: 1) { nodes { author { login } } } } }
+}
+
+` + reviewRequestsFragment + "\n\n" + timelineItemsFragment + "\n\n" + reviewsFragment + "\n\n" + commentsFragment
+
+// Review is a review submitted on a pull request
+type Review struct {
+	Author      string
+	State       string // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING
+	SubmittedAt time.Time
+	OnBehalfOf  []string // Slugs of the teams the review was submitted on behalf of
+}
+
+// Comment is a conversation comment posted on a pull request
+type Comment struct {
+	Author    string
+	CreatedAt time.Time
+}
+
+// PullRequestData holds everything gong needs to know about a pull request
+type PullRequestData struct {
+	Number                  int
+	Title                   string
+	Author                  string
+	URL                     string
+	Labels                  []string
+	State                   PullRequestState
+	ReviewRequests          []ReviewRequest
+	Reviews                 []Review
+	Comments                []Comment
+	LastCommitAt            time.Time // When the head commit was committed
+	UnresolvedThreads       int       // Number of review threads that are not resolved yet
+	UnresolvedThreadAuthors []string  // Who started each unresolved review thread
+	HeadSHA                 string    // Head commit of the pull request
+	BaseRef                 string    // Branch the pull request merges into
+	Mergeable               string    // MERGEABLE, CONFLICTING or UNKNOWN, empty if not reported
+	ChecksState             string    // Combined state of the checks on the head commit, one of the CheckState constants
+	Checks                  []Check   // Checks reported on the head commit
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Type    string        `json:"type"`
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"` // Field names and list indices
+}
+
+type graphQLResponse struct {
+	Data struct {
+		Repository map[string]*graphQLPullRequest `json:"repository"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// graphQLConnection is a page of a connection
+type graphQLConnection[T any] struct {
+	PageInfo struct {
+		HasNextPage     bool   `json:"hasNextPage"`
+		EndCursor       string `json:"endCursor"`
+		HasPreviousPage bool   `json:"hasPreviousPage"`
+		StartCursor     string `json:"startCursor"`
+	} `json:"pageInfo"`
+	Nodes []T `json:"nodes"`
+}
+
+type graphQLActor struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+}
+
+type graphQLPullRequest struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	State     string    `json:"state"`
+	IsDraft   bool      `json:"isDraft"`
+	Merged    bool      `json:"merged"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Author    *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	ReviewRequests graphQLConnection[graphQLReviewRequest] `json:"reviewRequests"`
+	TimelineItems  graphQLConnection[graphQLTimelineItem]  `json:"timelineItems"`
+	Reviews        graphQLConnection[graphQLReview]        `json:"reviews"`
+	Comments       graphQLConnection[graphQLComment]       `json:"comments"`
+	HeadRefOid     string                                  `json:"headRefOid"`
+	BaseRefName    string                                  `json:"baseRefName"`
+	Mergeable      string                                  `json:"mergeable"`
+	Commits        struct {
+		Nodes []struct {
+			Commit struct {
+				CommittedDate     time.Time `json:"committedDate"`
+				StatusCheckRollup *struct {
+					State    string `json:"state"`
+					Contexts struct {
+						Nodes []struct {
+							Typename   string `json:"__typename"`
+							Name       string `json:"name"`
+							Status     string `json:"status"`
+							Conclusion string `json:"conclusion"`
+							Context    string `json:"context"`
+							State      string `json:"state"`
+						} `json:"nodes"`
+					} `json:"contexts"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+	ReviewThreads struct {
+		Nodes []struct {
+			IsResolved bool `json:"isResolved"`
+			Comments   struct {
+				Nodes []struct {
+					Author *struct {
+						Login string `json:"login"`
+					} `json:"author"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"nodes"`
+	} `json:"reviewThreads"`
+}
+
+type graphQLReviewRequest struct {
+	RequestedReviewer *graphQLActor `json:"requestedReviewer"`
+}
+
+type graphQLTimelineItem struct {
+	Typename          string        `json:"__typename"`
+	CreatedAt         time.Time     `json:"createdAt"`
+	RequestedReviewer *graphQLActor `json:"requestedReviewer"`
+}
+
+type graphQLReview struct {
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	State       string    `json:"state"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	OnBehalfOf  struct {
+		Nodes []struct {
+			Slug string `json:"slug"`
+		} `json:"nodes"`
+	} `json:"onBehalfOf"`
+}
+
+type graphQLComment struct {
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GetPullRequestData fetches the state, review requests, reviews and checks of a pull request
+// with a single GraphQL query, plus one query per extra page of its longer connections.
+func GetPullRequestData(ctx context.Context, client *github.Client, owner, repo string, prNumber string) (*PullRequestData, error) {
+	prNum, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q := pullRequestQuery{client: client, variables: map[string]interface{}{"owner": owner, "repo": repo, "number": prNum}}
+	pr, err := q.run(ctx, "...gongPullRequest", pullRequestFragment, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fetchPages(ctx, q, reviewRequestsSelection, reviewRequestsFragment, &pr.ReviewRequests,
+		func(page *graphQLPullRequest) *graphQLConnection[graphQLReviewRequest] { return &page.ReviewRequests }); err != nil {
+		return nil, err
+	}
+	if err := fetchPages(ctx, q, timelineItemsSelection, timelineItemsFragment, &pr.TimelineItems,
+		func(page *graphQLPullRequest) *graphQLConnection[graphQLTimelineItem] { return &page.TimelineItems }); err != nil {
+		return nil, err
+	}
+	if err := fetchPages(ctx, q, reviewsSelection, reviewsFragment, &pr.Reviews,
+		func(page *graphQLPullRequest) *graphQLConnection[graphQLReview] { return &page.Reviews }); err != nil {
+		return nil, err
+	}
+	if err := fetchPages(ctx, q, commentsSelection, commentsFragment, &pr.Comments,
+		func(page *graphQLPullRequest) *graphQLConnection[graphQLComment] { return &page.Comments }); err != nil {
+		return nil, err
+	}
+
+	return pr.toPullRequestData(), nil
+}
+
+// fetchPages adds the pages of a connection the first query left out: the following ones for
+// connections read from their start, the previous ones, in order, for those read from their end
+func fetchPages[T any](ctx context.Context, q pullRequestQuery, selection, fragment string, conn *graphQLConnection[T], of func(*graphQLPullRequest) *graphQLConnection[T]) error {
+	for (conn.PageInfo.HasNextPage && conn.PageInfo.EndCursor != "") ||
+		(conn.PageInfo.HasPreviousPage && conn.PageInfo.StartCursor != "") {
+		cursor := conn.PageInfo.EndCursor
+		if conn.PageInfo.HasPreviousPage {
+			cursor = conn.PageInfo.StartCursor
+		}
+
+		pr, err := q.run(ctx, selection, fragment, cursor)
+		if err != nil {
+			return err
+		}
+		page := of(pr)
+
+		if conn.PageInfo.HasPreviousPage {
+			conn.Nodes = append(page.Nodes, conn.Nodes...)
+		} else {
+			conn.Nodes = append(conn.Nodes, page.Nodes...)
+		}
+		conn.PageInfo = page.PageInfo
+	}
+	return nil
+}
+
+// pullRequestQuery queries a pull request with the GraphQL API
+type pullRequestQuery struct {
+	client    *github.Client
+	variables map[string]interface{} // Owner, repository and number of the pull request
+}
+
+// run selects fields of the pull request, $cursor being set to cursor unless it is empty
+func (q pullRequestQuery) run(ctx context.Context, selection, fragments, cursor string) (*graphQLPullRequest, error) {
+	query := "query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {\n  repository(owner: $owner, name: $repo) {\n    pr: pullRequest(number: $number) { " +
+		selection + " }\n  }\n}\n\n" + fragments
+
+	variables := maps.Clone(q.variables)
+	if cursor != "" {
+		variables["cursor"] = cursor
+	}
+
+	req, err := q.client.NewRequest("POST", graphQLPath(q.client), &graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp graphQLResponse
+	if _, err := q.client.Do(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, gqlErr := range resp.Errors {
+		// Errors on a field of the pull request leave the rest of it usable
+		if len(gqlErr.Path) > 1 {
+			if gqlErr.Type == "NOT_FOUND" {
+				log.Debug().Msgf("GraphQL: %s", gqlErr.Message)
+			} else {
+				log.Warn().Msgf("GraphQL error on %s: %s", gqlErr.pathString(), gqlErr.Message)
+			}
+			continue
+		}
+		if gqlErr.Type == "NOT_FOUND" {
+			return nil, ErrPullRequestNotFound
+		}
+		return nil, fmt.Errorf("graphql error: %s", gqlErr.Message)
+	}
+
+	node := resp.Data.Repository["pr"]
+	if node == nil {
+		return nil, ErrPullRequestNotFound
+	}
+	return node, nil
+}
+
+// graphQLPath returns the GraphQL endpoint relative to the REST API root of the client. GitHub
+// Enterprise Server serves the REST API under /api/v3/ and GraphQL at /api/graphql.
+func graphQLPath(client *github.Client) string {
+	if strings.HasSuffix(client.BaseURL.Path, "/api/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
+
+// pathString formats the path of an error, made of field names and list indices
+func (e graphQLError) pathString() string {
+	parts := make([]string, len(e.Path))
+	for i, part := range e.Path {
+		parts[i] = fmt.Sprint(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (pr *graphQLPullRequest) toPullRequestData() *PullRequestData {
+	data := &PullRequestData{
+		Number: pr.Number,
+		Title:  pr.Title,
+		URL:    pr.URL,
+		State: PullRequestState{
+			IsOpen:    pr.State == "OPEN",
+			IsMerged:  pr.Merged,
+			IsClosed:  pr.State == "CLOSED" && !pr.Merged,
+			IsDraft:   pr.IsDraft,
+			CreatedAt: pr.CreatedAt,
+			UpdatedAt: pr.UpdatedAt,
+		},
+	}
+	if pr.Author != nil {
+		data.Author = pr.Author.Login
+	}
+
+	for _, label := range pr.Labels.Nodes {
+		data.Labels = append(data.Labels, label.Name)
+	}
+
+	for _, review := range pr.Reviews.Nodes {
+		r := Review{State: review.State, SubmittedAt: review.SubmittedAt}
+		if review.Author != nil {
+			r.Author = review.Author.Login
+		}
+		for _, team := range review.OnBehalfOf.Nodes {
+			r.OnBehalfOf = append(r.OnBehalfOf, team.Slug)
+		}
+		data.Reviews = append(data.Reviews, r)
+	}
+
+	for _, comment := range pr.Comments.Nodes {
+		c := Comment{CreatedAt: comment.CreatedAt}
+		if comment.Author != nil {
+			c.Author = comment.Author.Login
+		}
+		data.Comments = append(data.Comments, c)
+	}
+
+	data.HeadSHA, data.BaseRef, data.Mergeable = pr.HeadRefOid, pr.BaseRefName, pr.Mergeable
+	data.ChecksState = CheckStateNone
+	for _, commit := range pr.Commits.Nodes {
+		data.LastCommitAt = commit.Commit.CommittedDate
+
+		rollup := commit.Commit.StatusCheckRollup
+		if rollup == nil {
+			continue
+		}
+		data.ChecksState = commitStatusState(strings.ToLower(rollup.State))
+		for _, c := range rollup.Contexts.Nodes {
+			switch c.Typename {
+			case "CheckRun":
+				data.Checks = append(data.Checks, Check{Name: c.Name, State: checkRunState(c.Status, c.Conclusion)})
+			case "StatusContext":
+				data.Checks = append(data.Checks, Check{Name: c.Context, State: commitStatusState(strings.ToLower(c.State))})
+			}
+		}
+	}
+
+	for _, thread := range pr.ReviewThreads.Nodes {
+		if thread.IsResolved {
+			continue
+		}
+		data.UnresolvedThreads++
+		for _, comment := range thread.Comments.Nodes {
+			if comment.Author != nil && !slices.Contains(data.UnresolvedThreadAuthors, comment.Author.Login) {
+				data.UnresolvedThreadAuthors = append(data.UnresolvedThreadAuthors, comment.Author.Login)
+			}
+		}
+	}
+
+	// Events come in timeline order: the latest request wins and removed requests are forgotten
+	reviewerTimestamps := make(map[string]time.Time)
+	teamTimestamps := make(map[string]time.Time)
+	for _, event := range pr.TimelineItems.Nodes {
+		if event.RequestedReviewer == nil {
+			continue
+		}
+		timestamps, key := reviewerTimestamps, event.RequestedReviewer.Login
+		if event.RequestedReviewer.Typename == "Team" {
+			timestamps, key = teamTimestamps, event.RequestedReviewer.Slug
+		}
+
+		switch event.Typename {
+		case "ReviewRequestedEvent":
+			timestamps[key] = event.CreatedAt
+		case "ReviewRequestRemovedEvent":
+			delete(timestamps, key)
+		}
+	}
+
+	for _, node := range pr.ReviewRequests.Nodes {
+		reviewer := node.RequestedReviewer
+		if reviewer == nil {
+			continue
+		}
+
+		req := ReviewRequest{
+			From:     reviewer.Login,
+			PRTitle:  data.Title,
+			PRAuthor: data.Author,
+		}
+		timestamp, exists := reviewerTimestamps[reviewer.Login]
+		if reviewer.Typename == "Team" {
+			req.From = reviewer.Name
+			req.IsTeam = true
+			req.Slug = reviewer.Slug
+			timestamp, exists = teamTimestamps[reviewer.Slug]
+		}
+		if !exists {
+			// Requests made when the pull request was opened may have no event, e.g. on old
+			// pull requests, so the request dates back to its creation
+			timestamp = data.State.CreatedAt
+		}
+		req.On = timestamp
+
+		data.ReviewRequests = append(data.ReviewRequests, req)
+	}
+
+	data.AnnotateReviewRequests()
+	return data
+}
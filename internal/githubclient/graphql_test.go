@@ -0,0 +1,216 @@
+package githubclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/assert"
+)
+
+const graphQLPullRequestResponse = `{
+	"data": {
+		"repository": {
+			"pr": {
+				"number": 7,
+				"title": "Add feature",
+				"url": "https://github.com/testowner/testrepo/pull/7",
+				"state": "OPEN",
+				"isDraft": false,
+				"merged": false,
+				"createdAt": "2023-04-01T12:00:00Z",
+				"updatedAt": "2023-04-01T13:00:00Z",
+				"author": {"login": "author"},
+				"labels": {"nodes": [{"name": "bug"}]},
+				"reviewRequests": {"nodes": [
+					{"requestedReviewer": {"__typename": "User", "login": "alice"}},
+					{"requestedReviewer": {"__typename": "Team", "name": "Backend Team", "slug": "backend-team"}}
+				]},
+				"timelineItems": {"nodes": [
+					{"__typename": "ReviewRequestedEvent", "createdAt": "2023-04-01T12:30:00Z", "requestedReviewer": {"__typename": "User", "login": "alice"}},
+					{"__typename": "ReviewRequestedEvent", "createdAt": "2023-04-01T12:30:00Z", "requestedReviewer": {"__typename": "Team", "slug": "backend-team"}},
+					{"__typename": "ReviewRequestRemovedEvent", "createdAt": "2023-04-01T14:00:00Z", "requestedReviewer": {"__typename": "User", "login": "alice"}},
+					{"__typename": "ReviewRequestedEvent", "createdAt": "2023-04-02T08:00:00Z", "requestedReviewer": {"__typename": "User", "login": "alice"}}
+				]},
+				"reviews": {"nodes": [
+					{"author": {"login": "bob"}, "state": "APPROVED", "submittedAt": "2023-04-01T15:00:00Z"}
+				]},
+				"reviewThreads": {"nodes": [
+					{"isResolved": true, "comments": {"nodes": [{"author": {"login": "carol"}}]}},
+					{"isResolved": false, "comments": {"nodes": [{"author": {"login": "bob"}}]}},
+					{"isResolved": false, "comments": {"nodes": [{"author": {"login": "bob"}}]}}
+				]},
+				"headRefOid": "abc123",
+				"baseRefName": "main",
+				"mergeable": "CONFLICTING",
+				"commits": {"nodes": [{"commit": {
+					"committedDate": "2023-04-01T11:00:00Z",
+					"statusCheckRollup": {"state": "FAILURE", "contexts": {"nodes": [
+						{"__typename": "StatusContext", "context": "ci/lint", "state": "SUCCESS"},
+						{"__typename": "CheckRun", "name": "build", "status": "IN_PROGRESS", "conclusion": null},
+						{"__typename": "CheckRun", "name": "e2e", "status": "COMPLETED", "conclusion": "TIMED_OUT"}
+					]}}
+				}}]}
+			}
+		}
+	}
+}`
+
+func newGraphQLMockClient(t *testing.T, body string) *github.Client {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/graphql" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode GraphQL request: %v", err)
+		}
+		assert.Equal(t, float64(7), req.Variables["number"])
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf(writeResponseErrMsg, err)
+		}
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(mockServer.URL + "/")
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestGetPullRequestData(t *testing.T) {
+	client := newGraphQLMockClient(t, graphQLPullRequestResponse)
+
+	data, err := GetPullRequestData(context.Background(), client, "testowner", "testrepo", "7")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Add feature", data.Title)
+	assert.Equal(t, "author", data.Author)
+	assert.Equal(t, []string{"bug"}, data.Labels)
+	assert.True(t, data.State.IsOpen)
+	assert.False(t, data.State.IsDraft)
+	assert.Equal(t, []Review{{Author: "bob", State: "APPROVED", SubmittedAt: time.Date(2023, 4, 1, 15, 0, 0, 0, time.UTC)}}, data.Reviews)
+
+	assert.Len(t, data.ReviewRequests, 2)
+	assert.Equal(t, "alice", data.ReviewRequests[0].From)
+	assert.Equal(t, time.Date(2023, 4, 2, 8, 0, 0, 0, time.UTC), data.ReviewRequests[0].On)
+	assert.Equal(t, "Add feature", data.ReviewRequests[0].PRTitle)
+	assert.Equal(t, "Backend Team", data.ReviewRequests[1].From)
+	assert.True(t, data.ReviewRequests[1].IsTeam)
+	assert.Equal(t, time.Date(2023, 4, 1, 12, 30, 0, 0, time.UTC), data.ReviewRequests[1].On)
+
+	assert.Equal(t, 2, data.UnresolvedThreads)
+	assert.Equal(t, []string{"bob"}, data.UnresolvedThreadAuthors)
+	assert.Equal(t, time.Date(2023, 4, 1, 11, 0, 0, 0, time.UTC), data.LastCommitAt)
+	assert.Equal(t, "abc123", data.HeadSHA)
+	assert.Equal(t, "main", data.BaseRef)
+	assert.Equal(t, "CONFLICTING", data.Mergeable)
+	assert.Equal(t, CheckStateFailure, data.ChecksState)
+	assert.Equal(t, []Check{
+		{Name: "ci/lint", State: CheckStateSuccess},
+		{Name: "build", State: CheckStatePending},
+		{Name: "e2e", State: CheckStateFailure},
+	}, data.Checks)
+}
+
+func TestGetPullRequestDataNotFound(t *testing.T) {
+	client := newGraphQLMockClient(t, `{
+		"data": {"repository": {"pr": null}},
+		"errors": [{"type": "NOT_FOUND", "path": ["repository", "pr"], "message": "Could not resolve to a PullRequest with the number of 99."}]
+	}`)
+
+	data, err := GetPullRequestData(context.Background(), client, "testowner", "testrepo", "7")
+	assert.ErrorIs(t, err, ErrPullRequestNotFound)
+	assert.Nil(t, data)
+}
+
+func TestGetPullRequestDataPartialErrors(t *testing.T) {
+	// Errors on nested fields have paths mixing field names and list indices
+	client := newGraphQLMockClient(t, `{
+		"data": {"repository": {"pr": {"number": 7, "title": "Add feature", "state": "OPEN", "reviews": {"nodes": [null]}}}},
+		"errors": [{"type": "FORBIDDEN", "path": ["repository", "pr", "reviews", "nodes", 0, "author"], "message": "Resource not accessible"}]
+	}`)
+
+	data, err := GetPullRequestData(context.Background(), client, "testowner", "testrepo", "7")
+	assert.NoError(t, err)
+	assert.Equal(t, "Add feature", data.Title)
+}
+
+func TestGetPullRequestDataPagination(t *testing.T) {
+	// Review requests, timeline items and reviews have one more page than the first query returns
+	pages := map[string]string{
+		"reviewRequests": `{"pageInfo": {"hasNextPage": false}, "nodes": [
+			{"requestedReviewer": {"__typename": "User", "login": "carol"}}
+		]}`,
+		"timelineItems": `{"pageInfo": {"hasPreviousPage": false}, "nodes": [
+			{"__typename": "ReviewRequestedEvent", "createdAt": "2023-04-01T12:30:00Z", "requestedReviewer": {"__typename": "User", "login": "alice"}}
+		]}`,
+		"reviews": `{"pageInfo": {"hasPreviousPage": false}, "nodes": [
+			{"author": {"login": "bob"}, "state": "COMMENTED", "submittedAt": "2023-04-01T14:00:00Z"}
+		]}`,
+	}
+
+	var queried []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode GraphQL request: %v", err)
+		}
+
+		body := `{"data": {"repository": {"pr": {
+			"number": 7, "title": "Add feature", "state": "OPEN", "createdAt": "2023-04-01T12:00:00Z",
+			"reviewRequests": {"pageInfo": {"hasNextPage": true, "endCursor": "requests"}, "nodes": [
+				{"requestedReviewer": {"__typename": "User", "login": "alice"}}
+			]},
+			"timelineItems": {"pageInfo": {"hasPreviousPage": true, "startCursor": "timeline"}, "nodes": [
+				{"__typename": "ReviewRequestedEvent", "createdAt": "2023-04-01T12:30:00Z", "requestedReviewer": {"__typename": "User", "login": "bob"}}
+			]},
+			"reviews": {"pageInfo": {"hasPreviousPage": true, "startCursor": "reviews"}, "nodes": [
+				{"author": {"login": "bob"}, "state": "APPROVED", "submittedAt": "2023-04-01T15:00:00Z"}
+			]},
+			"comments": {"pageInfo": {"hasPreviousPage": false}, "nodes": []}
+		}}}}`
+		if cursor, ok := req.Variables["cursor"].(string); ok {
+			queried = append(queried, cursor)
+			field := map[string]string{"requests": "reviewRequests", "timeline": "timelineItems", "reviews": "reviews"}[cursor]
+			assert.Contains(t, req.Query, field+"(")
+			body = `{"data": {"repository": {"pr": {"` + field + `": ` + pages[field] + `}}}}`
+		}
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(mockServer.Close)
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(mockServer.URL + "/")
+	client.BaseURL = baseURL
+
+	data, err := GetPullRequestData(context.Background(), client, "testowner", "testrepo", "7")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"requests", "timeline", "reviews"}, queried)
+
+	// Earlier pages come first
+	assert.Equal(t, []Review{
+		{Author: "bob", State: "COMMENTED", SubmittedAt: time.Date(2023, 4, 1, 14, 0, 0, 0, time.UTC)},
+		{Author: "bob", State: "APPROVED", SubmittedAt: time.Date(2023, 4, 1, 15, 0, 0, 0, time.UTC)},
+	}, data.Reviews)
+
+	if assert.Len(t, data.ReviewRequests, 2) {
+		assert.Equal(t, "alice", data.ReviewRequests[0].From)
+		assert.Equal(t, time.Date(2023, 4, 1, 12, 30, 0, 0, time.UTC), data.ReviewRequests[0].On)
+
+		// Without a request event, the request dates back to the creation of the pull request
+		assert.Equal(t, "carol", data.ReviewRequests[1].From)
+		assert.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), data.ReviewRequests[1].On)
+	}
+}
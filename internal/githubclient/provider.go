@@ -0,0 +1,91 @@
+package githubclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/google/go-github/v69/github"
+)
+
+// Provider is a code host gong can read pull requests from and post comments to.
+// Implementations map their own data model onto PullRequestData and ReviewRequest so that
+// rules, templates and integrations work the same way regardless of the host.
+type Provider interface {
+	// Name returns the provider identifier used in the configuration (e.g. "github")
+	Name() string
+	// GetPullRequest returns the state, review requests and reviews of a pull request.
+	// It returns ErrPullRequestNotFound when the pull request does not exist.
+	GetPullRequest(ctx context.Context, owner, repo, prNumber string) (*PullRequestData, error)
+	// ListComments returns the body of every comment posted on a pull request
+	ListComments(ctx context.Context, owner, repo, prNumber string) ([]string, error)
+	// PostComment posts a comment on a pull request
+	PostComment(ctx context.Context, owner, repo, prNumber, body string) error
+}
+
+// GitHubProvider implements Provider on top of the GitHub REST and GraphQL APIs
+type GitHubProvider struct {
+	Client *github.Client
+}
+
+// NewGitHubProvider creates a GitHub provider using the given client
+func NewGitHubProvider(client *github.Client) *GitHubProvider {
+	return &GitHubProvider{Client: client}
+}
+
+func (p *GitHubProvider) Name() string {
+	return "github"
+}
+
+func (p *GitHubProvider) GetPullRequest(ctx context.Context, owner, repo, prNumber string) (*PullRequestData, error) {
+	return GetPullRequestData(ctx, p.Client, owner, repo, prNumber)
+}
+
+func (p *GitHubProvider) ListComments(ctx context.Context, owner, repo, prNumber string) ([]string, error) {
+	prNum, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodies []string
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		comments, resp, err := p.Client.Issues.ListComments(ctx, owner, repo, prNum, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			bodies = append(bodies, comment.GetBody())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return bodies, nil
+}
+
+func (p *GitHubProvider) PostComment(ctx context.Context, owner, repo, prNumber, body string) error {
+	prNum, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return err
+	}
+
+	comment := &github.IssueComment{Body: github.String(body)}
+	if _, _, err := p.Client.Issues.CreateComment(ctx, owner, repo, prNum, comment); err != nil {
+		return fmt.Errorf("error posting comment: %w", err)
+	}
+	return nil
+}
+
+// UserEmail returns the public email of a user, empty if they do not show one
+func (p *GitHubProvider) UserEmail(ctx context.Context, login string) (string, error) {
+	user, _, err := p.Client.Users.Get(ctx, login)
+	if err != nil {
+		return "", err
+	}
+	return user.GetEmail(), nil
+}
@@ -0,0 +1,131 @@
+package githubclient
+
+import (
+	"slices"
+	"time"
+)
+
+// Review states exposed on ReviewRequest.ReviewState
+const (
+	ReviewStateApproved         = "approved"
+	ReviewStateChangesRequested = "changes_requested"
+	ReviewStateCommented        = "commented"
+	ReviewStateNone             = "none"
+)
+
+// Reasons a pull request is waiting on its author, as returned by WaitingOnAuthor
+const (
+	AuthorReasonChangesRequested  = "changes requested"
+	AuthorReasonChecksFailing     = "checks failing"
+	AuthorReasonUnresolvedThreads = "unresolved review threads"
+)
+
+// reviewStates maps the review states returned by the API onto ReviewRequest.ReviewState.
+// Pending and dismissed reviews are ignored.
+var reviewStates = map[string]string{
+	"APPROVED":          ReviewStateApproved,
+	"CHANGES_REQUESTED": ReviewStateChangesRequested,
+	"COMMENTED":         ReviewStateCommented,
+}
+
+// AnnotateReviewRequests fills ReviewState and LastActivity on every review request from the
+// reviews and comments of the pull request. A team is credited with the reviews its members
+// submitted on its behalf; comments only count for individual reviewers.
+func (d *PullRequestData) AnnotateReviewRequests() {
+	for i := range d.ReviewRequests {
+		req := &d.ReviewRequests[i]
+		req.ReviewState = ReviewStateNone
+
+		var latestReview Review
+		for _, review := range d.Reviews {
+			state, ok := reviewStates[review.State]
+			if !ok {
+				continue
+			}
+			if req.IsTeam && !slices.Contains(review.OnBehalfOf, req.Slug) {
+				continue
+			}
+			if !req.IsTeam && review.Author != req.From {
+				continue
+			}
+			if !review.SubmittedAt.Before(latestReview.SubmittedAt) {
+				latestReview = review
+				req.ReviewState = state
+			}
+		}
+		req.LastActivity = latestReview.SubmittedAt
+
+		if req.IsTeam {
+			continue
+		}
+		for _, comment := range d.Comments {
+			if comment.Author != req.From {
+				continue
+			}
+			if req.ReviewState == ReviewStateNone {
+				req.ReviewState = ReviewStateCommented
+			}
+			if comment.CreatedAt.After(req.LastActivity) {
+				req.LastActivity = comment.CreatedAt
+			}
+		}
+	}
+}
+
+// WaitingOnAuthor returns why the pull request is waiting on its author, if it is, along with
+// the time the author was handed the ball: changes were requested and no commit was pushed
+// since, checks failed on the head commit, or review threads are still unresolved. Only the
+// reviewers who started an unresolved thread, listed in UnresolvedThreadAuthors, wait on it.
+func (d *PullRequestData) WaitingOnAuthor(checks *ChecksStatus) ([]string, time.Time) {
+	var reasons []string
+	var since, lastReview time.Time
+
+	// Only approvals, change requests and dismissals change where a reviewer stands
+	latest := make(map[string]Review)
+	for _, review := range d.Reviews {
+		if review.SubmittedAt.After(lastReview) {
+			lastReview = review.SubmittedAt
+		}
+		if review.State != "APPROVED" && review.State != "CHANGES_REQUESTED" && review.State != "DISMISSED" {
+			continue
+		}
+		if !review.SubmittedAt.Before(latest[review.Author].SubmittedAt) {
+			latest[review.Author] = review
+		}
+	}
+
+	// A change request stands until the author pushes a commit. Providers that do not report
+	// commit times leave LastCommitAt zero, in which case it stands until the reviewer changes it.
+	changesRequested := false
+	for _, review := range latest {
+		if review.State == "CHANGES_REQUESTED" && (d.LastCommitAt.IsZero() || review.SubmittedAt.After(d.LastCommitAt)) {
+			changesRequested = true
+			if review.SubmittedAt.After(since) {
+				since = review.SubmittedAt
+			}
+		}
+	}
+	if changesRequested {
+		reasons = append(reasons, AuthorReasonChangesRequested)
+	}
+
+	if checks != nil && checks.State == CheckStateFailure {
+		reasons = append(reasons, AuthorReasonChecksFailing)
+		if !d.LastCommitAt.IsZero() && d.LastCommitAt.After(since) {
+			since = d.LastCommitAt
+		}
+	}
+
+	if d.UnresolvedThreads > 0 {
+		reasons = append(reasons, AuthorReasonUnresolvedThreads)
+		if lastReview.After(since) {
+			since = lastReview
+		}
+	}
+
+	if len(reasons) > 0 && since.IsZero() {
+		since = d.State.UpdatedAt
+	}
+
+	return reasons, since
+}
@@ -0,0 +1,112 @@
+package githubclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotateReviewRequests(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2023, 4, 1, hour, 0, 0, 0, time.UTC) }
+
+	data := &PullRequestData{
+		ReviewRequests: []ReviewRequest{
+			{From: "alice"},
+			{From: "bob"},
+			{From: "carol"},
+			{From: "Backend Team", IsTeam: true, Slug: "backend-team"},
+			{From: "Frontend Team", IsTeam: true, Slug: "frontend-team"},
+		},
+		Reviews: []Review{
+			{Author: "alice", State: "CHANGES_REQUESTED", SubmittedAt: at(10)},
+			{Author: "alice", State: "APPROVED", SubmittedAt: at(12)},
+			{Author: "bob", State: "PENDING", SubmittedAt: at(11)},
+			{Author: "dave", State: "APPROVED", SubmittedAt: at(13), OnBehalfOf: []string{"backend-team"}},
+		},
+		Comments: []Comment{
+			{Author: "bob", CreatedAt: at(14)},
+			{Author: "alice", CreatedAt: at(9)},
+		},
+	}
+
+	data.AnnotateReviewRequests()
+
+	assert.Equal(t, ReviewStateApproved, data.ReviewRequests[0].ReviewState)
+	assert.Equal(t, at(12), data.ReviewRequests[0].LastActivity)
+
+	// Pending reviews are ignored, but comments count as activity
+	assert.Equal(t, ReviewStateCommented, data.ReviewRequests[1].ReviewState)
+	assert.Equal(t, at(14), data.ReviewRequests[1].LastActivity)
+
+	assert.Equal(t, ReviewStateNone, data.ReviewRequests[2].ReviewState)
+	assert.True(t, data.ReviewRequests[2].LastActivity.IsZero())
+
+	// Teams are credited with reviews submitted on their behalf
+	assert.Equal(t, ReviewStateApproved, data.ReviewRequests[3].ReviewState)
+	assert.Equal(t, at(13), data.ReviewRequests[3].LastActivity)
+	assert.Equal(t, ReviewStateNone, data.ReviewRequests[4].ReviewState)
+}
+
+func TestWaitingOnAuthor(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2023, 4, 1, hour, 0, 0, 0, time.UTC) }
+
+	t.Run("changes requested after the last commit", func(t *testing.T) {
+		data := &PullRequestData{
+			LastCommitAt: at(10),
+			Reviews: []Review{
+				{Author: "alice", State: "CHANGES_REQUESTED", SubmittedAt: at(11)},
+				{Author: "alice", State: "COMMENTED", SubmittedAt: at(12)},
+				{Author: "bob", State: "CHANGES_REQUESTED", SubmittedAt: at(9)},
+			},
+		}
+
+		reasons, since := data.WaitingOnAuthor(nil)
+
+		assert.Equal(t, []string{AuthorReasonChangesRequested}, reasons)
+		assert.Equal(t, at(11), since)
+	})
+
+	t.Run("changes requested then approved", func(t *testing.T) {
+		data := &PullRequestData{
+			LastCommitAt: at(10),
+			Reviews: []Review{
+				{Author: "alice", State: "CHANGES_REQUESTED", SubmittedAt: at(11)},
+				{Author: "alice", State: "APPROVED", SubmittedAt: at(12)},
+			},
+		}
+
+		reasons, _ := data.WaitingOnAuthor(&ChecksStatus{State: CheckStateSuccess})
+
+		assert.Empty(t, reasons)
+	})
+
+	t.Run("failing checks and unresolved threads", func(t *testing.T) {
+		data := &PullRequestData{
+			LastCommitAt:      at(10),
+			UnresolvedThreads: 2,
+			Reviews: []Review{
+				{Author: "alice", State: "COMMENTED", SubmittedAt: at(13)},
+			},
+		}
+
+		reasons, since := data.WaitingOnAuthor(&ChecksStatus{State: CheckStateFailure})
+
+		assert.Equal(t, []string{AuthorReasonChecksFailing, AuthorReasonUnresolvedThreads}, reasons)
+		assert.Equal(t, at(13), since)
+	})
+	t.Run("changes requested without commit times", func(t *testing.T) {
+		// Providers that do not report commit times leave LastCommitAt zero
+		data := &PullRequestData{
+			State: PullRequestState{UpdatedAt: at(15)},
+			Reviews: []Review{
+				{Author: "alice", State: "CHANGES_REQUESTED", SubmittedAt: at(11)},
+			},
+		}
+
+		reasons, since := data.WaitingOnAuthor(&ChecksStatus{State: CheckStateFailure})
+
+		assert.Equal(t, []string{AuthorReasonChangesRequested, AuthorReasonChecksFailing}, reasons)
+		assert.Equal(t, at(11), since)
+	})
+}
@@ -0,0 +1,92 @@
+package githubclient
+
+import (
+	"context"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/rs/zerolog/log"
+)
+
+// TeamsProvider is implemented by providers that can expand team review requests into the
+// members of the team
+type TeamsProvider interface {
+	ExpandTeams(ctx context.Context, owner string, data *PullRequestData) error
+}
+
+func (p *GitHubProvider) ExpandTeams(ctx context.Context, owner string, data *PullRequestData) error {
+	return expandTeams(ctx, p.Client, owner, data)
+}
+
+// expandTeams replaces the team review requests of a pull request with one request per member
+// of the team, child teams included. The PR author, members who reviewed since the team was
+// requested and members who were requested individually are left out. Member requests keep the
+// team name in Team so that rules can match either the team or its members.
+func expandTeams(ctx context.Context, client *github.Client, org string, data *PullRequestData) error {
+	requested := make(map[string]bool)
+	for _, req := range data.ReviewRequests {
+		if !req.IsTeam {
+			requested[req.From] = true
+		}
+	}
+
+	var expanded []ReviewRequest
+	for _, req := range data.ReviewRequests {
+		if !req.IsTeam {
+			expanded = append(expanded, req)
+			continue
+		}
+
+		members, err := teamMembers(ctx, client, org, req.Slug)
+		if err != nil {
+			return err
+		}
+
+		reviewed := make(map[string]bool)
+		for _, review := range data.Reviews {
+			if _, ok := reviewStates[review.State]; ok && review.SubmittedAt.After(req.On) {
+				reviewed[review.Author] = true
+			}
+		}
+
+		for _, member := range members {
+			if member == data.Author || reviewed[member] || requested[member] {
+				continue
+			}
+			requested[member] = true
+			expanded = append(expanded, ReviewRequest{
+				From:     member,
+				On:       req.On,
+				PRTitle:  req.PRTitle,
+				PRAuthor: req.PRAuthor,
+				Team:     req.From,
+			})
+		}
+		log.Debug().Msgf("Expanded team %s into %d members", req.From, len(members))
+	}
+
+	data.ReviewRequests = expanded
+	data.AnnotateReviewRequests()
+	return nil
+}
+
+// teamMembers returns the logins of the members of a team, child teams included
+func teamMembers(ctx context.Context, client *github.Client, org, slug string) ([]string, error) {
+	var members []string
+	opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		users, resp, err := client.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			members = append(members, user.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return members, nil
+}
@@ -0,0 +1,70 @@
+package githubclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTeams(t *testing.T) {
+	calls := make(map[string]int)
+	responses := map[string]string{
+		// Members of child teams are included by the API
+		"/orgs/testorg/teams/backend/members": `[{"login": "alice"}, {"login": "bob"}, {"login": "author"}, {"login": "carol"}, {"login": "dave"}]`,
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf(writeResponseErrMsg, err)
+		}
+	}))
+	defer mockServer.Close()
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(mockServer.URL + "/")
+	client.BaseURL = baseURL
+	provider := NewGitHubProvider(client)
+
+	requestedAt := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	newData := func() *PullRequestData {
+		return &PullRequestData{
+			Author: "author",
+			ReviewRequests: []ReviewRequest{
+				{From: "carol", On: requestedAt},
+				{From: "Backend", IsTeam: true, Slug: "backend", On: requestedAt, PRTitle: "Add feature", PRAuthor: "author"},
+			},
+			Reviews: []Review{
+				{Author: "bob", State: "APPROVED", SubmittedAt: requestedAt.Add(time.Hour)},
+				// Reviewed before the team was requested, e.g. on an earlier round
+				{Author: "dave", State: "CHANGES_REQUESTED", SubmittedAt: requestedAt.Add(-time.Hour)},
+			},
+		}
+	}
+
+	data := newData()
+	err := provider.ExpandTeams(context.Background(), "testorg", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ReviewRequest{
+		{From: "carol", On: requestedAt, ReviewState: ReviewStateNone},
+		{From: "alice", On: requestedAt, PRTitle: "Add feature", PRAuthor: "author", Team: "Backend", ReviewState: ReviewStateNone},
+		{From: "dave", On: requestedAt, PRTitle: "Add feature", PRAuthor: "author", Team: "Backend",
+			ReviewState: ReviewStateChangesRequested, LastActivity: requestedAt.Add(-time.Hour)},
+	}, data.ReviewRequests)
+
+	assert.Equal(t, 1, calls["/orgs/testorg/teams/backend/members"])
+	assert.Len(t, calls, 1)
+}
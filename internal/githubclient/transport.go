@@ -0,0 +1,269 @@
+package githubclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// ErrAPIBudgetExceeded is returned once gong has made as many API calls as allowed by --max-api-calls
+var ErrAPIBudgetExceeded = errors.New("API call budget exceeded")
+
+const (
+	// defaultMaxRetries is the number of times a failed request is retried
+	defaultMaxRetries = 3
+	// maxRetryWait is the longest gong accepts to wait before retrying a request
+	maxRetryWait = 5 * time.Minute
+	// lowRateLimitThreshold is the remaining rate limit under which gong starts warning
+	lowRateLimitThreshold = 100
+)
+
+// Variable to allow sleeping to be mocked in tests
+var sleep = time.Sleep
+
+// TransportOptions configures the HTTP transport used by the GitHub client
+type TransportOptions struct {
+	MaxRetries  int    // Number of retries of idempotent requests on 5xx and rate-limited responses
+	MaxAPICalls int    // Maximum number of HTTP requests sent to GitHub (0 means unlimited)
+	CacheDir    string // Directory used to store responses for conditional requests ("" disables caching)
+}
+
+// TransportOptionsFromConfig builds transport options from the max-api-calls and http-cache settings
+func TransportOptionsFromConfig() TransportOptions {
+	opts := TransportOptions{
+		MaxRetries:  defaultMaxRetries,
+		MaxAPICalls: viper.GetInt("max-api-calls"),
+	}
+
+	if viper.GetBool("http-cache") {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			log.Warn().Msgf("Could not find a cache directory, disabling HTTP cache: %v", err)
+		} else {
+			opts.CacheDir = filepath.Join(cacheDir, "gong", "http")
+		}
+	}
+
+	return opts
+}
+
+// transport retries failed requests, enforces the API call budget and serves unchanged
+// responses from the on-disk cache through ETag conditional requests.
+type transport struct {
+	base  http.RoundTripper
+	opts  TransportOptions
+	calls atomic.Int64
+}
+
+// NewTransport wraps base with retries, the API call budget and the on-disk cache. It is used by
+// the GitHub client and can be reused by other code host clients.
+func NewTransport(base http.RoundTripper, opts TransportOptions) http.RoundTripper {
+	return newTransport(base, opts)
+}
+
+func newTransport(base http.RoundTripper, opts TransportOptions) *transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, opts: opts}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := isRetryable(req)
+	cached := t.readCache(req)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.Header.Get("ETag"))
+	}
+
+	for attempt := 0; ; attempt++ {
+		if t.opts.MaxAPICalls > 0 && t.calls.Load() >= int64(t.opts.MaxAPICalls) {
+			return nil, fmt.Errorf("%w (%d calls)", ErrAPIBudgetExceeded, t.opts.MaxAPICalls)
+		}
+		t.calls.Add(1)
+
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		warnOnLowRateLimit(req, resp)
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			log.Trace().Msgf("Serving %s %s from cache", req.Method, req.URL)
+			closeBody(resp)
+			return cached, nil
+		}
+
+		wait, retry := retryDelay(resp, attempt)
+		if !retry || !retryable || attempt >= t.opts.MaxRetries {
+			if resp.StatusCode == http.StatusOK {
+				t.writeCache(req, resp)
+			}
+			return resp, nil
+		}
+
+		log.Warn().Msgf("API returned %d for %s %s %s, retrying in %s", resp.StatusCode, req.Method, req.URL.Host, req.URL.Path, wait)
+		closeBody(resp)
+		sleep(wait)
+	}
+}
+
+// isRetryable tells whether a request can safely be sent again. Only reads are retried: a POST
+// that failed with a server error may still have been applied, and sending it again could post a
+// second comment or request reviews twice. GraphQL queries are POSTs but only read, so they are
+// retried unless they carry a mutation.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(req.URL.Path, "/graphql") && !isGraphQLMutation(req)
+	}
+	return false
+}
+
+// isGraphQLMutation tells whether a GraphQL request carries a mutation, which is assumed when its
+// body cannot be read
+func isGraphQLMutation(req *http.Request) bool {
+	if req.GetBody == nil {
+		return true
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return true
+	}
+	defer body.Close()
+
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+}
+
+// rewindRequest returns a request whose body can be sent again for the given attempt
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// retryDelay tells whether a response should be retried and how long to wait before doing so.
+// Rate-limited responses honor Retry-After and X-RateLimit-Reset, server errors back off exponentially.
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	backoff := time.Duration(1<<attempt) * time.Second
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait := time.Duration(seconds) * time.Second
+			return wait, wait <= maxRetryWait
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				return backoff, true
+			}
+			wait := max(time.Until(time.Unix(reset, 0)), time.Second)
+			return wait, wait <= maxRetryWait
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return backoff, true
+		}
+		return 0, false
+	case resp.StatusCode >= 500:
+		return backoff, true
+	}
+
+	return 0, false
+}
+
+func warnOnLowRateLimit(req *http.Request, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining >= lowRateLimitThreshold || remaining == 0 {
+		return
+	}
+	log.Warn().Msgf("API rate limit for %s is running low: %d requests remaining", req.URL.Host, remaining)
+}
+
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String() + " " + req.Header.Get("Authorization") + " " + req.Header.Get("PRIVATE-TOKEN") + " " + req.Header.Get("Accept")))
+	return hex.EncodeToString(sum[:])
+}
+
+// readCache returns the cached response for a GET request, if any
+func (t *transport) readCache(req *http.Request) *http.Response {
+	if t.opts.CacheDir == "" || req.Method != http.MethodGet {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(t.opts.CacheDir, cacheKey(req)))
+	if err != nil {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil || resp.Header.Get("ETag") == "" {
+		return nil
+	}
+	return resp
+}
+
+// writeCache stores a successful GET response carrying an ETag
+func (t *transport) writeCache(req *http.Request, resp *http.Response) {
+	if t.opts.CacheDir == "" || req.Method != http.MethodGet || resp.Header.Get("ETag") == "" {
+		return
+	}
+
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		log.Debug().Msgf("Could not cache response for %s: %v", req.URL, err)
+		return
+	}
+
+	if err := os.MkdirAll(t.opts.CacheDir, 0700); err != nil {
+		log.Debug().Msgf("Could not create cache directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(t.opts.CacheDir, cacheKey(req)), data, 0600); err != nil {
+		log.Debug().Msgf("Could not cache response for %s: %v", req.URL, err)
+	}
+}
+
+func closeBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if err := resp.Body.Close(); err != nil {
+		log.Debug().Msgf("Error closing response body: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package githubclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTransportTestClient returns a client whose transport talks to the given handler
+func newTransportTestClient(t *testing.T, opts TransportOptions, handler http.HandlerFunc) *github.Client {
+	mockServer := httptest.NewServer(handler)
+	t.Cleanup(mockServer.Close)
+
+	client := NewClientWithOptions("test-token", opts)
+	baseURL, _ := url.Parse(mockServer.URL + "/")
+	client.BaseURL = baseURL
+	return client
+}
+
+func mockSleep(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { sleep = time.Sleep })
+	return &waits
+}
+
+func TestTransportRetriesServerErrors(t *testing.T) {
+	waits := mockSleep(t)
+	calls := 0
+	client := newTransportTestClient(t, TransportOptions{MaxRetries: 3}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = w.Write([]byte(`{"login": "octocat"}`))
+	})
+
+	user, _, err := client.Users.Get(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "octocat", user.GetLogin())
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *waits)
+}
+
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	waits := mockSleep(t)
+	calls := 0
+	client := newTransportTestClient(t, TransportOptions{MaxRetries: 3}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+			return
+		}
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = w.Write([]byte(`{"login": "octocat"}`))
+	})
+
+	_, _, err := client.Users.Get(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{30 * time.Second}, *waits)
+}
+
+func TestTransportHonorsRateLimitReset(t *testing.T) {
+	waits := mockSleep(t)
+	calls := 0
+	client := newTransportTestClient(t, TransportOptions{MaxRetries: 3}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = w.Write([]byte(`{"login": "octocat"}`))
+	})
+
+	_, _, err := client.Users.Get(context.Background(), "")
+	assert.NoError(t, err)
+	// A reset time in the past still waits a little before retrying
+	assert.Equal(t, []time.Duration{time.Second}, *waits)
+}
+
+func TestTransportDoesNotRetryPermissionErrors(t *testing.T) {
+	waits := mockSleep(t)
+	calls := 0
+	client := newTransportTestClient(t, TransportOptions{MaxRetries: 3}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	_, _, err := client.Users.Get(context.Background(), "")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, *waits)
+}
+
+func TestTransportAPICallBudget(t *testing.T) {
+	calls := 0
+	client := newTransportTestClient(t, TransportOptions{MaxAPICalls: 2}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = w.Write([]byte(`{"login": "octocat"}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		_, _, err := client.Users.Get(context.Background(), "")
+		assert.NoError(t, err)
+	}
+
+	_, _, err := client.Users.Get(context.Background(), "")
+	assert.ErrorIs(t, err, ErrAPIBudgetExceeded)
+	assert.Equal(t, 2, calls)
+}
+
+func TestTransportConditionalRequests(t *testing.T) {
+	var ifNoneMatch []string
+	client := newTransportTestClient(t, TransportOptions{CacheDir: t.TempDir()}, func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"login": "octocat"}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		user, _, err := client.Users.Get(context.Background(), "")
+		assert.NoError(t, err)
+		assert.Equal(t, "octocat", user.GetLogin())
+	}
+
+	assert.Equal(t, []string{"", `"v1"`}, ifNoneMatch)
+}
+
+func TestTransportDoesNotRetryWrites(t *testing.T) {
+	waits := mockSleep(t)
+	calls := 0
+	client := newTransportTestClient(t, TransportOptions{MaxRetries: 3}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, _, err := client.Issues.CreateComment(context.Background(), "owner", "repo", 1, &github.IssueComment{Body: github.Ptr("hello")})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, *waits)
+}
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected bool
+	}{
+		{name: "GET", method: http.MethodGet, path: "/repos/owner/repo", expected: true},
+		{name: "HEAD", method: http.MethodHead, path: "/repos/owner/repo", expected: true},
+		{name: "REST POST", method: http.MethodPost, path: "/repos/owner/repo/issues/1/comments", body: `{"body":"hello"}`},
+		{name: "DELETE", method: http.MethodDelete, path: "/repos/owner/repo/pulls/1/requested_reviewers"},
+		{name: "GraphQL query", method: http.MethodPost, path: "/graphql", body: `{"query":"query($owner: String!) { viewer { login } }"}`, expected: true},
+		{name: "GraphQL mutation", method: http.MethodPost, path: "/graphql", body: `{"query":" mutation { addComment(input: {}) { clientMutationId } }"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "https://api.github.com"+tc.path, strings.NewReader(tc.body))
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(tc.body)), nil }
+
+			assert.Equal(t, tc.expected, isRetryable(req))
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package gitlabclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/restclient"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultBaseURL is the URL of gitlab.com, used when no instance URL is configured
+const DefaultBaseURL = "https://gitlab.com"
+
+// Client implements githubclient.Provider for GitLab merge requests
+type Client struct {
+	api *restclient.Client
+}
+
+// NewClient creates a GitLab client for the instance at instanceURL (e.g. https://gitlab.example.com)
+func NewClient(instanceURL, token string, opts githubclient.TransportOptions) (*Client, error) {
+	if instanceURL == "" {
+		instanceURL = DefaultBaseURL
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(instanceURL, "/") + "/api/v4/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitLab URL %q: %w", instanceURL, err)
+	}
+
+	return &Client{api: &restclient.Client{
+		Service:      "gitlab",
+		BaseURL:      baseURL,
+		HTTPClient:   restclient.NewHTTPClient(opts),
+		Authenticate: func(req *http.Request) { req.Header.Set("PRIVATE-TOKEN", token) },
+		ErrorMessage: errorMessage,
+	}}, nil
+}
+
+type user struct {
+	Username string `json:"username"`
+}
+
+type mergeRequest struct {
+	IID       int       `json:"iid"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	Draft     bool      `json:"draft"`
+	WebURL    string    `json:"web_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Author    user      `json:"author"`
+	Labels    []string  `json:"labels"`
+	Reviewers []user    `json:"reviewers"`
+}
+
+type note struct {
+	Author    user      `json:"author"`
+	Body      string    `json:"body"`
+	System    bool      `json:"system"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type approvals struct {
+	ApprovedBy []struct {
+		User       user      `json:"user"`
+		ApprovedAt time.Time `json:"approved_at"` // Only reported by recent GitLab versions
+	} `json:"approved_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c *Client) Name() string {
+	return "gitlab"
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo, prNumber string) (*githubclient.PullRequestData, error) {
+	mrPath := projectPath(owner, repo) + "/merge_requests/" + url.PathEscape(prNumber)
+
+	var mr mergeRequest
+	if err := c.api.Do(ctx, http.MethodGet, mrPath, nil, &mr); err != nil {
+		return nil, restclient.NotFound(err)
+	}
+
+	log.Debug().Msgf("Working on MR %s/%s!%d : '%s' by %s", owner, repo, mr.IID, mr.Title, mr.Author.Username)
+
+	var notes []note
+	err := c.getPaginated(ctx, mrPath+"/notes?sort=asc&order_by=created_at", func(data []byte) error {
+		var page []note
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		notes = append(notes, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mrApprovals approvals
+	if err := c.api.Do(ctx, http.MethodGet, mrPath+"/approvals", nil, &mrApprovals); err != nil {
+		return nil, err
+	}
+
+	data := &githubclient.PullRequestData{
+		Number: mr.IID,
+		Title:  mr.Title,
+		Author: mr.Author.Username,
+		URL:    mr.WebURL,
+		Labels: mr.Labels,
+		State: githubclient.PullRequestState{
+			IsOpen:    mr.State == "opened",
+			IsMerged:  mr.State == "merged",
+			IsClosed:  mr.State == "closed" || mr.State == "locked",
+			IsDraft:   mr.Draft,
+			CreatedAt: mr.CreatedAt,
+			UpdatedAt: mr.UpdatedAt,
+		},
+	}
+
+	approvedAt := approvalTimestamps(notes)
+	for _, approval := range mrApprovals.ApprovedBy {
+		submittedAt := approval.ApprovedAt
+		if submittedAt.IsZero() {
+			submittedAt = approvedAt[approval.User.Username]
+		}
+		if submittedAt.IsZero() {
+			// Without a note either, the last change of the approvals is the best guess
+			submittedAt = mrApprovals.UpdatedAt
+		}
+		data.Reviews = append(data.Reviews, githubclient.Review{
+			Author:      approval.User.Username,
+			State:       "APPROVED",
+			SubmittedAt: submittedAt,
+		})
+	}
+
+	for _, n := range notes {
+		if !n.System {
+			data.Comments = append(data.Comments, githubclient.Comment{Author: n.Author.Username, CreatedAt: n.CreatedAt})
+		}
+	}
+
+	timestamps := reviewRequestTimestamps(notes)
+	for _, reviewer := range mr.Reviewers {
+		timestamp, exists := timestamps[reviewer.Username]
+		if !exists {
+			// If we couldn't find a timestamp, use current time as fallback
+			timestamp = time.Now()
+		}
+		data.ReviewRequests = append(data.ReviewRequests, githubclient.ReviewRequest{
+			From:     reviewer.Username,
+			On:       timestamp,
+			PRTitle:  mr.Title,
+			PRAuthor: mr.Author.Username,
+		})
+	}
+
+	data.AnnotateReviewRequests()
+	return data, nil
+}
+
+func (c *Client) ListComments(ctx context.Context, owner, repo, prNumber string) ([]string, error) {
+	var bodies []string
+	path := projectPath(owner, repo) + "/merge_requests/" + url.PathEscape(prNumber) + "/notes"
+
+	err := c.getPaginated(ctx, path, func(data []byte) error {
+		var notes []note
+		if err := json.Unmarshal(data, &notes); err != nil {
+			return err
+		}
+		for _, n := range notes {
+			if !n.System {
+				bodies = append(bodies, n.Body)
+			}
+		}
+		return nil
+	})
+
+	return bodies, restclient.NotFound(err)
+}
+
+func (c *Client) PostComment(ctx context.Context, owner, repo, prNumber, body string) error {
+	path := projectPath(owner, repo) + "/merge_requests/" + url.PathEscape(prNumber) + "/notes"
+	return c.api.Do(ctx, http.MethodPost, path, map[string]string{"body": body}, nil)
+}
+
+var (
+	requestedReviewPattern = regexp.MustCompile(`^requested review from (.+)$`)
+	removedReviewPattern   = regexp.MustCompile(`^removed review request for (.+)$`)
+	usernamePattern        = regexp.MustCompile(`@([\w.\-]+)`)
+)
+
+// reviewRequestTimestamps extracts when each reviewer was last asked for a review from the
+// system notes of a merge request, which are expected in chronological order
+func reviewRequestTimestamps(notes []note) map[string]time.Time {
+	timestamps := make(map[string]time.Time)
+
+	for _, n := range notes {
+		if !n.System {
+			continue
+		}
+
+		// A single system note may contain several actions, one per line
+		for _, line := range strings.Split(n.Body, "\n") {
+			line = strings.TrimSpace(line)
+			if m := requestedReviewPattern.FindStringSubmatch(line); m != nil {
+				for _, u := range usernamePattern.FindAllStringSubmatch(m[1], -1) {
+					timestamps[u[1]] = n.CreatedAt
+				}
+			} else if m := removedReviewPattern.FindStringSubmatch(line); m != nil {
+				for _, u := range usernamePattern.FindAllStringSubmatch(m[1], -1) {
+					delete(timestamps, u[1])
+				}
+			}
+		}
+	}
+
+	return timestamps
+}
+
+// approvalTimestamps returns when each user last approved a merge request, from the system notes
+// GitLab adds when a user approves or revokes their approval
+func approvalTimestamps(notes []note) map[string]time.Time {
+	timestamps := make(map[string]time.Time)
+
+	for _, n := range notes {
+		if !n.System {
+			continue
+		}
+
+		switch strings.TrimSpace(n.Body) {
+		case "approved this merge request":
+			timestamps[n.Author.Username] = n.CreatedAt
+		case "unapproved this merge request":
+			delete(timestamps, n.Author.Username)
+		}
+	}
+
+	return timestamps
+}
+
+// projectPath returns the API path of a project, whose full path may include subgroups
+func projectPath(owner, repo string) string {
+	return "projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// getPaginated calls handle with the body of every page of a list endpoint
+func (c *Client) getPaginated(ctx context.Context, path string, handle func([]byte) error) error {
+	return c.api.Paginate(ctx, restclient.WithQuery(path, "per_page=100&page=1"), func(resp *http.Response, data []byte) (string, error) {
+		if err := handle(data); err != nil {
+			return "", err
+		}
+		if page := resp.Header.Get("X-Next-Page"); page != "" {
+			return restclient.WithQuery(path, "per_page=100&page="+page), nil
+		}
+		return "", nil
+	})
+}
+
+// errorMessage extracts the message of an error response, which GitLab reports either as a
+// message, possibly a map of field errors, or an error
+func errorMessage(body []byte) string {
+	var apiErr struct {
+		Message interface{} `json:"message"`
+		Error   string      `json:"error"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	if apiErr.Message != nil {
+		return fmt.Sprint(apiErr.Message)
+	}
+	return apiErr.Error
+}
@@ -0,0 +1,133 @@
+package gitlabclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/stretchr/testify/assert"
+)
+
+const projectAPIPath = "/api/v4/projects/group%2Fsubgroup%2Fproject"
+
+func createMockServer(t *testing.T, posted *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body string
+		switch {
+		case r.Method == "GET" && r.URL.EscapedPath() == projectAPIPath+"/merge_requests/3":
+			body = `{
+				"iid": 3,
+				"title": "Add feature",
+				"state": "opened",
+				"draft": false,
+				"web_url": "https://gitlab.example.com/group/subgroup/project/-/merge_requests/3",
+				"created_at": "2023-04-01T12:00:00Z",
+				"updated_at": "2023-04-01T13:00:00Z",
+				"author": {"username": "author"},
+				"labels": ["backend"],
+				"reviewers": [{"username": "alice"}, {"username": "bob"}]
+			}`
+		case r.Method == "GET" && r.URL.EscapedPath() == projectAPIPath+"/merge_requests/3/notes" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-Next-Page", "2")
+			body = `[
+				{"body": "requested review from @alice and @bob", "system": true, "created_at": "2023-04-01T12:00:00Z"},
+				{"body": "Looks good so far", "system": false, "created_at": "2023-04-01T12:10:00Z"}
+			]`
+		case r.Method == "GET" && r.URL.EscapedPath() == projectAPIPath+"/merge_requests/3/notes":
+			body = `[
+				{"body": "removed review request for @bob", "system": true, "created_at": "2023-04-01T13:00:00Z"},
+				{"body": "requested review from @bob", "system": true, "created_at": "2023-04-02T09:00:00Z"},
+				{"author": {"username": "dave"}, "body": "approved this merge request", "system": true, "created_at": "2023-04-02T09:30:00Z"}
+			]`
+		case r.Method == "GET" && r.URL.EscapedPath() == projectAPIPath+"/merge_requests/3/approvals":
+			body = `{
+				"approved_by": [
+					{"user": {"username": "carol"}, "approved_at": "2023-04-02T08:00:00Z"},
+					{"user": {"username": "dave"}}
+				],
+				"updated_at": "2023-04-02T10:00:00Z"
+			}`
+		case r.Method == "POST" && r.URL.EscapedPath() == projectAPIPath+"/merge_requests/3/notes":
+			var note map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+				t.Fatalf("Failed to decode note: %v", err)
+			}
+			*posted = append(*posted, note["body"])
+			w.WriteHeader(http.StatusCreated)
+			body = `{}`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"message": "404 Not found"}`
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("Failed to write response: %v", err)
+		}
+	}))
+}
+
+func newTestClient(t *testing.T, posted *[]string) *Client {
+	mockServer := createMockServer(t, posted)
+	t.Cleanup(mockServer.Close)
+
+	client, err := NewClient(mockServer.URL, "test-token", githubclient.TransportOptions{})
+	assert.NoError(t, err)
+	return client
+}
+
+func TestGetPullRequest(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	data, err := client.GetPullRequest(context.Background(), "group/subgroup", "project", "3")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Add feature", data.Title)
+	assert.Equal(t, "author", data.Author)
+	assert.Equal(t, []string{"backend"}, data.Labels)
+	assert.Equal(t, "https://gitlab.example.com/group/subgroup/project/-/merge_requests/3", data.URL)
+	assert.True(t, data.State.IsOpen)
+	assert.False(t, data.State.IsMerged)
+
+	assert.Len(t, data.ReviewRequests, 2)
+	assert.Equal(t, "alice", data.ReviewRequests[0].From)
+	assert.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), data.ReviewRequests[0].On)
+	assert.Equal(t, "author", data.ReviewRequests[0].PRAuthor)
+	assert.Equal(t, "bob", data.ReviewRequests[1].From)
+	assert.Equal(t, time.Date(2023, 4, 2, 9, 0, 0, 0, time.UTC), data.ReviewRequests[1].On)
+
+	// Each approval keeps its own time, read from the approval or else from the approval note
+	assert.Equal(t, []githubclient.Review{
+		{Author: "carol", State: "APPROVED", SubmittedAt: time.Date(2023, 4, 2, 8, 0, 0, 0, time.UTC)},
+		{Author: "dave", State: "APPROVED", SubmittedAt: time.Date(2023, 4, 2, 9, 30, 0, 0, time.UTC)},
+	}, data.Reviews)
+}
+
+func TestGetPullRequestNotFound(t *testing.T) {
+	client := newTestClient(t, nil)
+
+	_, err := client.GetPullRequest(context.Background(), "group/subgroup", "project", "99")
+	assert.ErrorIs(t, err, githubclient.ErrPullRequestNotFound)
+}
+
+func TestComments(t *testing.T) {
+	var posted []string
+	client := newTestClient(t, &posted)
+
+	comments, err := client.ListComments(context.Background(), "group/subgroup", "project", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Looks good so far"}, comments)
+
+	err = client.PostComment(context.Background(), "group/subgroup", "project", "3", "Please review\n<!-- gong -->")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Please review\n<!-- gong -->"}, posted)
+}
@@ -0,0 +1,262 @@
+package identity
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Directory maps code host logins and team names onto the user IDs or group handles of one chat
+// platform, so that notifications can mention people in a way that actually notifies them
+type Directory struct {
+	Users  map[string]string // Lowercase login -> chat user ID
+	Teams  map[string]string // Lowercase team name or slug -> chat group handle
+	Emails map[string]string // Lowercase email -> chat user ID, read from a chat user export
+	// EmailLookup returns the public email of a login, used to match logins missing from Users
+	// against Emails. Nil disables email matching.
+	EmailLookup func(ctx context.Context, login string) (string, error)
+
+	emailCache map[string]string
+}
+
+// Directories holds the directory of each chat platform, keyed by integration type (slack, teams,
+// matrix, telegram...), since a reviewer has a different ID on each
+type Directories map[string]*Directory
+
+// New returns an empty directory
+func New() *Directory {
+	return &Directory{
+		Users:      make(map[string]string),
+		Teams:      make(map[string]string),
+		Emails:     make(map[string]string),
+		emailCache: make(map[string]string),
+	}
+}
+
+// FromConfig builds the directories of the identities section of the configuration, one per
+// integration type:
+//
+//	identities:
+//	  slack:
+//	    users: {octocat: U012AB3CD}
+//	    teams: {backend: S0614TZR7}
+//	    file: slack.csv          # "login,chat ID" rows, team rows prefixed with @
+//	    emails: slack-users.json # Slack users export, or "email,chat ID" CSV
+//	  teams:
+//	    users: {octocat: octocat@example.com}
+func FromConfig() (Directories, error) {
+	directories := make(Directories)
+
+	for platform := range viper.GetStringMap("identities") {
+		switch platform {
+		case "users", "file", "emails":
+			return nil, fmt.Errorf("identities.%s: identities are set per integration type, e.g. identities.slack.%s", platform, platform)
+		}
+
+		d, err := directoryFromConfig("identities." + platform)
+		if err != nil {
+			return nil, err
+		}
+		directories[platform] = d
+	}
+
+	return directories, nil
+}
+
+// directoryFromConfig builds the directory of a platform from the configuration section key
+func directoryFromConfig(key string) (*Directory, error) {
+	d := New()
+
+	for login, id := range viper.GetStringMapString(key + ".users") {
+		d.Users[strings.ToLower(login)] = id
+	}
+	for team, handle := range viper.GetStringMapString(key + ".teams") {
+		d.Teams[strings.ToLower(team)] = handle
+	}
+
+	if path := viper.GetString(key + ".file"); path != "" {
+		if err := d.LoadCSV(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := viper.GetString(key + ".emails"); path != "" {
+		if err := d.LoadUserExport(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// Platform returns the directory of a platform, empty when the configuration has none
+func (ds Directories) Platform(platform string) *Directory {
+	if d, ok := ds[platform]; ok {
+		return d
+	}
+	return New()
+}
+
+// Lookup returns the chat IDs of the reviewer of a review request, keyed by the platforms that
+// know them
+func (ds Directories) Lookup(ctx context.Context, req githubclient.ReviewRequest) map[string]string {
+	chatIDs := make(map[string]string)
+	for platform, d := range ds {
+		if id := d.Lookup(ctx, req); id != "" {
+			chatIDs[platform] = id
+		}
+	}
+	return chatIDs
+}
+
+// LoadCSV reads "login,chat ID" rows. Team rows use the team name or slug prefixed with @,
+// optionally with the organization (@org/team). Lines starting with # are ignored.
+func (d *Directory) LoadCSV(path string) error {
+	rows, err := readCSV(path)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		name, id := strings.ToLower(row[0]), row[1]
+		if team, ok := strings.CutPrefix(name, "@"); ok {
+			if _, slug, hasOrg := strings.Cut(team, "/"); hasOrg {
+				team = slug
+			}
+			d.Teams[team] = id
+			continue
+		}
+		d.Users[name] = id
+	}
+
+	return nil
+}
+
+// LoadUserExport reads the chat users to match by email: either a Slack users export (a JSON
+// array of users with an id and a profile email) or "email,chat ID" CSV rows, as exported from
+// Microsoft Teams or any other directory.
+func (d *Directory) LoadUserExport(path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading user export: %w", err)
+		}
+
+		var users []struct {
+			ID      string `json:"id"`
+			Deleted bool   `json:"deleted"`
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		}
+		if err := json.Unmarshal(data, &users); err != nil {
+			return fmt.Errorf("error parsing user export %s: %w", path, err)
+		}
+
+		for _, user := range users {
+			if !user.Deleted && user.Profile.Email != "" {
+				d.Emails[strings.ToLower(user.Profile.Email)] = user.ID
+			}
+		}
+		return nil
+	}
+
+	rows, err := readCSV(path)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		// Skip headers and anything else that is not an email
+		if strings.Contains(row[0], "@") {
+			d.Emails[strings.ToLower(row[0])] = row[1]
+		}
+	}
+	return nil
+}
+
+// Lookup returns the chat ID of the reviewer of a review request, or an empty string if the
+// reviewer is unknown. Teams are looked up by name then slug; users by login, then by their
+// public email when an EmailLookup is configured.
+func (d *Directory) Lookup(ctx context.Context, req githubclient.ReviewRequest) string {
+	if req.IsTeam {
+		if handle, ok := d.Teams[strings.ToLower(req.From)]; ok {
+			return handle
+		}
+		return d.Teams[strings.ToLower(req.Slug)]
+	}
+
+	login := strings.ToLower(req.From)
+	if id, ok := d.Users[login]; ok {
+		return id
+	}
+
+	if d.EmailLookup == nil || len(d.Emails) == 0 {
+		return ""
+	}
+	if id, ok := d.emailCache[login]; ok {
+		return id
+	}
+
+	email, err := d.EmailLookup(ctx, req.From)
+	if err != nil {
+		log.Debug().Msgf("Could not look up the email of %s: %v", req.From, err)
+	}
+	id := ""
+	if email != "" {
+		id = d.Emails[strings.ToLower(email)]
+	}
+	d.emailCache[login] = id
+
+	return id
+}
+
+// Login returns the lowercase login mapped onto a chat user ID, used to find out which reviewer
+// acted on a chat message
+func (d *Directory) Login(chatID string) (string, bool) {
+	for login, id := range d.Users {
+		if id == chatID {
+			return login, true
+		}
+	}
+	return "", false
+}
+
+// readCSV returns the rows of a CSV file that have at least two columns, trimmed
+func readCSV(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading identities: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Debug().Msgf("Error closing %s: %v", path, err)
+		}
+	}()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	var rows [][]string
+	for _, record := range records {
+		if len(record) < 2 || strings.TrimSpace(record[0]) == "" || strings.TrimSpace(record[1]) == "" {
+			continue
+		}
+		rows = append(rows, []string{strings.TrimSpace(record[0]), strings.TrimSpace(record[1])})
+	}
+	return rows, nil
+}
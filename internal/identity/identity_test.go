@@ -0,0 +1,112 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestFromConfig(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	viper.Set("identities", map[string]interface{}{
+		"slack": map[string]interface{}{
+			"users": map[string]interface{}{"Octocat": "U012AB3CD"},
+			"teams": map[string]interface{}{"backend": "S0614TZR7"},
+			"file": writeFile(t, "identities.csv", `# login,chat ID
+hubot, W0123
+@myorg/frontend,S0999
+incomplete
+`),
+		},
+		"teams": map[string]interface{}{
+			"users": map[string]interface{}{"octocat": "octocat@example.com"},
+		},
+	})
+
+	directories, err := FromConfig()
+
+	assert.NoError(t, err)
+	d := directories.Platform("slack")
+	assert.Equal(t, "U012AB3CD", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "octocat"}))
+	assert.Equal(t, "W0123", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "Hubot"}))
+	assert.Equal(t, "S0614TZR7", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "Backend", IsTeam: true}))
+	assert.Equal(t, "S0999", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "Frontend Team", Slug: "frontend", IsTeam: true}))
+	assert.Equal(t, "", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "incomplete"}))
+
+	login, found := d.Login("W0123")
+	assert.True(t, found)
+	assert.Equal(t, "hubot", login)
+	_, found = d.Login("U0UNKNOWN")
+	assert.False(t, found)
+
+	// Each platform only gets its own IDs
+	assert.Equal(t, map[string]string{"slack": "U012AB3CD", "teams": "octocat@example.com"},
+		directories.Lookup(context.Background(), githubclient.ReviewRequest{From: "octocat"}))
+	assert.Equal(t, map[string]string{"slack": "W0123"},
+		directories.Lookup(context.Background(), githubclient.ReviewRequest{From: "hubot"}))
+	assert.Empty(t, directories.Platform("matrix").Users)
+}
+
+func TestFromConfigWithoutPlatform(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	viper.Set("identities", map[string]interface{}{
+		"users": map[string]interface{}{"octocat": "U012AB3CD"},
+	})
+
+	_, err := FromConfig()
+
+	assert.EqualError(t, err, "identities.users: identities are set per integration type, e.g. identities.slack.users")
+}
+
+func TestLookupByEmail(t *testing.T) {
+	d := New()
+	err := d.LoadUserExport(writeFile(t, "users.json", `[
+		{"id": "U1", "profile": {"email": "Alice@example.com"}},
+		{"id": "U2", "deleted": true, "profile": {"email": "bob@example.com"}}
+	]`))
+	assert.NoError(t, err)
+
+	lookups := 0
+	d.EmailLookup = func(ctx context.Context, login string) (string, error) {
+		lookups++
+		switch login {
+		case "alice":
+			return "alice@example.com", nil
+		case "bob":
+			return "bob@example.com", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	assert.Equal(t, "U1", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "alice"}))
+	assert.Equal(t, "U1", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "alice"}))
+	assert.Equal(t, "", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "bob"}))
+	assert.Equal(t, "", d.Lookup(context.Background(), githubclient.ReviewRequest{From: "carol"}))
+	assert.Equal(t, 3, lookups)
+}
+
+func TestLoadUserExportCSV(t *testing.T) {
+	d := New()
+	err := d.LoadUserExport(writeFile(t, "users.csv", "email,id\nalice@example.com,29:1abc\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"alice@example.com": "29:1abc"}, d.Emails)
+}
@@ -31,6 +31,13 @@ func Run(ctx context.Context) {
 		return
 	}
 
+	if ping.IsAuthorNudge(pingRequests) {
+		if err := writeAuthorNudge(outputFilePath, envFilePath, pingRequests[0]); err != nil {
+			log.Fatal().Msgf("Error writing author nudge: %v\n", err)
+		}
+		return
+	}
+
 	// Process ping requests
 	enabledReviewers, disabledReviewers := processRequests(pingRequests)
 
@@ -71,6 +78,16 @@ func processRequests(pingRequests []ping.PingRequest) ([]string, []string) {
 			status := "waiting"
 			if !req.Enabled {
 				status = "disabled"
+			} else if req.TeamMemberReviewed() {
+				status = "reviewed"
+			} else if blocking := req.ChecksBlocking(); blocking != "" {
+				status = blocking
+			} else if len(req.WaitingOnAuthor) > 0 {
+				status = "waiting on author"
+			} else if req.Snooze != nil && req.Snooze.Acknowledged {
+				status = "acknowledged"
+			} else if req.Snooze != nil {
+				status = "snoozed"
 			}
 			disabledReviewers = append(disabledReviewers, fmt.Sprintf("%s (%s ago, status: %s)",
 				reviewer, formattedDuration, status))
@@ -130,6 +147,40 @@ func writeToGitHubOutput(filePath string, enabledReviewers, disabledReviewers []
 	return nil
 }
 
+// writeAuthorNudge writes the PR author and why the PR is waiting on them to GITHUB_OUTPUT
+// (author, waitingOnAuthor) and GITHUB_ENV (GONG_AUTHOR, GONG_WAITING_ON_AUTHOR)
+func writeAuthorNudge(outputFilePath, envFilePath string, req ping.PingRequest) error {
+	reasons := strings.Join(req.WaitingOnAuthor, ",")
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{outputFilePath, fmt.Sprintf("author=%s\nwaitingOnAuthor=%s\n", req.Req.From, reasons)},
+		{envFilePath, fmt.Sprintf("GONG_AUTHOR=%s\nGONG_WAITING_ON_AUTHOR=%s\n", req.Req.From, reasons)},
+	}
+
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+
+		f, err := os.OpenFile(file.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file.path, err)
+		}
+		_, err = f.WriteString(file.content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write author nudge to %s: %w", file.path, err)
+		}
+	}
+
+	return nil
+}
+
 // writeToGitHubEnv writes reviewer information to GITHUB_ENV file
 func writeToGitHubEnv(filePath string, enabledReviewers, disabledReviewers []string) error {
 	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -4,21 +4,29 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/Djiit/gong/internal/format"
 	"github.com/Djiit/gong/internal/githubclient"
 	"github.com/Djiit/gong/internal/ping"
-	"github.com/google/go-github/v69/github"
-	"github.com/spf13/viper"
+	"github.com/Djiit/gong/internal/provider"
 )
 
 // DefaultTemplate is the default template used for comment output
 const DefaultTemplate = `Awaiting reviews from: {{ range $i, $r := .ActiveReviewers }}{{ if $i }}, {{ end }}@{{ $r }}{{ end }}
 <!-- gong -->`
 
+// DefaultAuthorTemplate is the default template used for comment output when nudging the PR author
+const DefaultAuthorTemplate = `@{{ .Author }}, this PR is waiting on you: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}
+<!-- gong author -->`
+
+// Markers identifying the comments gong already posted, for reviewers and for the author
+const (
+	reviewersMarker = "<!-- gong -->"
+	authorMarker    = "<!-- gong author -->"
+)
+
 func Run(ctx context.Context) {
 	pingRequests := ctx.Value("pingRequests").([]ping.PingRequest)
 	repoOwner := ctx.Value("repoOwner").(string)
@@ -29,12 +37,18 @@ func Run(ctx context.Context) {
 	// Get template parameter from integrations config
 	var templateStr string
 
+	// Author nudges have their own template and are tracked separately from reviewer pings
+	templateKey, defaultTemplate, marker := "template", DefaultTemplate, reviewersMarker
+	if ping.IsAuthorNudge(pingRequests) {
+		templateKey, defaultTemplate, marker = "authorTemplate", DefaultAuthorTemplate, authorMarker
+	}
+
 	// First check if there's a template in the integration parameters
 	if len(pingRequests) > 0 {
 		for _, intg := range pingRequests[0].Integrations {
 			if intg.Type == "comment" {
 				// Look for template parameter
-				if tmpl, ok := intg.Parameters["template"]; ok && tmpl != "" {
+				if tmpl, ok := intg.Parameters[templateKey]; ok && tmpl != "" {
 					templateStr = tmpl
 					break
 				}
@@ -44,10 +58,10 @@ func Run(ctx context.Context) {
 
 	// If no template found in integration parameters, try context
 	if templateStr == "" {
-		if val, ok := ctx.Value("template").(string); ok && val != "" {
+		if val, ok := ctx.Value(templateKey).(string); ok && val != "" {
 			templateStr = val
 		} else {
-			templateStr = DefaultTemplate
+			templateStr = defaultTemplate
 		}
 	}
 
@@ -57,19 +71,20 @@ func Run(ctx context.Context) {
 			fmt.Printf("Error formatting output with template: %v\n", err)
 			return
 		}
-		fmt.Printf("[DRY RUN] Would post GitHub comment:\n%s\n", output)
+		fmt.Printf("[DRY RUN] Would post comment:\n%s\n", output)
 		return
 	}
 
-	client := githubclient.NewClient(viper.GetString("github-token"))
-
-	prNum, err := strconv.Atoi(prNumber)
-	if err != nil {
-		fmt.Printf("Error converting PR number: %v\n", err)
-		return
+	prov, ok := ctx.Value("provider").(githubclient.Provider)
+	if !ok {
+		var err error
+		if prov, err = provider.NewFromConfig(); err != nil {
+			fmt.Printf("Error creating provider: %v\n", err)
+			return
+		}
 	}
 
-	if alreadyCommented(ctx, client, repoOwner, repoName, prNum) {
+	if alreadyCommented(ctx, prov, repoOwner, repoName, prNumber, marker) {
 		fmt.Println("Comment already exists for this PR.")
 		return
 	}
@@ -80,20 +95,20 @@ func Run(ctx context.Context) {
 		return
 	}
 
-	if err := postComment(ctx, client, repoOwner, repoName, prNum, output); err != nil {
+	if err := prov.PostComment(ctx, repoOwner, repoName, prNumber, output); err != nil {
 		fmt.Printf("Error posting comment: %v\n", err)
 	}
 }
 
-func alreadyCommented(ctx context.Context, client *github.Client, owner, repo string, prNumber int) bool {
-	comments, _, err := client.Issues.ListComments(ctx, owner, repo, prNumber, nil)
+func alreadyCommented(ctx context.Context, prov githubclient.Provider, owner, repo, prNumber, marker string) bool {
+	comments, err := prov.ListComments(ctx, owner, repo, prNumber)
 	if err != nil {
 		fmt.Printf("Error fetching comments: %v\n", err)
 		return false
 	}
 
 	for _, comment := range comments {
-		if strings.Contains(comment.GetBody(), "<!-- gong -->") {
+		if strings.Contains(comment, marker) {
 			return true
 		}
 	}
@@ -101,12 +116,6 @@ func alreadyCommented(ctx context.Context, client *github.Client, owner, repo st
 	return false
 }
 
-func postComment(ctx context.Context, client *github.Client, owner, repo string, prNumber int, body string) error {
-	comment := &github.IssueComment{Body: github.String(body)}
-	_, _, err := client.Issues.CreateComment(ctx, owner, repo, prNumber, comment)
-	return err
-}
-
 func formatWithTemplate(pingRequests []ping.PingRequest, templateStr string) (string, error) {
 	if len(pingRequests) == 0 {
 		return "No pending review requests.\n<!-- gong -->", nil
@@ -0,0 +1,304 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTemplate is the default template of the embed description
+const DefaultTemplate = `PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}} is waiting for review.`
+
+// DefaultAuthorTemplate is the default template of the embed description when nudging the PR author
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}} is waiting on its author: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}.`
+
+// Embed colors, from the least to the most urgent
+const (
+	ColorFresh   = 0x57F287 // Green, waiting for less than a day
+	ColorWaiting = 0xFEE75C // Yellow, waiting for less than UrgentAfter
+	ColorUrgent  = 0xED4245 // Red
+	ColorAuthor  = 0x5865F2 // Blurple, used for author nudges
+)
+
+// UrgentAfter is how long a review can wait before its embed turns red
+const UrgentAfter = 3 * 24 * time.Hour
+
+// maxAttempts bounds how many times a rate-limited message is sent
+const maxAttempts = 3
+
+// maxRetryAfter bounds how long gong waits for a rate limit to reset
+const maxRetryAfter = time.Minute
+
+// snowflake matches Discord user and role IDs
+var snowflake = regexp.MustCompile(`^[0-9]{15,21}$`)
+
+// wait pauses until d elapsed, replaced in tests
+var wait = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimits remembers, per webhook, when the current rate limit bucket resets once exhausted
+var rateLimits = struct {
+	sync.Mutex
+	resetAt map[string]time.Time
+}{resetAt: make(map[string]time.Time)}
+
+// message is a Discord webhook message
+type message struct {
+	Content         string          `json:"content,omitempty"`
+	Embeds          []embed         `json:"embeds"`
+	AllowedMentions allowedMentions `json:"allowed_mentions"`
+}
+
+type embed struct {
+	Title       string       `json:"title"`
+	URL         string       `json:"url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Color       int          `json:"color"`
+	Fields      []embedField `json:"fields,omitempty"`
+	Footer      *embedFooter `json:"footer,omitempty"`
+}
+
+type embedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type embedFooter struct {
+	Text string `json:"text"`
+}
+
+// allowedMentions restricts who a message notifies to the mentioned reviewers, so that a PR
+// title containing @everyone does not ping the whole server
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+	Users []string `json:"users,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "discord")
+	if !ok {
+		return
+	}
+
+	// Discord webhooks post to a single channel, so rules may use their own
+	webhookURL := n.Param("webhook", "discord-webhook")
+	if webhookURL == "" {
+		log.Error().Msg("No Discord webhook URL found in configuration. Skipping Discord notifications.")
+		return
+	}
+
+	data := n.TemplateData()
+	description, err := format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Discord message with template")
+		return
+	}
+
+	msg := newMessage(description, data, n.PingRequests)
+
+	if n.DryRun {
+		n.LogDryRun("send Discord notification", msg.Content+" "+description)
+		return
+	}
+
+	if err := sendMessage(ctx, webhookURL, msg); err != nil {
+		log.Error().Err(err).Msg("Error sending Discord notification")
+	}
+}
+
+// mention formats a reviewer for Discord: user IDs become user mentions and, for teams, role IDs
+// become role mentions. Reviewers missing from the identity directory are only named.
+func mention(m format.Mention) string {
+	switch {
+	case !snowflake.MatchString(m.ChatID):
+		return m.Name
+	case m.IsTeam:
+		return "<@&" + m.ChatID + ">"
+	}
+	return "<@" + m.ChatID + ">"
+}
+
+// newMessage builds the message of a notification. Discord does not notify mentions made in
+// embeds, so the reviewers are mentioned in the message content.
+func newMessage(description string, data format.TemplateData, pingRequests []ping.PingRequest) message {
+	title := fmt.Sprintf("Review requested on PR #%s", data.PRNumber)
+	if data.Author != "" {
+		title = fmt.Sprintf("PR #%s is waiting on its author", data.PRNumber)
+	}
+	if data.PRTitle != "" {
+		title += ": " + data.PRTitle
+	}
+
+	e := embed{
+		Title:       truncate(title, 256),
+		URL:         data.PRURL,
+		Description: truncate(description, 4096),
+		Color:       color(pingRequests),
+		Footer:      &embedFooter{Text: "Sent via gong"},
+	}
+
+	msg := message{AllowedMentions: allowedMentions{Parse: []string{}}}
+
+	var mentions []string
+	for _, m := range data.Mentions {
+		tag := mention(m)
+		mentions = append(mentions, tag)
+		switch {
+		case tag == m.Name:
+		case m.IsTeam:
+			msg.AllowedMentions.Roles = append(msg.AllowedMentions.Roles, m.ChatID)
+		default:
+			msg.AllowedMentions.Users = append(msg.AllowedMentions.Users, m.ChatID)
+		}
+	}
+
+	field := "Reviewers"
+	if data.Author != "" {
+		field = "Author"
+	}
+	if len(mentions) > 0 {
+		msg.Content = strings.Join(mentions, " ")
+		e.Fields = append(e.Fields, embedField{Name: field, Value: truncate(strings.Join(mentions, ", "), 1024)})
+	}
+	if data.PRAuthor != "" && data.Author == "" {
+		e.Fields = append(e.Fields, embedField{Name: "Author", Value: data.PRAuthor, Inline: true})
+	}
+	if data.Age != "" {
+		e.Fields = append(e.Fields, embedField{Name: "Waiting for", Value: data.Age, Inline: true})
+	}
+	if len(data.Labels) > 0 {
+		e.Fields = append(e.Fields, embedField{Name: "Labels", Value: truncate(strings.Join(data.Labels, ", "), 1024), Inline: true})
+	}
+
+	msg.Embeds = []embed{e}
+	return msg
+}
+
+// color returns the embed color matching how long the oldest review request has been waiting
+func color(pingRequests []ping.PingRequest) int {
+	if ping.IsAuthorNudge(pingRequests) {
+		return ColorAuthor
+	}
+
+	var oldest time.Time
+	for _, req := range pingRequests {
+		if oldest.IsZero() || req.Req.On.Before(oldest) {
+			oldest = req.Req.On
+		}
+	}
+
+	switch waiting := time.Since(oldest); {
+	case waiting >= UrgentAfter:
+		return ColorUrgent
+	case waiting >= 24*time.Hour:
+		return ColorWaiting
+	}
+	return ColorFresh
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// sendMessage posts a message to a webhook. It waits for the rate limit of the webhook to reset
+// when a previous message exhausted it, and retries messages Discord rejected with a 429.
+func sendMessage(ctx context.Context, webhookURL string, msg message) error {
+	log.Debug().Msg("Sending Discord notification via webhook")
+
+	for attempt := 1; ; attempt++ {
+		rateLimits.Lock()
+		resetAt := rateLimits.resetAt[webhookURL]
+		rateLimits.Unlock()
+		if d := time.Until(resetAt); d > 0 {
+			log.Debug().Msgf("Discord rate limit exhausted, waiting %s", d)
+			if err := wait(ctx, d); err != nil {
+				return err
+			}
+		}
+
+		retryAfter, err := post(ctx, webhookURL, msg)
+		if err == nil || retryAfter == 0 {
+			return err
+		}
+		if attempt == maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		log.Warn().Msgf("Discord rate limited the webhook, retrying in %s", retryAfter)
+		if err := wait(ctx, retryAfter); err != nil {
+			return err
+		}
+	}
+}
+
+// post sends a message once. It records the rate limit reported in the response headers and, when
+// the message was rate limited, returns how long to wait before retrying along with the error.
+func post(ctx context.Context, webhookURL string, msg message) (time.Duration, error) {
+	resp, err := notify.PostJSON(ctx, "discord webhook", webhookURL, msg, nil)
+	if resp == nil {
+		return 0, err
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if resetAfter := seconds(resp.Header.Get("X-RateLimit-Reset-After")); resetAfter > 0 {
+			rateLimits.Lock()
+			rateLimits.resetAt[webhookURL] = time.Now().Add(resetAfter)
+			rateLimits.Unlock()
+		}
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, err
+	}
+
+	// The JSON body holds the most precise delay, the Retry-After header a rounded one
+	var rateLimited struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	retryAfter := seconds(resp.Header.Get("Retry-After"))
+	if json.Unmarshal(resp.Body, &rateLimited) == nil && rateLimited.RetryAfter > 0 {
+		retryAfter = time.Duration(rateLimited.RetryAfter * float64(time.Second))
+	}
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	if retryAfter > maxRetryAfter {
+		retryAfter = maxRetryAfter
+	}
+	return retryAfter, err
+}
+
+// seconds parses a number of seconds from a rate limit header
+func seconds(header string) time.Duration {
+	s, err := strconv.ParseFloat(header, 64)
+	if err != nil || s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,259 @@
+package discord
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDiscordStandIn starts a stand-in of a Discord webhook. Each response is written by the next
+// function of respond, the last one answering all further requests.
+func newDiscordStandIn(t *testing.T, respond ...func(w http.ResponseWriter)) *notifytest.StandIn[message] {
+	var standIn *notifytest.StandIn[message]
+	standIn = notifytest.NewStandIn[message](t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if len(respond) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		respond[min(len(standIn.Requests()), len(respond))-1](w)
+	})
+	return standIn
+}
+
+// recordWaits replaces wait for the duration of a test and returns the waits it was asked for
+func recordWaits(t *testing.T) *[]time.Duration {
+	waits := &[]time.Duration{}
+	original := wait
+	wait = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	t.Cleanup(func() {
+		wait = original
+		rateLimits.resetAt = make(map[string]time.Time)
+	})
+	return waits
+}
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name              string
+		params            map[string]string
+		globalWebhook     bool
+		isDryRun          bool
+		expectPosted      bool
+		expectDescription string
+	}{
+		{
+			name:              "Default embed",
+			params:            map[string]string{},
+			globalWebhook:     true,
+			expectPosted:      true,
+			expectDescription: "PR #123 in owner/repo is waiting for review.",
+		},
+		{
+			name:              "Webhook from the parameters",
+			params:            map[string]string{"webhook": "stand-in", "template": "Please review {{ range .Mentions }}{{ mention . }} {{ end }}"},
+			expectPosted:      true,
+			expectDescription: "Please review <@123456789012345678> bob ",
+		},
+		{
+			name:          "Dry run",
+			params:        map[string]string{},
+			globalWebhook: true,
+			isDryRun:      true,
+		},
+		{
+			name:   "No webhook",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newDiscordStandIn(t)
+
+			viper.Reset()
+			defer viper.Reset()
+			if tc.globalWebhook {
+				viper.Set("discord-webhook", standIn.URL)
+			}
+			params := make(map[string]string)
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			if params["webhook"] == "stand-in" {
+				params["webhook"] = standIn.URL
+			}
+
+			integrations := []ping.Integration{{Type: "discord", Parameters: params}}
+			Run(notifytest.NewContext([]ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-30 * time.Hour), PRTitle: "Add Discord", PRAuthor: "dave"}, ShouldPing: true, ChatID: "123456789012345678", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+			}, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			if !assert.Len(t, standIn.Requests(), 1) {
+				return
+			}
+
+			msg := standIn.Bodies()[0]
+			assert.Equal(t, "<@123456789012345678> bob", msg.Content)
+			assert.Equal(t, allowedMentions{Parse: []string{}, Users: []string{"123456789012345678"}}, msg.AllowedMentions)
+			assert.Equal(t, []embed{{
+				Title:       "Review requested on PR #123: Add Discord",
+				URL:         "https://github.com/owner/repo/pull/123",
+				Description: tc.expectDescription,
+				Color:       ColorWaiting,
+				Fields: []embedField{
+					{Name: "Reviewers", Value: "<@123456789012345678>, bob"},
+					{Name: "Author", Value: "dave", Inline: true},
+					{Name: "Waiting for", Value: "1d 6h", Inline: true},
+					{Name: "Labels", Value: "bug", Inline: true},
+				},
+				Footer: &embedFooter{Text: "Sent via gong"},
+			}}, msg.Embeds)
+		})
+	}
+}
+
+func TestNewMessageMentions(t *testing.T) {
+	standIn := newDiscordStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("discord-webhook", standIn.URL)
+
+	integrations := []ping.Integration{{Type: "discord"}}
+	Run(notifytest.NewContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", On: time.Now()}, ShouldPing: true, ChatID: "123456789012345678", Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "backend", On: time.Now(), IsTeam: true}, ShouldPing: true, ChatID: "876543210987654321", Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "carol", On: time.Now()}, ShouldPing: true, ChatID: "carol@example.com", Integrations: integrations},
+	}, false))
+
+	if assert.Len(t, standIn.Requests(), 1) {
+		msg := standIn.Bodies()[0]
+		// Chat IDs that are not Discord IDs are only named, and never allowed to notify
+		assert.Equal(t, "<@123456789012345678> <@&876543210987654321> carol", msg.Content)
+		assert.Equal(t, allowedMentions{
+			Parse: []string{},
+			Users: []string{"123456789012345678"},
+			Roles: []string{"876543210987654321"},
+		}, msg.AllowedMentions)
+	}
+}
+
+func TestColor(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name         string
+		pingRequests []ping.PingRequest
+		expected     int
+	}{
+		{
+			name:         "Fresh",
+			pingRequests: []ping.PingRequest{{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-time.Hour)}}},
+			expected:     ColorFresh,
+		},
+		{
+			name: "Oldest request decides",
+			pingRequests: []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-time.Hour)}},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * 24 * time.Hour)}},
+			},
+			expected: ColorWaiting,
+		},
+		{
+			name:         "Urgent",
+			pingRequests: []ping.PingRequest{{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-UrgentAfter)}}},
+			expected:     ColorUrgent,
+		},
+		{
+			name:         "Author nudge",
+			pingRequests: []ping.PingRequest{{Req: githubclient.ReviewRequest{From: "dave", On: now.Add(-UrgentAfter)}, AuthorNudge: true}},
+			expected:     ColorAuthor,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, color(tc.pingRequests))
+		})
+	}
+}
+
+func TestSendMessageRateLimited(t *testing.T) {
+	waits := recordWaits(t)
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "2")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 1.5, "global": false}`))
+	}
+	standIn := newDiscordStandIn(t, rateLimited, func(w http.ResponseWriter) { w.WriteHeader(http.StatusNoContent) })
+
+	err := sendMessage(context.Background(), standIn.URL, message{Content: "hello"})
+
+	assert.NoError(t, err)
+	assert.Len(t, standIn.Requests(), 2)
+	assert.Equal(t, []time.Duration{1500 * time.Millisecond}, *waits)
+}
+
+func TestSendMessageGivesUp(t *testing.T) {
+	waits := recordWaits(t)
+	standIn := newDiscordStandIn(t, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	err := sendMessage(context.Background(), standIn.URL, message{Content: "hello"})
+
+	assert.Error(t, err)
+	assert.Len(t, standIn.Requests(), maxAttempts)
+	// Long rate limits are capped
+	assert.Equal(t, []time.Duration{maxRetryAfter, maxRetryAfter}, *waits)
+}
+
+func TestSendMessageWaitsForReset(t *testing.T) {
+	waits := recordWaits(t)
+	standIn := newDiscordStandIn(t, func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "30")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.NoError(t, sendMessage(context.Background(), standIn.URL, message{Content: "first"}))
+	assert.Empty(t, *waits)
+
+	// The bucket is exhausted, so the next message waits for it to reset
+	assert.NoError(t, sendMessage(context.Background(), standIn.URL, message{Content: "second"}))
+	if assert.Len(t, *waits, 1) {
+		assert.InDelta(t, 30*time.Second, (*waits)[0], float64(time.Second))
+	}
+	assert.Len(t, standIn.Requests(), 2)
+}
+
+func TestSendMessageError(t *testing.T) {
+	recordWaits(t)
+	standIn := newDiscordStandIn(t, func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadRequest) })
+
+	err := sendMessage(context.Background(), standIn.URL, message{Content: "hello"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Len(t, standIn.Requests(), 1)
+}
@@ -0,0 +1,234 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"net/mail"
+	"strings"
+	"text/template"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultSubject is the default template of the subject of review reminders
+const DefaultSubject = `Review requested on {{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}{{ if .PRTitle }}: {{.PRTitle}}{{ end }}`
+
+// DefaultAuthorSubject is the default template of the subject of author nudges
+const DefaultAuthorSubject = `{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}} is waiting on you{{ if .PRTitle }}: {{.PRTitle}}{{ end }}`
+
+// DefaultTemplate is the default template of the plain-text body of review reminders
+const DefaultTemplate = `Hi {{ range $i, $r := .ActiveReviewers }}{{ if $i }}, {{ end }}{{ $r }}{{ end }},
+
+PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}}{{ if .PRTitle }} ("{{.PRTitle}}"){{ end }} is waiting for review{{ if .Age }} since {{.Age}}{{ end }}.
+
+{{.PRURL}}
+
+--
+Sent by gong`
+
+// DefaultHTMLTemplate is the default template of the HTML body of review reminders
+const DefaultHTMLTemplate = `<p>Hi {{ range $i, $r := .ActiveReviewers }}{{ if $i }}, {{ end }}{{ $r }}{{ end }},</p>
+<p><a href="{{.PRURL}}">PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}}</a>{{ if .PRTitle }} (<strong>{{.PRTitle}}</strong>){{ end }} is waiting for review{{ if .Age }} since {{.Age}}{{ end }}.</p>
+{{ if .Labels }}<p>Labels: {{ range $i, $l := .Labels }}{{ if $i }}, {{ end }}<code>{{ $l }}</code>{{ end }}</p>
+{{ end }}<p style="color:#888">Sent by gong</p>`
+
+// DefaultAuthorTemplate is the default template of the plain-text body of author nudges
+const DefaultAuthorTemplate = `Hi {{.Author}},
+
+PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}}{{ if .PRTitle }} ("{{.PRTitle}}"){{ end }} is waiting on you: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}.
+
+{{.PRURL}}
+
+--
+Sent by gong`
+
+// DefaultAuthorHTMLTemplate is the default template of the HTML body of author nudges
+const DefaultAuthorHTMLTemplate = `<p>Hi {{.Author}},</p>
+<p><a href="{{.PRURL}}">PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}}</a>{{ if .PRTitle }} (<strong>{{.PRTitle}}</strong>){{ end }} is waiting on you: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}.</p>
+<p style="color:#888">Sent by gong</p>`
+
+// emailLookup is implemented by providers that can return the public email of a user
+type emailLookup interface {
+	UserEmail(ctx context.Context, login string) (string, error)
+}
+
+// templates holds the templates of the subject and the bodies of an email
+type templates struct {
+	subject string
+	text    string
+	html    string
+}
+
+// email is a rendered email, ready to be sent
+type email struct {
+	From    string
+	To      []string
+	Cc      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "email")
+	if !ok {
+		return
+	}
+
+	// Author nudges have their own templates
+	tmpls := templates{
+		subject: n.Template(ctx, "subject", DefaultSubject),
+		text:    n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate),
+		html:    n.Template(ctx, "htmlTemplate", DefaultHTMLTemplate),
+	}
+	if n.AuthorNudge {
+		tmpls.subject = n.Template(ctx, "authorSubject", DefaultAuthorSubject)
+		tmpls.html = n.Template(ctx, "authorHtmlTemplate", DefaultAuthorHTMLTemplate)
+	}
+
+	server := serverFromConfig(n.Params)
+	if server.Host == "" {
+		log.Error().Msg("No SMTP host found in configuration. Skipping email notifications.")
+		return
+	}
+
+	from := n.Param("from", "smtp-from")
+	if _, err := mail.ParseAddress(from); err != nil {
+		log.Error().Msgf("Invalid or missing email sender %q. Skipping email notifications.", from)
+		return
+	}
+	to := splitAddresses(n.Params["to"])
+	cc := splitAddresses(n.Params["cc"])
+
+	render := func(requests []ping.PingRequest, recipients, copies []string) (email, error) {
+		about := *n
+		about.PingRequests = requests
+		return renderEmail(tmpls, about.TemplateData(), email{From: from, To: recipients, Cc: copies})
+	}
+
+	var messages []email
+
+	// Every reviewer gets their own email, only about them
+	for _, req := range n.PingRequests {
+		address, err := recipient(ctx, req)
+		if err != nil {
+			log.Warn().Msgf("Cannot email %s: %v", req.Req.From, err)
+			continue
+		}
+
+		msg, err := render([]ping.PingRequest{req}, []string{address}, nil)
+		if err != nil {
+			log.Error().Err(err).Msg("Error formatting email with template")
+			return
+		}
+		messages = append(messages, msg)
+	}
+
+	// Fixed recipients, such as a team mailing list, get one email about everybody, which is the
+	// only one copied so that reviewers' addresses are not disclosed
+	if len(to) > 0 {
+		msg, err := render(n.PingRequests, to, cc)
+		if err != nil {
+			log.Error().Err(err).Msg("Error formatting email with template")
+			return
+		}
+		messages = append(messages, msg)
+	}
+
+	for _, msg := range messages {
+		if n.DryRun {
+			log.Info().Msgf("[DRY RUN] Subject: %s", msg.Subject)
+			n.LogDryRun("email "+strings.Join(msg.To, ", "), msg.Text)
+			continue
+		}
+
+		if err := server.send(ctx, msg); err != nil {
+			log.Error().Err(err).Msgf("Error sending email to %s", strings.Join(msg.To, ", "))
+		}
+	}
+}
+
+// recipient returns the email address of a reviewer: their chat ID in the identity directory when
+// it is an email address, which is also how teams are mapped to mailing lists, or else their
+// public email on the code host
+func recipient(ctx context.Context, req ping.PingRequest) (string, error) {
+	if !strings.HasPrefix(req.ChatID, "@") {
+		if address, err := mail.ParseAddress(req.ChatID); err == nil {
+			return address.Address, nil
+		}
+	}
+	if req.Req.IsTeam {
+		return "", fmt.Errorf("no email address known for the team")
+	}
+
+	lookup, ok := ctx.Value("provider").(emailLookup)
+	if !ok {
+		return "", fmt.Errorf("no email address known")
+	}
+
+	address, err := lookup.UserEmail(ctx, req.Req.From)
+	if err != nil {
+		return "", err
+	}
+	if address == "" {
+		return "", fmt.Errorf("no email address known and no public email")
+	}
+	return address, nil
+}
+
+// renderEmail renders the subject, plain-text body and HTML body of an email
+func renderEmail(tmpls templates, data format.TemplateData, msg email) (email, error) {
+	var err error
+	if msg.Subject, err = renderText("subject", tmpls.subject, data); err != nil {
+		return email{}, err
+	}
+	// Headers cannot span lines
+	msg.Subject = strings.Join(strings.Fields(msg.Subject), " ")
+
+	if msg.Text, err = renderText("text", tmpls.text, data); err != nil {
+		return email{}, err
+	}
+
+	// html/template escapes the data, such as PR titles, to keep the markup intact
+	tmpl, err := htmltemplate.New("html").Parse(tmpls.html)
+	if err != nil {
+		return email{}, fmt.Errorf("html template parsing error: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return email{}, fmt.Errorf("html template execution error: %w", err)
+	}
+	msg.HTML = buf.String()
+
+	return msg, nil
+}
+
+func renderText(name, templateStr string, data format.TemplateData) (string, error) {
+	tmpl, err := template.New(name).Parse(templateStr)
+	if err != nil {
+		return "", fmt.Errorf("%s template parsing error: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s template execution error: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// splitAddresses splits a comma-separated list of email addresses
+func splitAddresses(list string) []string {
+	var addresses []string
+	for _, address := range strings.Split(list, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
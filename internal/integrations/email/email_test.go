@@ -0,0 +1,332 @@
+package email
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// received is an email delivered to the SMTP stand-in
+type received struct {
+	From    string
+	To      []string
+	Auth    string // Decoded AUTH PLAIN credentials, empty without authentication
+	Subject string
+	Header  mail.Header
+	Text    string
+	HTML    string
+}
+
+// smtpStandIn is a local SMTP server recording the emails it receives. It never offers STARTTLS,
+// and offers AUTH PLAIN when auth is set.
+type smtpStandIn struct {
+	Host string
+	Port int
+
+	auth bool
+	mu   sync.Mutex
+	mail []received
+}
+
+func newSMTPStandIn(t *testing.T, auth bool) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	standIn := &smtpStandIn{Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port, auth: auth}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go standIn.serve(t, conn)
+		}
+	}()
+
+	return standIn
+}
+
+func (s *smtpStandIn) serve(t *testing.T, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	c := textproto.NewConn(conn)
+
+	var msg received
+	_ = c.PrintfLine("220 stand-in ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if s.auth {
+				_ = c.PrintfLine("250-stand-in")
+				_ = c.PrintfLine("250 AUTH PLAIN")
+			} else {
+				_ = c.PrintfLine("250 stand-in")
+			}
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			msg.Auth = strings.ReplaceAll(string(credentials), "\x00", ":")
+			_ = c.PrintfLine("235 Authenticated")
+		case "MAIL":
+			msg.From = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			_ = c.PrintfLine("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = c.PrintfLine("250 OK")
+		case "DATA":
+			_ = c.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			s.record(t, msg, string(data))
+			msg = received{}
+			_ = c.PrintfLine("250 Queued")
+		case "QUIT":
+			_ = c.PrintfLine("221 Bye")
+			return
+		default:
+			_ = c.PrintfLine("250 OK")
+		}
+	}
+}
+
+// record parses a received email into its subject and plain-text and HTML bodies
+func (s *smtpStandIn) record(t *testing.T, msg received, data string) {
+	parsed, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Errorf("Invalid email: %v", err)
+		return
+	}
+	msg.Header = parsed.Header
+	msg.Subject, _ = new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Errorf("Invalid content type: %v", err)
+		return
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			break
+		}
+		// Quoted-printable parts are decoded by the reader
+		content, _ := io.ReadAll(part)
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/html") {
+			msg.HTML = string(content)
+		} else {
+			msg.Text = string(content)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mail = append(s.mail, msg)
+}
+
+func (s *smtpStandIn) received() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]received{}, s.mail...)
+}
+
+type fakeEmailLookup map[string]string
+
+func (f fakeEmailLookup) UserEmail(_ context.Context, login string) (string, error) {
+	return f[login], nil
+}
+
+// newTestContext returns the context of a run whose provider knows the public email of bob
+func newTestContext(pingRequests []ping.PingRequest, isDryRun bool) context.Context {
+	return context.WithValue(notifytest.NewContext(pingRequests, isDryRun), "provider", fakeEmailLookup{"bob": "bob@example.com"})
+}
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name         string
+		params       map[string]string
+		noHost       bool
+		isDryRun     bool
+		expectedTo   [][]string
+		expectedText string
+	}{
+		{
+			name:       "One email per reviewer",
+			params:     map[string]string{},
+			expectedTo: [][]string{{"alice@example.com"}, {"bob@example.com"}},
+			expectedText: "Hi alice,\n\nPR #123 in owner/repo (\"Fix <script> handling\") is waiting for review since 3h.\n\n" +
+				"https://github.com/owner/repo/pull/123\n\n--\nSent by gong",
+		},
+		{
+			name:         "Fixed recipients and copies",
+			params:       map[string]string{"to": "reviews@example.com", "cc": "lead@example.com", "template": "Please review PR #{{ .PRNumber }}"},
+			expectedTo:   [][]string{{"alice@example.com"}, {"bob@example.com"}, {"reviews@example.com", "lead@example.com"}},
+			expectedText: "Please review PR #123",
+		},
+		{
+			name:     "Dry run",
+			params:   map[string]string{},
+			isDryRun: true,
+		},
+		{
+			name:   "No SMTP host",
+			params: map[string]string{},
+			noHost: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newSMTPStandIn(t, false)
+
+			viper.Reset()
+			defer viper.Reset()
+			if !tc.noHost {
+				viper.Set("smtp-host", standIn.Host)
+				viper.Set("smtp-port", strconv.Itoa(standIn.Port))
+			}
+			viper.Set("smtp-from", "gong <gong@example.com>")
+			params := map[string]string{"tls": TLSNone}
+			for k, v := range tc.params {
+				params[k] = v
+			}
+
+			integrations := []ping.Integration{{Type: "email", Parameters: params}}
+			Run(newTestContext([]ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-3 * time.Hour), PRTitle: "Fix <script> handling"}, ShouldPing: true, ChatID: "alice@example.com", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, ChatID: "U0BOB", Integrations: integrations},
+				// Neither in the identity directory nor showing a public email
+				{Req: githubclient.ReviewRequest{From: "carol", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+			}, tc.isDryRun))
+
+			mail := standIn.received()
+			if !assert.Len(t, mail, len(tc.expectedTo)) || len(mail) == 0 {
+				return
+			}
+			for i, m := range mail {
+				assert.Equal(t, "gong@example.com", m.From)
+				assert.Equal(t, tc.expectedTo[i], m.To)
+				assert.Equal(t, `"gong" <gong@example.com>`, m.Header.Get("From"))
+
+				// Only the email to the fixed recipients is copied
+				if tc.params["to"] != "" && i == len(mail)-1 {
+					assert.Contains(t, m.Header.Get("Cc"), tc.params["cc"])
+				} else {
+					assert.Empty(t, m.Header.Get("Cc"))
+				}
+			}
+
+			first := mail[0]
+			assert.Equal(t, "Review requested on owner/repo#123: Fix <script> handling", first.Subject)
+			assert.Equal(t, tc.expectedText, first.Text)
+			if tc.params["template"] == "" {
+				// The PR title is escaped in the HTML body
+				assert.Contains(t, first.HTML, `<a href="https://github.com/owner/repo/pull/123">PR #123 in owner/repo</a> (<strong>Fix &lt;script&gt; handling</strong>)`)
+				assert.Contains(t, first.HTML, "<code>bug</code>")
+			}
+		})
+	}
+}
+
+func TestRunAuthorNudge(t *testing.T) {
+	standIn := newSMTPStandIn(t, false)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("smtp-host", standIn.Host)
+	viper.Set("smtp-port", strconv.Itoa(standIn.Port))
+	viper.Set("smtp-from", "gong@example.com")
+	viper.Set("smtp-tls", TLSNone)
+
+	integrations := []ping.Integration{{Type: "email"}}
+	Run(newTestContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "dave", On: time.Now()}, ShouldPing: true, AuthorNudge: true, ChatID: "dave@example.com", WaitingOnAuthor: []string{"changes requested by alice"}, Integrations: integrations},
+	}, false))
+
+	mail := standIn.received()
+	if assert.Len(t, mail, 1) {
+		assert.Equal(t, []string{"dave@example.com"}, mail[0].To)
+		assert.Equal(t, "owner/repo#123 is waiting on you", mail[0].Subject)
+		assert.Contains(t, mail[0].Text, "is waiting on you: changes requested by alice.")
+	}
+}
+
+func TestSend(t *testing.T) {
+	msg := email{From: "gong@example.com", To: []string{"alice@example.com"}, Subject: "Réexamen", Text: "Hello", HTML: "<p>Hello</p>"}
+
+	t.Run("Authentication", func(t *testing.T) {
+		standIn := newSMTPStandIn(t, true)
+		s := server{Host: standIn.Host, Port: standIn.Port, Username: "gong", Password: "secret", TLS: TLSNone}
+
+		assert.NoError(t, s.send(context.Background(), msg))
+
+		mail := standIn.received()
+		if assert.Len(t, mail, 1) {
+			assert.Equal(t, ":gong:secret", mail[0].Auth)
+			assert.Equal(t, "Réexamen", mail[0].Subject)
+		}
+	})
+
+	t.Run("STARTTLS not supported", func(t *testing.T) {
+		standIn := newSMTPStandIn(t, false)
+		s := server{Host: standIn.Host, Port: standIn.Port, TLS: TLSStartTLS}
+
+		err := s.send(context.Background(), msg)
+
+		assert.ErrorContains(t, err, "STARTTLS")
+		assert.Empty(t, standIn.received())
+	})
+
+	t.Run("Authentication not supported", func(t *testing.T) {
+		standIn := newSMTPStandIn(t, false)
+		s := server{Host: standIn.Host, Port: standIn.Port, Username: "gong", TLS: TLSNone}
+
+		assert.ErrorContains(t, s.send(context.Background(), msg), "authentication")
+	})
+}
+
+func TestServerFromConfig(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("smtp-host", "smtp.example.com")
+	viper.Set("smtp-password", "secret")
+
+	assert.Equal(t, server{Host: "smtp.example.com", Port: 587, Password: "secret", TLS: TLSStartTLS}, serverFromConfig(nil))
+	assert.Equal(t, server{Host: "mail.example.com", Port: 465, Username: "gong", Password: "secret", TLS: TLSImplicit},
+		serverFromConfig(map[string]string{"host": "mail.example.com", "username": "gong", "tls": "implicit"}))
+	assert.Equal(t, 2525, serverFromConfig(map[string]string{"port": "2525"}).Port)
+}
+
+func TestRenderEmailTemplateError(t *testing.T) {
+	_, err := renderEmail(templates{subject: "{{ .Missing", text: "", html: ""}, format.TemplateData{}, email{})
+
+	assert.ErrorContains(t, err, fmt.Sprintf("%s template parsing error", "subject"))
+}
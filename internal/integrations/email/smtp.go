@@ -0,0 +1,257 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// TLS modes of the SMTP connection
+const (
+	TLSStartTLS = "starttls" // Upgrade a plain connection, usually on port 587
+	TLSImplicit = "implicit" // Connect with TLS, usually on port 465
+	TLSNone     = "none"     // Never encrypt, only meant for local relays
+)
+
+// dialTimeout bounds the time spent talking to the SMTP server
+const dialTimeout = 30 * time.Second
+
+// server is the SMTP server emails are sent through
+type server struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+}
+
+// serverFromConfig reads the SMTP server from the integration parameters, falling back to the
+// smtp-* configuration keys. The password is only read from the configuration, so that it can be
+// kept in the environment.
+func serverFromConfig(params map[string]string) server {
+	param := func(key, configKey string) string {
+		if val := params[key]; val != "" {
+			return val
+		}
+		return viper.GetString(configKey)
+	}
+
+	s := server{
+		Host:     param("host", "smtp-host"),
+		Username: param("username", "smtp-username"),
+		Password: viper.GetString("smtp-password"),
+		TLS:      strings.ToLower(param("tls", "smtp-tls")),
+	}
+	if s.TLS == "" {
+		s.TLS = TLSStartTLS
+	}
+
+	if port := param("port", "smtp-port"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			log.Warn().Msgf("Invalid SMTP port %q, using the default port", port)
+		}
+		s.Port = p
+	}
+	if s.Port <= 0 {
+		s.Port = 587
+		if s.TLS == TLSImplicit {
+			s.Port = 465
+		}
+	}
+
+	return s
+}
+
+// send delivers an email to its recipients and copies
+func (s server) send(ctx context.Context, msg email) error {
+	log.Debug().Msgf("Sending email to %s via %s:%d", strings.Join(msg.To, ", "), s.Host, s.Port)
+
+	body, err := msg.bytes()
+	if err != nil {
+		return err
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			log.Debug().Msgf("Error closing SMTP connection: %v", err)
+		}
+	}()
+
+	if s.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server does not support authentication")
+		}
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range append(append([]string{}, msg.To...), msg.Cc...) {
+		address, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return fmt.Errorf("invalid recipient: %w", err)
+		}
+		if err := client.Rcpt(address.Address); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", address.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the server and secures the connection as configured
+func (s server) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConfig := &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}
+
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if s.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	switch s.TLS {
+	case TLSImplicit, TLSNone:
+	case TLSStartTLS:
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return nil, fmt.Errorf("smtp server does not support STARTTLS, set tls to implicit or none")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	default:
+		_ = client.Close()
+		return nil, fmt.Errorf("unknown SMTP TLS mode %q", s.TLS)
+	}
+
+	return client, nil
+}
+
+// bytes formats the email as a multipart/alternative MIME message with a plain-text and an HTML
+// part, so that every mail client shows one of them
+func (msg email) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", formatAddresses([]string{msg.From}))
+	header("To", formatAddresses(msg.To))
+	if len(msg.Cc) > 0 {
+		header("Cc", formatAddresses(msg.Cc))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(msg.From))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatAddresses formats addresses for a header, encoding the names that are not ASCII
+func formatAddresses(addresses []string) string {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		formatted[i] = address
+		if parsed, err := mail.ParseAddress(address); err == nil {
+			formatted[i] = parsed.String()
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// messageID returns a unique Message-ID in the domain of the sender
+func messageID(from string) string {
+	domain := "gong.local"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(address.Address, "@"); ok {
+			domain = d
+		}
+	}
+
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%s.%s@%s>", strconv.FormatInt(time.Now().UnixNano(), 36), hex.EncodeToString(random), domain)
+}
@@ -0,0 +1,193 @@
+package googlechat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTemplate is the default template of the text of Google Chat messages
+const DefaultTemplate = `PR #{{.PRNumber}} is waiting for review: <{{.PRURL}}|{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}>
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template of the text of Google Chat messages nudging the PR author
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} is waiting on its author: <{{.PRURL}}|{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}>
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
+// replyOption makes Google Chat post in the thread of the thread key, or start it
+const replyOption = "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+
+// message is the message accepted by Google Chat space webhooks
+type message struct {
+	Text    string   `json:"text"`
+	CardsV2 []cardV2 `json:"cardsV2,omitempty"`
+	Thread  *thread  `json:"thread,omitempty"`
+}
+
+type thread struct {
+	ThreadKey string `json:"threadKey"`
+}
+
+type cardV2 struct {
+	CardID string `json:"cardId"`
+	Card   card   `json:"card"`
+}
+
+type card struct {
+	Header   header    `json:"header"`
+	Sections []section `json:"sections"`
+}
+
+type header struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type section struct {
+	Widgets []widget `json:"widgets"`
+}
+
+// widget holds one of the card widgets gong uses
+type widget struct {
+	DecoratedText *decoratedText `json:"decoratedText,omitempty"`
+	ButtonList    *buttonList    `json:"buttonList,omitempty"`
+}
+
+type decoratedText struct {
+	TopLabel string `json:"topLabel"`
+	Text     string `json:"text"`
+	WrapText bool   `json:"wrapText,omitempty"`
+}
+
+type buttonList struct {
+	Buttons []button `json:"buttons"`
+}
+
+type button struct {
+	Text    string  `json:"text"`
+	OnClick onClick `json:"onClick"`
+}
+
+type onClick struct {
+	OpenLink openLink `json:"openLink"`
+}
+
+type openLink struct {
+	URL string `json:"url"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "googlechat")
+	if !ok {
+		return
+	}
+
+	// Space webhooks post to a single space, so rules may use their own
+	webhookURL := n.Param("webhook", "googlechat-webhook")
+	if webhookURL == "" {
+		log.Error().Msg("No Google Chat webhook URL found in configuration. Skipping Google Chat notifications.")
+		return
+	}
+
+	title := fmt.Sprintf("Review requested on PR #%s", n.PRNumber)
+	if n.AuthorNudge {
+		title = fmt.Sprintf("PR #%s is waiting on its author", n.PRNumber)
+	}
+
+	data := n.TemplateData()
+	text, err := format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Google Chat message with template")
+		return
+	}
+
+	msg := newMessage(title, text, data)
+
+	if n.DryRun {
+		n.LogDryRun("send Google Chat notification in thread "+msg.Thread.ThreadKey, text)
+		return
+	}
+
+	if err := sendMessage(ctx, webhookURL, msg); err != nil {
+		log.Error().Err(err).Msg("Error sending Google Chat notification")
+	}
+}
+
+// mention formats a reviewer for Google Chat, whose chat ID is their user ID ("users/123" or
+// "123") or email address. Google Chat has no group mentions, so teams are only named.
+func mention(m format.Mention) string {
+	if m.ChatID == "" || m.IsTeam {
+		return m.Name
+	}
+	return "<users/" + strings.TrimPrefix(m.ChatID, "users/") + ">"
+}
+
+// threadKey returns the thread of the reminders of a pull request, so that they stay in one thread
+func threadKey(repoOwner, repoName, prNumber string) string {
+	return fmt.Sprintf("gong-%s-%s-%s", repoOwner, repoName, prNumber)
+}
+
+// newMessage builds the message of a notification. Mentions only notify in the message text, so
+// the card only holds the details of the pull request and a button to open it.
+func newMessage(title, text string, data format.TemplateData) message {
+	var widgets []widget
+	if data.PRTitle != "" {
+		widgets = append(widgets, textWidget("Pull request", data.PRTitle))
+	}
+	if data.PRAuthor != "" {
+		widgets = append(widgets, textWidget("Author", data.PRAuthor))
+	}
+	if data.Age != "" {
+		widgets = append(widgets, textWidget("Waiting for", data.Age))
+	}
+	if len(data.ActiveReviewers) > 0 {
+		widgets = append(widgets, textWidget("Reviewers", strings.Join(data.ActiveReviewers, ", ")))
+	}
+	if len(data.Labels) > 0 {
+		widgets = append(widgets, textWidget("Labels", strings.Join(data.Labels, ", ")))
+	}
+	widgets = append(widgets, widget{ButtonList: &buttonList{Buttons: []button{
+		{Text: "View pull request", OnClick: onClick{OpenLink: openLink{URL: data.PRURL}}},
+	}}})
+
+	return message{
+		Text: text,
+		CardsV2: []cardV2{{
+			CardID: "gong",
+			Card: card{
+				Header:   header{Title: title, Subtitle: fmt.Sprintf("%s/%s", data.RepoOwner, data.RepoName)},
+				Sections: []section{{Widgets: widgets}},
+			},
+		}},
+		Thread: &thread{ThreadKey: threadKey(data.RepoOwner, data.RepoName, data.PRNumber)},
+	}
+}
+
+func textWidget(label, text string) widget {
+	return widget{DecoratedText: &decoratedText{TopLabel: label, Text: text, WrapText: true}}
+}
+
+func sendMessage(ctx context.Context, webhookURL string, msg message) error {
+	log.Debug().Msg("Sending Google Chat notification via webhook")
+
+	// Threading needs the reply option on top of the key and token of the webhook URL
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid Google Chat webhook URL: %w", err)
+	}
+	if msg.Thread != nil {
+		query := u.Query()
+		query.Set("messageReplyOption", replyOption)
+		u.RawQuery = query.Encode()
+	}
+
+	header := http.Header{"Content-Type": {"application/json; charset=UTF-8"}}
+	_, err = notify.PostJSON(ctx, "google chat webhook", u.String(), msg, header)
+	return err
+}
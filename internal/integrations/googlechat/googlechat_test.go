@@ -0,0 +1,121 @@
+package googlechat
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookPath is the path and query of the webhook of the Google Chat stand-ins
+const webhookPath = "/v1/spaces/AAAA/messages?key=k&token=t"
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		params        map[string]string
+		globalWebhook bool
+		isDryRun      bool
+		expectPosted  bool
+		expectText    string
+	}{
+		{
+			name:          "Default message",
+			params:        map[string]string{},
+			globalWebhook: true,
+			expectPosted:  true,
+			expectText:    "PR #123 is waiting for review: <https://github.com/owner/repo/pull/123|owner/repo#123>\nReviewers: <users/112233>, backend (team), bob",
+		},
+		{
+			name:         "Webhook from the parameters",
+			params:       map[string]string{"webhook": "stand-in", "template": "Please review PR #{{ .PRNumber }}"},
+			expectPosted: true,
+			expectText:   "Please review PR #123",
+		},
+		{
+			name:          "Dry run",
+			params:        map[string]string{},
+			globalWebhook: true,
+			isDryRun:      true,
+		},
+		{
+			name:   "No webhook",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := notifytest.NewStandIn[message](t, nil)
+
+			viper.Reset()
+			defer viper.Reset()
+			if tc.globalWebhook {
+				viper.Set("googlechat-webhook", standIn.URL+webhookPath)
+			}
+			params := make(map[string]string)
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			if params["webhook"] == "stand-in" {
+				params["webhook"] = standIn.URL + webhookPath
+			}
+
+			integrations := []ping.Integration{{Type: "googlechat", Parameters: params}}
+			Run(notifytest.NewContext([]ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-3 * time.Hour), PRTitle: "Add Google Chat", PRAuthor: "dave"}, ShouldPing: true, ChatID: "users/112233", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "backend", On: now.Add(-2 * time.Hour), IsTeam: true}, ShouldPing: true, ChatID: "spaces/AAAA", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+			}, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			requests := standIn.Requests()
+			if !assert.Len(t, requests, 1) {
+				return
+			}
+
+			// The key and token of the webhook are kept, and the message goes to the thread of the PR
+			u, err := url.Parse(requests[0].URI)
+			assert.NoError(t, err)
+			assert.Equal(t, url.Values{"key": {"k"}, "token": {"t"}, "messageReplyOption": {replyOption}}, u.Query())
+			assert.Equal(t, "application/json; charset=UTF-8", requests[0].Header.Get("Content-Type"))
+
+			msg := requests[0].Body
+			assert.Equal(t, tc.expectText, msg.Text)
+			assert.Equal(t, &thread{ThreadKey: "gong-owner-repo-123"}, msg.Thread)
+			assert.Equal(t, []cardV2{{
+				CardID: "gong",
+				Card: card{
+					Header: header{Title: "Review requested on PR #123", Subtitle: "owner/repo"},
+					Sections: []section{{Widgets: []widget{
+						{DecoratedText: &decoratedText{TopLabel: "Pull request", Text: "Add Google Chat", WrapText: true}},
+						{DecoratedText: &decoratedText{TopLabel: "Author", Text: "dave", WrapText: true}},
+						{DecoratedText: &decoratedText{TopLabel: "Waiting for", Text: "3h", WrapText: true}},
+						{DecoratedText: &decoratedText{TopLabel: "Reviewers", Text: "alice, backend (team), bob", WrapText: true}},
+						{DecoratedText: &decoratedText{TopLabel: "Labels", Text: "bug", WrapText: true}},
+						{ButtonList: &buttonList{Buttons: []button{
+							{Text: "View pull request", OnClick: onClick{OpenLink: openLink{URL: "https://github.com/owner/repo/pull/123"}}},
+						}}},
+					}}},
+				},
+			}}, msg.CardsV2)
+		})
+	}
+}
+
+func TestThreadKey(t *testing.T) {
+	// Reminders of one PR share a thread, other PRs get their own
+	assert.Equal(t, threadKey("owner", "repo", "123"), threadKey("owner", "repo", "123"))
+	assert.NotEqual(t, threadKey("owner", "repo", "123"), threadKey("owner", "repo", "124"))
+	assert.NotEqual(t, threadKey("owner", "repo", "123"), threadKey("owner", "other", "123"))
+}
@@ -0,0 +1,97 @@
+package gotify
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// DefaultTitle is the default template of the message title
+const DefaultTitle = `{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}{{ if .PRTitle }}: {{.PRTitle}}{{ end }}`
+
+// DefaultTemplate is the default template used for Gotify messages, written in Markdown
+const DefaultTemplate = `[PR #{{.PRNumber}}]({{.PRURL}}) is waiting for review{{ if .Mentions }} by {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template used for Gotify messages nudging the PR author
+const DefaultAuthorTemplate = `[PR #{{.PRNumber}}]({{.PRURL}}) is waiting on {{ range .Mentions }}{{ mention . }}{{ end }}: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}`
+
+// DefaultPriority is the priority of messages. Gotify clients usually notify from priority 4 and
+// play a sound from priority 8.
+const DefaultPriority = 5
+
+// message is a message created through the Gotify API
+type message struct {
+	Title    string                 `json:"title,omitempty"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "gotify")
+	if !ok {
+		return
+	}
+
+	serverURL := n.Param("url", "gotify-url")
+	// The application token is only read from the configuration, so that it can be kept in the environment
+	token := viper.GetString("gotify-token")
+	if serverURL == "" || token == "" {
+		log.Error().Msg("No Gotify server URL or application token found in configuration. Skipping Gotify notifications.")
+		return
+	}
+
+	msg := message{Priority: DefaultPriority}
+	if priority := n.Params["priority"]; priority != "" {
+		p, err := strconv.Atoi(priority)
+		if err != nil || p < 0 {
+			log.Warn().Msgf("Invalid Gotify priority %q, using the default priority", priority)
+			p = DefaultPriority
+		}
+		msg.Priority = p
+	}
+
+	data := n.TemplateData()
+	var err error
+	if msg.Title, err = format.FormatTemplate(n.Template(ctx, "title", DefaultTitle), data, mention); err != nil {
+		log.Error().Err(err).Msg("Error formatting Gotify title with template")
+		return
+	}
+	if msg.Message, err = format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention); err != nil {
+		log.Error().Err(err).Msg("Error formatting Gotify message with template")
+		return
+	}
+
+	// Clients render the message as Markdown, and open the pull request when the notification is clicked
+	msg.Extras = map[string]interface{}{
+		"client::display":      map[string]string{"contentType": "text/markdown"},
+		"client::notification": map[string]interface{}{"click": map[string]string{"url": n.PRURL}},
+	}
+
+	if n.DryRun {
+		n.LogDryRun("send Gotify message", msg.Message)
+		return
+	}
+
+	if err := sendMessage(ctx, strings.TrimSuffix(serverURL, "/"), token, msg); err != nil {
+		log.Error().Err(err).Msg("Error sending Gotify notification")
+	}
+}
+
+// mention names a reviewer. Push notifications cannot mention anybody.
+func mention(m format.Mention) string {
+	return m.Name
+}
+
+func sendMessage(ctx context.Context, serverURL, token string, msg message) error {
+	log.Debug().Msg("Sending Gotify notification")
+
+	_, err := notify.PostJSON(ctx, "gotify server", serverURL+"/message", msg, http.Header{"X-Gotify-Key": {token}})
+	return err
+}
@@ -0,0 +1,114 @@
+package gotify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newGotifyStandIn starts a stand-in of a Gotify server, which knows the application token "secret"
+func newGotifyStandIn(t *testing.T) *notifytest.StandIn[message] {
+	return notifytest.NewStandIn[message](t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/message", r.URL.Path)
+
+		if r.Header.Get("X-Gotify-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"Unauthorized","errorCode":401,"errorDescription":"you need to provide a valid access token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name           string
+		params         map[string]string
+		globalURL      string
+		isDryRun       bool
+		expectPosted   bool
+		expectTitle    string
+		expectMessage  string
+		expectPriority int
+	}{
+		{
+			name:           "Default message",
+			params:         map[string]string{},
+			globalURL:      "stand-in/",
+			expectPosted:   true,
+			expectTitle:    "owner/repo#123: Fix the build",
+			expectMessage:  "[PR #123](https://github.com/owner/repo/pull/123) is waiting for review by alice, bob",
+			expectPriority: DefaultPriority,
+		},
+		{
+			name:           "Server, priority and templates from parameters",
+			params:         map[string]string{"url": "stand-in", "priority": "8", "title": "Review needed", "template": "{{ len .Mentions }} reviewers"},
+			expectPosted:   true,
+			expectTitle:    "Review needed",
+			expectMessage:  "2 reviewers",
+			expectPriority: 8,
+		},
+		{
+			name:      "Dry run",
+			params:    map[string]string{},
+			globalURL: "stand-in",
+			isDryRun:  true,
+		},
+		{
+			name:   "No server URL",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newGotifyStandIn(t)
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("gotify-url", strings.Replace(tc.globalURL, "stand-in", standIn.URL, 1))
+			viper.Set("gotify-token", "secret")
+			if url, ok := tc.params["url"]; ok {
+				tc.params["url"] = strings.Replace(url, "stand-in", standIn.URL, 1)
+			}
+
+			integrations := []ping.Integration{{Type: "gotify", Parameters: tc.params}}
+			pingRequests := []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: time.Now(), PRTitle: "Fix the build"}, ShouldPing: true, Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: time.Now(), PRTitle: "Fix the build"}, ShouldPing: true, Integrations: integrations},
+			}
+
+			Run(notifytest.NewContext(pingRequests, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			if messages := standIn.Bodies(); assert.Len(t, messages, 1) {
+				msg := messages[0]
+				assert.Equal(t, tc.expectTitle, msg.Title)
+				assert.Equal(t, tc.expectMessage, msg.Message)
+				assert.Equal(t, tc.expectPriority, msg.Priority)
+				assert.Equal(t, map[string]interface{}{
+					"client::display":      map[string]interface{}{"contentType": "text/markdown"},
+					"client::notification": map[string]interface{}{"click": map[string]interface{}{"url": "https://github.com/owner/repo/pull/123"}},
+				}, msg.Extras)
+			}
+		})
+	}
+}
+
+func TestSendMessageError(t *testing.T) {
+	standIn := newGotifyStandIn(t)
+
+	err := sendMessage(context.Background(), standIn.URL, "wrong", message{Message: "hello"})
+
+	assert.EqualError(t, err, `gotify server returned 401: {"error":"Unauthorized","errorCode":401,"errorDescription":"you need to provide a valid access token"}`)
+}
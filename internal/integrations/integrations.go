@@ -5,13 +5,29 @@ import (
 
 	"github.com/Djiit/gong/internal/integrations/actions"
 	"github.com/Djiit/gong/internal/integrations/comment"
+	"github.com/Djiit/gong/internal/integrations/discord"
+	"github.com/Djiit/gong/internal/integrations/email"
+	"github.com/Djiit/gong/internal/integrations/googlechat"
+	"github.com/Djiit/gong/internal/integrations/gotify"
+	"github.com/Djiit/gong/internal/integrations/matrix"
+	"github.com/Djiit/gong/internal/integrations/mattermost"
+	"github.com/Djiit/gong/internal/integrations/ntfy"
+	"github.com/Djiit/gong/internal/integrations/opsgenie"
+	"github.com/Djiit/gong/internal/integrations/pagerduty"
+	"github.com/Djiit/gong/internal/integrations/rocketchat"
 	"github.com/Djiit/gong/internal/integrations/slack"
 	"github.com/Djiit/gong/internal/integrations/stdout"
+	"github.com/Djiit/gong/internal/integrations/teams"
+	"github.com/Djiit/gong/internal/integrations/telegram"
+	"github.com/Djiit/gong/internal/integrations/webhook"
 )
 
 type Integration struct {
 	Name string
 	Run  func(ctx context.Context)
+	// Resolve, if set, is called once the pull request no longer waits on reviews (merged, closed,
+	// back in draft or fully reviewed), with the reason in the "resolution" context value
+	Resolve func(ctx context.Context)
 }
 
 var Integrations = map[string]Integration{
@@ -24,11 +40,66 @@ var Integrations = map[string]Integration{
 		Run:  comment.Run,
 	},
 	"slack": {
-		Name: "Slack Integration",
-		Run:  slack.Run,
+		Name:    "Slack Integration",
+		Run:     slack.Run,
+		Resolve: slack.Resolve,
 	},
 	"actions": {
 		Name: "GitHub Actions Integration",
 		Run:  actions.Run,
 	},
+	"teams": {
+		Name: "Microsoft Teams Integration",
+		Run:  teams.Run,
+	},
+	"discord": {
+		Name: "Discord Integration",
+		Run:  discord.Run,
+	},
+	"mattermost": {
+		Name: "Mattermost Integration",
+		Run:  mattermost.Run,
+	},
+	"rocketchat": {
+		Name: "Rocket.Chat Integration",
+		Run:  rocketchat.Run,
+	},
+	"googlechat": {
+		Name: "Google Chat Integration",
+		Run:  googlechat.Run,
+	},
+	"email": {
+		Name: "Email Integration",
+		Run:  email.Run,
+	},
+	"webhook": {
+		Name: "Webhook Integration",
+		Run:  webhook.Run,
+	},
+	"matrix": {
+		Name: "Matrix Integration",
+		Run:  matrix.Run,
+	},
+	"telegram": {
+		Name: "Telegram Integration",
+		Run:  telegram.Run,
+	},
+	"ntfy": {
+		Name: "ntfy Integration",
+		Run:  ntfy.Run,
+	},
+	"gotify": {
+		Name: "Gotify Integration",
+		Run:  gotify.Run,
+	},
+	"pagerduty": {
+		Name:    "PagerDuty Integration",
+		Run:     pagerduty.Run,
+		Resolve: pagerduty.Resolve,
+	},
+	"opsgenie": {
+		Name:    "Opsgenie Integration",
+		Run:     opsgenie.Run,
+		Resolve: opsgenie.Resolve,
+	},
 }
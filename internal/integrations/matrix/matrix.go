@@ -0,0 +1,285 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// DefaultTemplate is the default template of the plain-text body of Matrix messages
+const DefaultTemplate = `PR #{{.PRNumber}} is waiting for review: {{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}} {{.PRURL}}
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultHTMLTemplate is the default template of the HTML body of Matrix messages
+const DefaultHTMLTemplate = `PR #{{.PRNumber}} is waiting for review: <a href="{{.PRURL}}">{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}</a>{{ if .PRTitle }} <em>{{.PRTitle}}</em>{{ end }}<br>
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template of the plain-text body of author nudges
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} is waiting on its author: {{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}} {{.PRURL}}
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
+// DefaultAuthorHTMLTemplate is the default template of the HTML body of author nudges
+const DefaultAuthorHTMLTemplate = `PR #{{.PRNumber}} is waiting on its author: <a href="{{.PRURL}}">{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}</a><br>
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
+// maxAttempts bounds how many times a message is sent when the homeserver fails or rate limits
+const maxAttempts = 3
+
+// maxRetryAfter bounds how long gong waits for a rate limit to reset
+const maxRetryAfter = time.Minute
+
+// wait pauses until d elapsed, replaced in tests
+var wait = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// message is the content of an m.room.message event
+type message struct {
+	MsgType       string   `json:"msgtype"`
+	Body          string   `json:"body"`
+	Format        string   `json:"format,omitempty"`
+	FormattedBody string   `json:"formatted_body,omitempty"`
+	Mentions      mentions `json:"m.mentions"`
+}
+
+// mentions lists who the message intentionally mentions, so that only they are notified
+type mentions struct {
+	UserIDs []string `json:"user_ids,omitempty"`
+}
+
+// matrixError is the error body of the client-server API
+type matrixError struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// client sends events to a room with an access token
+type client struct {
+	homeserver string
+	token      string
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "matrix")
+	if !ok {
+		return
+	}
+
+	homeserver := strings.TrimSuffix(n.Param("homeserver", "matrix-homeserver"), "/")
+	room := n.Param("room", "matrix-room")
+	// The access token is only read from the configuration, so that it can be kept in the environment
+	token := viper.GetString("matrix-token")
+
+	if homeserver == "" || room == "" || token == "" {
+		log.Error().Msg("Matrix homeserver, room or access token missing in configuration. Skipping Matrix notifications.")
+		return
+	}
+
+	// Author nudges have their own templates
+	templateStr := n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate)
+	htmlTemplateStr := n.Template(ctx, "htmlTemplate", DefaultHTMLTemplate)
+	if n.AuthorNudge {
+		htmlTemplateStr = n.Template(ctx, "authorHtmlTemplate", DefaultAuthorHTMLTemplate)
+	}
+
+	msg, err := renderMessage(templateStr, htmlTemplateStr, n.TemplateData())
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Matrix message with template")
+		return
+	}
+
+	if n.DryRun {
+		n.LogDryRun("send Matrix message to "+room, msg.Body)
+		return
+	}
+
+	c := client{homeserver: homeserver, token: token}
+	if err := c.send(ctx, room, msg); err != nil {
+		log.Error().Err(err).Msg("Error sending Matrix notification")
+	}
+}
+
+// renderMessage renders the plain-text and HTML bodies of a message, and lists the Matrix users
+// it mentions
+func renderMessage(templateStr, htmlTemplateStr string, data format.TemplateData) (message, error) {
+	tmpl, err := template.New("matrix").Funcs(template.FuncMap{"mention": mention}).Parse(templateStr)
+	if err != nil {
+		return message{}, fmt.Errorf("template parsing error: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return message{}, fmt.Errorf("template execution error: %w", err)
+	}
+
+	// html/template escapes the data, such as PR titles, to keep the markup intact
+	htmlTmpl, err := htmltemplate.New("matrix-html").Funcs(htmltemplate.FuncMap{"mention": pill}).Parse(htmlTemplateStr)
+	if err != nil {
+		return message{}, fmt.Errorf("html template parsing error: %w", err)
+	}
+	var formatted bytes.Buffer
+	if err := htmlTmpl.Execute(&formatted, data); err != nil {
+		return message{}, fmt.Errorf("html template execution error: %w", err)
+	}
+
+	msg := message{
+		MsgType:       "m.text",
+		Body:          body.String(),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted.String(),
+	}
+	for _, m := range data.Mentions {
+		if isUserID(m) {
+			msg.Mentions.UserIDs = append(msg.Mentions.UserIDs, m.ChatID)
+		}
+	}
+	return msg, nil
+}
+
+// isUserID reports whether a reviewer's chat ID is a Matrix user ID (@localpart:server). Matrix
+// has no group mentions, so teams are never mentioned.
+func isUserID(m format.Mention) bool {
+	return !m.IsTeam && strings.HasPrefix(m.ChatID, "@") && strings.Contains(m.ChatID, ":")
+}
+
+// mention formats a reviewer in the plain-text body
+func mention(m format.Mention) string {
+	if !isUserID(m) {
+		return m.Name
+	}
+	return m.ChatID
+}
+
+// pill formats a reviewer in the HTML body as a link to their user ID, which clients display as a pill
+func pill(m format.Mention) htmltemplate.HTML {
+	if !isUserID(m) {
+		return htmltemplate.HTML(html.EscapeString(m.Name))
+	}
+	return htmltemplate.HTML(fmt.Sprintf(`<a href="https://matrix.to/#/%s">%s</a>`, html.EscapeString(m.ChatID), html.EscapeString(m.Name)))
+}
+
+// send sends a message to a room, given by ID or alias. The transaction ID is the same for every
+// attempt, so that the homeserver ignores the retries of a message it already received.
+func (c client) send(ctx context.Context, room string, msg message) error {
+	roomID, err := c.resolveRoom(ctx, room)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), newTransactionID())
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := c.do(ctx, http.MethodPut, path, body, nil)
+		if err == nil || retryAfter == 0 {
+			return err
+		}
+		if attempt == maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		log.Warn().Msgf("Matrix message failed (%v), retrying in %s", err, retryAfter)
+		if err := wait(ctx, retryAfter); err != nil {
+			return err
+		}
+	}
+}
+
+// resolveRoom returns the ID of a room given by ID (!id:server) or alias (#alias:server)
+func (c client) resolveRoom(ctx context.Context, room string) (string, error) {
+	if !strings.HasPrefix(room, "#") {
+		return room, nil
+	}
+
+	var resolved struct {
+		RoomID string `json:"room_id"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/_matrix/client/v3/directory/room/"+url.PathEscape(room), nil, &resolved); err != nil {
+		return "", fmt.Errorf("resolving room alias %s: %w", room, err)
+	}
+	return resolved.RoomID, nil
+}
+
+// do calls the client-server API. When the call failed but is worth retrying, after a rate limit
+// or a server error, it returns how long to wait before retrying along with the error.
+func (c client) do(ctx context.Context, method, path string, body []byte, result interface{}) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.homeserver+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := notify.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, err
+		}
+		return time.Second, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Debug().Msgf("Error closing response body: %v", err)
+		}
+	}()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 300 {
+		if result == nil {
+			return 0, nil
+		}
+		return 0, json.Unmarshal(respBody, result)
+	}
+
+	var matrixErr matrixError
+	_ = json.Unmarshal(respBody, &matrixErr)
+	err = fmt.Errorf("matrix homeserver returned %d: %s %s", resp.StatusCode, matrixErr.ErrCode, matrixErr.Error)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retryAfter := time.Duration(matrixErr.RetryAfterMs) * time.Millisecond
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		return min(retryAfter, maxRetryAfter), err
+	case resp.StatusCode >= 500:
+		return time.Second, err
+	}
+	return 0, err
+}
+
+// newTransactionID returns a unique transaction ID for a message
+func newTransactionID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return "gong-" + hex.EncodeToString(id)
+}
@@ -0,0 +1,301 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// event is an event sent to the homeserver stand-in
+type event struct {
+	Room          string
+	TransactionID string
+	Content       message
+}
+
+// homeserverStandIn is a local homeserver knowing the #gong:example.org alias and accepting
+// events sent with the token "secret". Each send answers with the status taken from statuses in
+// turn, the last one answering all further sends.
+type homeserverStandIn struct {
+	URL string
+
+	mu             sync.Mutex
+	transactionIDs []string
+	events         []event
+}
+
+func newHomeserverStandIn(t *testing.T, statuses ...int) *homeserverStandIn {
+	standIn := &homeserverStandIn{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errcode": "M_UNKNOWN_TOKEN", "error": "Invalid access token"}`))
+			return
+		}
+
+		if r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/directory/room/#gong:example.org" {
+			_, _ = w.Write([]byte(`{"room_id": "!resolved:example.org"}`))
+			return
+		}
+
+		rest, ok := strings.CutPrefix(r.URL.Path, "/_matrix/client/v3/rooms/")
+		parts := strings.Split(rest, "/")
+		if r.Method != http.MethodPut || !ok || len(parts) != 4 || parts[1] != "send" || parts[2] != "m.room.message" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode": "M_UNRECOGNIZED"}`))
+			return
+		}
+
+		standIn.mu.Lock()
+		standIn.transactionIDs = append(standIn.transactionIDs, parts[3])
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status = statuses[min(len(standIn.transactionIDs), len(statuses))-1]
+		}
+		if status == http.StatusOK {
+			var content message
+			_ = json.NewDecoder(r.Body).Decode(&content)
+			standIn.events = append(standIn.events, event{Room: parts[0], TransactionID: parts[3], Content: content})
+		}
+		standIn.mu.Unlock()
+
+		w.WriteHeader(status)
+		switch status {
+		case http.StatusOK:
+			_, _ = w.Write([]byte(`{"event_id": "$event"}`))
+		case http.StatusTooManyRequests:
+			_, _ = w.Write([]byte(`{"errcode": "M_LIMIT_EXCEEDED", "error": "Too many requests", "retry_after_ms": 2000}`))
+		default:
+			_, _ = w.Write([]byte(`{"errcode": "M_UNKNOWN", "error": "Internal error"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	standIn.URL = server.URL
+
+	return standIn
+}
+
+func (s *homeserverStandIn) received() []event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]event{}, s.events...)
+}
+
+// attempts returns the transaction IDs of every send, including the failed ones
+func (s *homeserverStandIn) attempts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.transactionIDs...)
+}
+
+// recordWaits replaces wait for the duration of a test and returns the waits it was asked for
+func recordWaits(t *testing.T) *[]time.Duration {
+	waits := &[]time.Duration{}
+	original := wait
+	wait = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	t.Cleanup(func() { wait = original })
+	return waits
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      map[string]string
+		params      map[string]string
+		isDryRun    bool
+		expectRoom  string
+		expectBody  string
+		expectHTML  string
+		expectUsers []string
+	}{
+		{
+			name:        "Default templates",
+			config:      map[string]string{"matrix-homeserver": "stand-in", "matrix-token": "secret", "matrix-room": "!room:example.org"},
+			expectRoom:  "!room:example.org",
+			expectBody:  "PR #123 is waiting for review: owner/repo#123 https://github.com/owner/repo/pull/123\nReviewers: @alice:example.org, bob",
+			expectHTML:  "PR #123 is waiting for review: <a href=\"https://github.com/owner/repo/pull/123\">owner/repo#123</a> <em>Fix &lt;script&gt; injection</em><br>\nReviewers: <a href=\"https://matrix.to/#/@alice:example.org\">alice</a>, bob",
+			expectUsers: []string{"@alice:example.org"},
+		},
+		{
+			name:   "Room alias and templates from parameters",
+			config: map[string]string{"matrix-token": "secret"},
+			params: map[string]string{
+				"homeserver":   "stand-in",
+				"room":         "#gong:example.org",
+				"template":     "Review {{.PRURL}}",
+				"htmlTemplate": "Review <b>{{.PRTitle}}</b>",
+			},
+			expectRoom:  "!resolved:example.org",
+			expectBody:  "Review https://github.com/owner/repo/pull/123",
+			expectHTML:  "Review <b>Fix &lt;script&gt; injection</b>",
+			expectUsers: []string{"@alice:example.org"},
+		},
+		{
+			name:     "Dry run",
+			config:   map[string]string{"matrix-homeserver": "stand-in", "matrix-token": "secret", "matrix-room": "!room:example.org"},
+			isDryRun: true,
+		},
+		{
+			name:   "No access token",
+			config: map[string]string{"matrix-homeserver": "stand-in", "matrix-room": "!room:example.org"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newHomeserverStandIn(t)
+			viper.Reset()
+			defer viper.Reset()
+			for key, val := range tc.config {
+				viper.Set(key, strings.Replace(val, "stand-in", standIn.URL, 1))
+			}
+			params := make(map[string]string)
+			for key, val := range tc.params {
+				params[key] = strings.Replace(val, "stand-in", standIn.URL, 1)
+			}
+
+			integrations := []ping.Integration{{Type: "matrix", Parameters: params}}
+			pingRequests := []ping.PingRequest{
+				{
+					Req:          githubclient.ReviewRequest{From: "alice", On: time.Now(), PRTitle: "Fix <script> injection"},
+					ShouldPing:   true,
+					ChatID:       "@alice:example.org",
+					Integrations: integrations,
+				},
+				{
+					Req:          githubclient.ReviewRequest{From: "bob", On: time.Now(), PRTitle: "Fix <script> injection"},
+					ShouldPing:   true,
+					Integrations: integrations,
+				},
+			}
+
+			Run(notifytest.NewContext(pingRequests, tc.isDryRun))
+
+			events := standIn.received()
+			if tc.expectRoom == "" {
+				assert.Empty(t, events)
+				return
+			}
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, tc.expectRoom, events[0].Room)
+				assert.Equal(t, "m.text", events[0].Content.MsgType)
+				assert.Equal(t, "org.matrix.custom.html", events[0].Content.Format)
+				assert.Equal(t, tc.expectBody, events[0].Content.Body)
+				assert.Equal(t, tc.expectHTML, events[0].Content.FormattedBody)
+				assert.Equal(t, tc.expectUsers, events[0].Content.Mentions.UserIDs)
+			}
+		})
+	}
+}
+
+func TestPill(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mention  format.Mention
+		expected string
+	}{
+		{"User ID", format.Mention{Name: "alice", ChatID: "@alice:example.org"}, `<a href="https://matrix.to/#/@alice:example.org">alice</a>`},
+		{"Not a user ID", format.Mention{Name: "bob", ChatID: "U0BOB"}, "bob"},
+		{"Team", format.Mention{Name: "org/a&b (team)", ChatID: "@team:example.org", IsTeam: true}, "org/a&amp;b (team)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(pill(tc.mention)))
+		})
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	testCases := []struct {
+		name           string
+		statuses       []int
+		expectErr      bool
+		expectEvents   int
+		expectedWaits  []time.Duration
+		expectAttempts int
+	}{
+		{
+			name:           "Rate limited then sent",
+			statuses:       []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusOK},
+			expectEvents:   1,
+			expectAttempts: 3,
+			expectedWaits:  []time.Duration{2 * time.Second, time.Second},
+		},
+		{
+			name:           "Giving up",
+			statuses:       []int{http.StatusServiceUnavailable},
+			expectErr:      true,
+			expectAttempts: 3,
+			expectedWaits:  []time.Duration{time.Second, time.Second},
+		},
+		{
+			name:           "Client errors are not retried",
+			statuses:       []int{http.StatusForbidden},
+			expectErr:      true,
+			expectAttempts: 1,
+			expectedWaits:  []time.Duration{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			waits := recordWaits(t)
+			standIn := newHomeserverStandIn(t, tc.statuses...)
+			c := client{homeserver: standIn.URL, token: "secret"}
+
+			err := c.send(context.Background(), "!room:example.org", message{MsgType: "m.text", Body: "hello"})
+
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, standIn.received(), tc.expectEvents)
+			assert.Len(t, standIn.attempts(), tc.expectAttempts)
+			assert.Equal(t, tc.expectedWaits, *waits)
+		})
+	}
+}
+
+func TestSendTransactionIDs(t *testing.T) {
+	recordWaits(t)
+	standIn := newHomeserverStandIn(t, http.StatusBadGateway, http.StatusOK)
+	c := client{homeserver: standIn.URL, token: "secret"}
+
+	assert.NoError(t, c.send(context.Background(), "!room:example.org", message{Body: "first"}))
+	assert.NoError(t, c.send(context.Background(), "!room:example.org", message{Body: "second"}))
+
+	// The retry of the first message reuses its transaction ID, the second message gets a new one
+	attempts := standIn.attempts()
+	if assert.Len(t, attempts, 3) {
+		assert.True(t, strings.HasPrefix(attempts[0], "gong-"))
+		assert.Equal(t, attempts[0], attempts[1])
+		assert.NotEqual(t, attempts[1], attempts[2])
+	}
+}
+
+func TestSendUnknownToken(t *testing.T) {
+	standIn := newHomeserverStandIn(t)
+	c := client{homeserver: standIn.URL, token: "wrong"}
+
+	err := c.send(context.Background(), "#gong:example.org", message{Body: "hello"})
+
+	assert.ErrorContains(t, err, "M_UNKNOWN_TOKEN")
+	assert.Empty(t, standIn.received())
+}
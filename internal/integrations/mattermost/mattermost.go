@@ -0,0 +1,137 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTemplate is the default template used for Mattermost messages
+const DefaultTemplate = `PR #{{.PRNumber}} is waiting for review: [{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}]({{.PRURL}})
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template used for Mattermost messages nudging the PR author
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} is waiting on its author: [{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}]({{.PRURL}})
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
+// Attachment colors of review requests and author nudges
+const (
+	ColorReview = "#1C58D9"
+	ColorAuthor = "#FFBC1F"
+)
+
+// payload is the message accepted by Mattermost incoming webhooks
+type payload struct {
+	Text        string       `json:"text"`
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	IconURL     string       `json:"icon_url,omitempty"`
+	IconEmoji   string       `json:"icon_emoji,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+// attachment is a Mattermost message attachment. Mentions in attachments do not notify anybody,
+// so it only holds the details of the pull request.
+type attachment struct {
+	Fallback  string  `json:"fallback"`
+	Color     string  `json:"color,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	TitleLink string  `json:"title_link,omitempty"`
+	Fields    []field `json:"fields,omitempty"`
+	Footer    string  `json:"footer,omitempty"`
+}
+
+type field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "mattermost")
+	if !ok {
+		return
+	}
+
+	webhookURL := n.Param("webhook", "mattermost-webhook")
+	if webhookURL == "" {
+		log.Error().Msg("No Mattermost webhook URL found in configuration. Skipping Mattermost notifications.")
+		return
+	}
+
+	data := n.TemplateData()
+	text, err := format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Mattermost message with template")
+		return
+	}
+
+	// Overriding the channel, username and icon must be allowed by the webhook and the server
+	// settings, otherwise Mattermost ignores them
+	message := payload{
+		Text:        text,
+		Channel:     channel(n.Params["channel"]),
+		Username:    n.Params["username"],
+		IconURL:     n.Params["icon_url"],
+		IconEmoji:   n.Params["icon_emoji"],
+		Attachments: []attachment{newAttachment(data)},
+	}
+
+	if n.DryRun {
+		n.LogDryRun("send Mattermost notification", text)
+		return
+	}
+
+	log.Debug().Msg("Sending Mattermost notification via webhook")
+	if _, err := notify.PostJSON(ctx, "mattermost webhook", webhookURL, message, nil); err != nil {
+		log.Error().Err(err).Msg("Error sending Mattermost notification")
+	}
+}
+
+// mention formats a reviewer for Mattermost, whose chat ID is their username or, for teams, the
+// name of a user group. Reviewers missing from the identity directory are only named.
+func mention(m format.Mention) string {
+	if m.ChatID == "" {
+		return m.Name
+	}
+	return "@" + strings.TrimPrefix(m.ChatID, "@")
+}
+
+// channel normalizes a channel override: Mattermost expects the channel name without "#", or
+// "@username" for a direct message
+func channel(name string) string {
+	return strings.TrimPrefix(name, "#")
+}
+
+// newAttachment lists the pull request title, author, waiting time and labels
+func newAttachment(data format.TemplateData) attachment {
+	a := attachment{
+		Fallback:  fmt.Sprintf("%s/%s#%s", data.RepoOwner, data.RepoName, data.PRNumber),
+		Color:     ColorReview,
+		Title:     fmt.Sprintf("%s/%s#%s", data.RepoOwner, data.RepoName, data.PRNumber),
+		TitleLink: data.PRURL,
+		Footer:    "Sent via gong",
+	}
+	if data.Author != "" {
+		a.Color = ColorAuthor
+	}
+	if data.PRTitle != "" {
+		a.Title += " " + data.PRTitle
+	}
+
+	if data.PRAuthor != "" {
+		a.Fields = append(a.Fields, field{Title: "Author", Value: data.PRAuthor, Short: true})
+	}
+	if data.Age != "" {
+		a.Fields = append(a.Fields, field{Title: "Waiting for", Value: data.Age, Short: true})
+	}
+	if len(data.Labels) > 0 {
+		a.Fields = append(a.Fields, field{Title: "Labels", Value: "`" + strings.Join(data.Labels, "` `") + "`", Short: true})
+	}
+
+	return a
+}
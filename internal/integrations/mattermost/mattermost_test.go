@@ -0,0 +1,112 @@
+package mattermost
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		params        map[string]string
+		globalWebhook bool
+		isDryRun      bool
+		expectPosted  bool
+		expected      payload
+	}{
+		{
+			name:          "Default message",
+			params:        map[string]string{},
+			globalWebhook: true,
+			expectPosted:  true,
+			expected: payload{
+				Text: "PR #123 is waiting for review: [owner/repo#123](https://github.com/owner/repo/pull/123)\nReviewers: @alice, @backend, bob",
+			},
+		},
+		{
+			name: "Channel, username and icon overrides",
+			params: map[string]string{
+				"webhook":    "stand-in",
+				"channel":    "#reviews",
+				"username":   "gong",
+				"icon_emoji": ":bell:",
+				"template":   "Please review PR #{{ .PRNumber }}",
+			},
+			expectPosted: true,
+			expected: payload{
+				Text:      "Please review PR #123",
+				Channel:   "reviews",
+				Username:  "gong",
+				IconEmoji: ":bell:",
+			},
+		},
+		{
+			name:          "Dry run",
+			params:        map[string]string{},
+			globalWebhook: true,
+			isDryRun:      true,
+		},
+		{
+			name:   "No webhook",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := notifytest.NewStandIn[payload](t, notifytest.Answer(http.StatusOK, "ok"))
+
+			viper.Reset()
+			defer viper.Reset()
+			if tc.globalWebhook {
+				viper.Set("mattermost-webhook", standIn.URL)
+			}
+			params := make(map[string]string)
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			if params["webhook"] == "stand-in" {
+				params["webhook"] = standIn.URL
+			}
+
+			integrations := []ping.Integration{{Type: "mattermost", Parameters: params}}
+			Run(notifytest.NewContext([]ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-3 * time.Hour), PRTitle: "Add Mattermost", PRAuthor: "dave"}, ShouldPing: true, ChatID: "alice", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "backend", On: now.Add(-2 * time.Hour), IsTeam: true}, ShouldPing: true, ChatID: "@backend", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+			}, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			messages := standIn.Bodies()
+			if !assert.Len(t, messages, 1) {
+				return
+			}
+
+			tc.expected.Attachments = []attachment{{
+				Fallback:  "owner/repo#123",
+				Color:     ColorReview,
+				Title:     "owner/repo#123 Add Mattermost",
+				TitleLink: "https://github.com/owner/repo/pull/123",
+				Fields: []field{
+					{Title: "Author", Value: "dave", Short: true},
+					{Title: "Waiting for", Value: "3h", Short: true},
+					{Title: "Labels", Value: "`bug`", Short: true},
+				},
+				Footer: "Sent via gong",
+			}}
+			assert.Equal(t, tc.expected, messages[0])
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// HTTPClient sends the notifications of the integrations calling HTTP APIs; services answer
+// quickly or not at all
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Notification is a pull request an integration notifies about, read from the context of the run
+type Notification struct {
+	PingRequests []ping.PingRequest
+	RepoOwner    string
+	RepoName     string
+	PRNumber     string
+	PRURL        string
+	Labels       []string
+	DryRun       bool
+	// Whether the notification nudges the PR author rather than reviewers
+	AuthorNudge bool
+	// Parameters of the integration, from the rule that applied to the reviewers
+	Params map[string]string
+}
+
+// FromContext reads the notification of an integration from the context of the run. It returns
+// false when there is nobody to notify.
+func FromContext(ctx context.Context, integration string) (*Notification, bool) {
+	pingRequests := ctx.Value("pingRequests").([]ping.PingRequest)
+	if len(pingRequests) == 0 {
+		return nil, false
+	}
+
+	n := &Notification{
+		PingRequests: pingRequests,
+		RepoOwner:    ctx.Value("repoOwner").(string),
+		RepoName:     ctx.Value("repoName").(string),
+		PRNumber:     ctx.Value("pr").(string),
+		DryRun:       ctx.Value("dry-run").(bool),
+		AuthorNudge:  ping.IsAuthorNudge(pingRequests),
+		Params:       make(map[string]string),
+	}
+	n.Labels, _ = ctx.Value("labels").([]string)
+
+	for _, intg := range pingRequests[0].Integrations {
+		if intg.Type == integration && intg.Parameters != nil {
+			n.Params = intg.Parameters
+		}
+	}
+
+	// Use the PR URL reported by the provider, falling back to GitHub
+	n.PRURL, _ = ctx.Value("prURL").(string)
+	if n.PRURL == "" {
+		n.PRURL = fmt.Sprintf("https://github.com/%s/%s/pull/%s", n.RepoOwner, n.RepoName, n.PRNumber)
+	}
+
+	return n, true
+}
+
+// Param returns a parameter of the integration, falling back to the configuration key configKey
+func (n *Notification) Param(key, configKey string) string {
+	if val := n.Params[key]; val != "" {
+		return val
+	}
+	return viper.GetString(configKey)
+}
+
+// Template returns the template set under key in the integration parameters, then in the
+// context, falling back to defaultTemplate
+func (n *Notification) Template(ctx context.Context, key, defaultTemplate string) string {
+	if val := n.Params[key]; val != "" {
+		return val
+	}
+	if val, ok := ctx.Value(key).(string); ok && val != "" {
+		return val
+	}
+	return defaultTemplate
+}
+
+// MessageTemplate returns the template of the message: "template" for reviewers, or
+// "authorTemplate" when nudging the author, each with its default
+func (n *Notification) MessageTemplate(ctx context.Context, defaultTemplate, defaultAuthorTemplate string) string {
+	if n.AuthorNudge {
+		return n.Template(ctx, "authorTemplate", defaultAuthorTemplate)
+	}
+	return n.Template(ctx, "template", defaultTemplate)
+}
+
+// TemplateData returns the data the templates of the notification are rendered with
+func (n *Notification) TemplateData() format.TemplateData {
+	data := format.PrepareTemplateData(n.PingRequests, n.RepoOwner, n.RepoName, n.PRNumber, n.PRURL, false)
+	data.Labels = n.Labels
+	return data
+}
+
+// LogDryRun logs what a dry run would do, e.g. "send Discord notification", and the message
+func (n *Notification) LogDryRun(action, message string) {
+	log.Info().Msgf("[DRY RUN] Would %s for PR #%s in %s/%s", action, n.PRNumber, n.RepoOwner, n.RepoName)
+	log.Info().Msgf("[DRY RUN] Message: %s", message)
+}
+
+// ResolveAlert resolves the alert service, e.g. "pagerduty", stored for the pull request of the
+// context by calling resolveAlert with the resolution. The alert is forgotten once resolved, and
+// kept when resolveAlert fails so that the next run retries.
+func ResolveAlert(ctx context.Context, service string, resolveAlert func(ctx context.Context, alert state.Alert, resolution string) error) {
+	repoOwner := ctx.Value("repoOwner").(string)
+	repoName := ctx.Value("repoName").(string)
+	prNumber := ctx.Value("pr").(string)
+	isDryRun := ctx.Value("dry-run").(bool)
+	resolution, _ := ctx.Value("resolution").(string)
+
+	store, err := state.FromContext(ctx)
+	if err != nil {
+		log.Warn().Msgf("Could not open the state, %s alerts of PR #%s in %s/%s will not be resolved: %v", service, prNumber, repoOwner, repoName, err)
+		return
+	}
+
+	key := state.AlertKey(repoOwner, repoName, prNumber, service)
+	alert, found := store.Alert(key)
+	if !found {
+		return
+	}
+
+	if isDryRun {
+		log.Info().Msgf("[DRY RUN] Would resolve %s alert %s (%s)", service, alert.DedupKey, resolution)
+		return
+	}
+
+	if err := resolveAlert(ctx, alert, resolution); err != nil {
+		log.Error().Err(err).Msgf("Error resolving %s alert %s", service, alert.DedupKey)
+		return
+	}
+	log.Info().Msgf("Resolved %s alert %s: %s", service, alert.DedupKey, resolution)
+
+	store.DeleteAlert(key)
+	if err := store.Save(); err != nil {
+		log.Error().Err(err).Msgf("Error saving the state after resolving the %s alert", service)
+	}
+}
+
+// Response is the answer of a service to PostJSON
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // Start of the response body
+}
+
+// StatusError is returned by PostJSON when the service answers with an error status
+type StatusError struct {
+	Service string // e.g. "mattermost webhook"
+	*Response
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Service, e.StatusCode, strings.TrimSpace(string(e.Body)))
+}
+
+// PostJSON posts v as JSON to url with HTTPClient, along with header, and returns the answer of
+// the service. Error statuses are returned as a *StatusError along with the answer.
+func PostJSON(ctx context.Context, service, url string, v interface{}, header http.Header) (*Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Debug().Msgf("Error closing response body: %v", err)
+		}
+	}()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	answer := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}
+	if resp.StatusCode >= 300 {
+		return answer, &StatusError{Service: service, Response: answer}
+	}
+	return answer, nil
+}
@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	integrations := []ping.Integration{
+		{Type: "other", Parameters: map[string]string{"webhook": "other"}},
+		{Type: "chat", Parameters: map[string]string{"webhook": "https://chat.example.com/hook"}},
+	}
+	pingRequests := []ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", PRTitle: "Fix the build"}, ShouldPing: true, Integrations: integrations},
+	}
+
+	n, ok := FromContext(notifytest.NewContext(pingRequests, true), "chat")
+
+	assert.True(t, ok)
+	assert.Equal(t, &Notification{
+		PingRequests: pingRequests,
+		RepoOwner:    "owner",
+		RepoName:     "repo",
+		PRNumber:     "123",
+		PRURL:        "https://github.com/owner/repo/pull/123",
+		Labels:       []string{"bug"},
+		DryRun:       true,
+		Params:       map[string]string{"webhook": "https://chat.example.com/hook"},
+	}, n)
+	data := n.TemplateData()
+	assert.Equal(t, "Fix the build", data.PRTitle)
+	assert.Equal(t, []string{"bug"}, data.Labels)
+
+	_, ok = FromContext(notifytest.NewContext(nil, false), "chat")
+	assert.False(t, ok)
+}
+
+func TestFromContextFallsBackToGitHubURL(t *testing.T) {
+	ctx := context.WithValue(notifytest.NewContext([]ping.PingRequest{{ShouldPing: true}}, false), "prURL", "")
+
+	n, _ := FromContext(ctx, "chat")
+
+	assert.Equal(t, "https://github.com/owner/repo/pull/123", n.PRURL)
+	assert.Empty(t, n.Params)
+}
+
+func TestTemplates(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("chat-webhook", "https://chat.example.com/global")
+
+	ctx := context.WithValue(context.Background(), "template", "from context")
+	n := &Notification{Params: map[string]string{"authorTemplate": "from parameters", "channel": ""}}
+
+	assert.Equal(t, "from context", n.MessageTemplate(ctx, "review", "author"))
+	assert.Equal(t, "review", n.MessageTemplate(context.Background(), "review", "author"))
+	assert.Equal(t, "https://chat.example.com/global", n.Param("webhook", "chat-webhook"))
+	assert.Equal(t, "", n.Param("channel", ""))
+
+	n.AuthorNudge = true
+	assert.Equal(t, "from parameters", n.MessageTemplate(ctx, "review", "author"))
+}
+
+func TestResolveAlert(t *testing.T) {
+	ctx, store := notifytest.WithState(notifytest.NewContext(nil, false), t)
+	ctx = context.WithValue(ctx, "resolution", "PR #123 was merged")
+	key := state.AlertKey("owner", "repo", "123", "pager")
+	store.SetAlert(key, state.Alert{DedupKey: "gong:owner/repo#123"})
+
+	var resolved []string
+	resolveAlert := func(ctx context.Context, alert state.Alert, resolution string) error {
+		resolved = append(resolved, alert.DedupKey+": "+resolution)
+		if len(resolved) == 1 {
+			return errors.New("service unavailable")
+		}
+		return nil
+	}
+
+	// A failure keeps the alert to retry, and once resolved it is forgotten
+	ResolveAlert(ctx, "pager", resolveAlert)
+	_, stillOpen := store.Alert(key)
+	assert.True(t, stillOpen)
+	ResolveAlert(ctx, "pager", resolveAlert)
+	ResolveAlert(ctx, "pager", resolveAlert)
+
+	assert.Equal(t, []string{"gong:owner/repo#123: PR #123 was merged", "gong:owner/repo#123: PR #123 was merged"}, resolved)
+	_, stillOpen = store.Alert(key)
+	assert.False(t, stillOpen)
+}
+
+func TestPostJSON(t *testing.T) {
+	standIn := notifytest.NewStandIn[map[string]string](t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Remaining", "4")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+
+	resp, err := PostJSON(context.Background(), "chat webhook", standIn.URL+"/hook", map[string]string{"text": "hello"},
+		http.Header{"Authorization": {"Bearer secret"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "4", resp.Header.Get("X-Remaining"))
+	assert.Equal(t, `{"ok":true}`, string(resp.Body))
+	if requests := standIn.Requests(); assert.Len(t, requests, 1) {
+		assert.Equal(t, http.MethodPost, requests[0].Method)
+		assert.Equal(t, "/hook", requests[0].URI)
+		assert.Equal(t, "application/json", requests[0].Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", requests[0].Header.Get("Authorization"))
+		assert.Equal(t, map[string]string{"text": "hello"}, requests[0].Body)
+	}
+}
+
+func TestPostJSONError(t *testing.T) {
+	standIn := notifytest.NewStandIn[map[string]string](t, notifytest.Answer(http.StatusForbidden, "invalid token\n"))
+
+	_, err := PostJSON(context.Background(), "chat webhook", standIn.URL, map[string]string{"text": "hello"}, nil)
+
+	assert.EqualError(t, err, "chat webhook returned 403: invalid token")
+	var statusErr *StatusError
+	if assert.ErrorAs(t, err, &statusErr) {
+		assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	}
+}
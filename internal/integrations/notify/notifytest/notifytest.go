@@ -0,0 +1,110 @@
+package notifytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+)
+
+// NewContext returns the context of a run notifying about owner/repo#123, labeled "bug"
+func NewContext(pingRequests []ping.PingRequest, isDryRun bool) context.Context {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "pingRequests", pingRequests)
+	ctx = context.WithValue(ctx, "repoOwner", "owner")
+	ctx = context.WithValue(ctx, "repoName", "repo")
+	ctx = context.WithValue(ctx, "pr", "123")
+	ctx = context.WithValue(ctx, "prURL", "https://github.com/owner/repo/pull/123")
+	ctx = context.WithValue(ctx, "labels", []string{"bug"})
+	ctx = context.WithValue(ctx, "dry-run", isDryRun)
+	return ctx
+}
+
+// WithState adds an empty state, stored in a temporary directory, to the context of a run
+func WithState(ctx context.Context, t testing.TB) (context.Context, *state.Store) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return context.WithValue(ctx, "state", store), store
+}
+
+// Request is a request received by a StandIn, with its JSON body decoded
+type Request[T any] struct {
+	Method string
+	URI    string // Path and query
+	Header http.Header
+	Body   T
+}
+
+// StandIn is a local stand-in of a service receiving JSON requests, which records them
+type StandIn[T any] struct {
+	URL string
+
+	mu       sync.Mutex
+	requests []Request[T]
+}
+
+// NewStandIn starts a stand-in answering every request with respond, or with a 200 when respond
+// is nil. Requests are recorded before they are answered.
+func NewStandIn[T any](t testing.TB, respond http.HandlerFunc) *StandIn[T] {
+	standIn := &StandIn[T]{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Error reading request: %v", err)
+		}
+
+		received := Request[T]{Method: r.Method, URI: r.RequestURI, Header: r.Header.Clone()}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &received.Body); err != nil {
+				t.Errorf("Invalid request body %s: %v", data, err)
+			}
+		}
+		standIn.mu.Lock()
+		standIn.requests = append(standIn.requests, received)
+		standIn.mu.Unlock()
+
+		if respond != nil {
+			r.Body = io.NopCloser(bytes.NewReader(data))
+			respond(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	standIn.URL = server.URL
+
+	return standIn
+}
+
+// Requests returns the requests received so far
+func (s *StandIn[T]) Requests() []Request[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request[T](nil), s.requests...)
+}
+
+// Bodies returns the bodies of the requests received so far
+func (s *StandIn[T]) Bodies() []T {
+	var bodies []T
+	for _, r := range s.Requests() {
+		bodies = append(bodies, r.Body)
+	}
+	return bodies
+}
+
+// Answer returns a handler answering every request with status and body
+func Answer(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
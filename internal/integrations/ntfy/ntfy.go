@@ -0,0 +1,150 @@
+package ntfy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// DefaultTitle is the default template of the notification title
+const DefaultTitle = `{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}{{ if .PRTitle }}: {{.PRTitle}}{{ end }}`
+
+// DefaultTemplate is the default template used for ntfy notifications
+const DefaultTemplate = `Waiting for review{{ if .Mentions }} by {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template used for ntfy notifications nudging the PR author
+const DefaultAuthorTemplate = `Waiting on {{ range .Mentions }}{{ mention . }}{{ end }}: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}`
+
+// DefaultPriority is the priority of notifications, in ntfy's 1 (min) to 5 (max) scale
+const DefaultPriority = 3
+
+// priorities maps ntfy's priority names to their level
+var priorities = map[string]int{"min": 1, "low": 2, "default": 3, "high": 4, "max": 5, "urgent": 5}
+
+// notification is a message published as JSON to an ntfy server
+type notification struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Icon     string   `json:"icon,omitempty"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "ntfy")
+	if !ok {
+		return
+	}
+
+	topicURL := n.Param("topic", "ntfy-topic")
+	if topicURL == "" {
+		log.Error().Msg("No ntfy topic URL found in configuration. Skipping ntfy notifications.")
+		return
+	}
+	serverURL, topic, err := splitTopicURL(topicURL)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid ntfy topic URL. Skipping ntfy notifications.")
+		return
+	}
+
+	// Tapping the notification opens the pull request
+	msg := notification{
+		Topic:    topic,
+		Priority: DefaultPriority,
+		Tags:     splitList(n.Params["tags"]),
+		Click:    n.PRURL,
+		Icon:     n.Params["icon"],
+	}
+	if priority := n.Params["priority"]; priority != "" {
+		msg.Priority = parsePriority(priority)
+	}
+
+	data := n.TemplateData()
+	if msg.Title, err = format.FormatTemplate(n.Template(ctx, "title", DefaultTitle), data, mention); err != nil {
+		log.Error().Err(err).Msg("Error formatting ntfy title with template")
+		return
+	}
+	if msg.Message, err = format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention); err != nil {
+		log.Error().Err(err).Msg("Error formatting ntfy message with template")
+		return
+	}
+
+	if n.DryRun {
+		n.LogDryRun("publish ntfy notification to "+topicURL, msg.Message)
+		return
+	}
+
+	if err := publish(ctx, serverURL, viper.GetString("ntfy-token"), msg); err != nil {
+		log.Error().Err(err).Msg("Error publishing ntfy notification")
+	}
+}
+
+// mention names a reviewer. Push notifications cannot mention anybody.
+func mention(m format.Mention) string {
+	return m.Name
+}
+
+// splitTopicURL splits a topic URL, such as https://ntfy.sh/reviews, into the server URL and the topic
+func splitTopicURL(topicURL string) (string, string, error) {
+	u, err := url.Parse(topicURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", "", fmt.Errorf("%q is not an absolute URL", topicURL)
+	}
+
+	dir, topic := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if topic == "" {
+		return "", "", fmt.Errorf("%q has no topic", topicURL)
+	}
+	u.Path = strings.TrimSuffix(dir, "/")
+	return u.String(), topic, nil
+}
+
+// parsePriority reads a priority given by level (1 to 5) or name (min, low, default, high, max or urgent)
+func parsePriority(priority string) int {
+	if level, ok := priorities[strings.ToLower(priority)]; ok {
+		return level
+	}
+	if level, err := strconv.Atoi(priority); err == nil && level >= 1 && level <= 5 {
+		return level
+	}
+	log.Warn().Msgf("Invalid ntfy priority %q, using the default priority", priority)
+	return DefaultPriority
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func publish(ctx context.Context, serverURL, token string, n notification) error {
+	log.Debug().Msgf("Publishing ntfy notification to topic %s", n.Topic)
+
+	// Topics protected by access control need an access token
+	var header http.Header
+	if token != "" {
+		header = http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	_, err := notify.PostJSON(ctx, "ntfy server", serverURL, n, header)
+	return err
+}
@@ -0,0 +1,157 @@
+package ntfy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newNtfyStandIn starts a stand-in of an ntfy server. Topics under /protected need the token "secret".
+func newNtfyStandIn(t *testing.T) *notifytest.StandIn[notification] {
+	return notifytest.NewStandIn[notification](t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if strings.HasPrefix(r.URL.Path, "/protected") && r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":40301,"http":403,"error":"forbidden"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"abc","event":"message"}`))
+	})
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name         string
+		params       map[string]string
+		globalTopic  string
+		token        string
+		isDryRun     bool
+		expectPosted bool
+		expected     notification
+	}{
+		{
+			name:         "Default notification",
+			params:       map[string]string{},
+			globalTopic:  "stand-in/reviews",
+			expectPosted: true,
+			expected: notification{
+				Topic:    "reviews",
+				Title:    "owner/repo#123: Fix the build",
+				Message:  "Waiting for review by alice, bob",
+				Priority: DefaultPriority,
+			},
+		},
+		{
+			name: "Topic, priority, tags and templates from parameters",
+			params: map[string]string{
+				"topic":    "stand-in/protected/urgent-reviews/",
+				"priority": "urgent",
+				"tags":     "rotating_light, security,",
+				"title":    "Review #{{.PRNumber}}",
+				"template": "{{ len .Mentions }} reviewers",
+			},
+			token:        "secret",
+			expectPosted: true,
+			expected: notification{
+				Topic:    "urgent-reviews",
+				Title:    "Review #123",
+				Message:  "2 reviewers",
+				Priority: 5,
+				Tags:     []string{"rotating_light", "security"},
+			},
+		},
+		{
+			name:        "Dry run",
+			params:      map[string]string{},
+			globalTopic: "stand-in/reviews",
+			isDryRun:    true,
+		},
+		{
+			name:   "No topic",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newNtfyStandIn(t)
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("ntfy-topic", strings.Replace(tc.globalTopic, "stand-in", standIn.URL, 1))
+			viper.Set("ntfy-token", tc.token)
+			if topic, ok := tc.params["topic"]; ok {
+				tc.params["topic"] = strings.Replace(topic, "stand-in", standIn.URL, 1)
+			}
+
+			integrations := []ping.Integration{{Type: "ntfy", Parameters: tc.params}}
+			pingRequests := []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: time.Now(), PRTitle: "Fix the build"}, ShouldPing: true, ChatID: "U0ALICE", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: time.Now(), PRTitle: "Fix the build"}, ShouldPing: true, Integrations: integrations},
+			}
+
+			Run(notifytest.NewContext(pingRequests, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			if notifications := standIn.Bodies(); assert.Len(t, notifications, 1) {
+				tc.expected.Click = "https://github.com/owner/repo/pull/123"
+				assert.Equal(t, tc.expected, notifications[0])
+			}
+		})
+	}
+}
+
+func TestSplitTopicURL(t *testing.T) {
+	testCases := []struct {
+		topicURL     string
+		expectServer string
+		expectTopic  string
+		expectErr    bool
+	}{
+		{topicURL: "https://ntfy.sh/reviews", expectServer: "https://ntfy.sh", expectTopic: "reviews"},
+		{topicURL: "https://example.com/ntfy/reviews/", expectServer: "https://example.com/ntfy", expectTopic: "reviews"},
+		{topicURL: "https://ntfy.sh/", expectErr: true},
+		{topicURL: "reviews", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.topicURL, func(t *testing.T) {
+			server, topic, err := splitTopicURL(tc.topicURL)
+
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectServer, server)
+			assert.Equal(t, tc.expectTopic, topic)
+		})
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	assert.Equal(t, 1, parsePriority("min"))
+	assert.Equal(t, 4, parsePriority("High"))
+	assert.Equal(t, 2, parsePriority("2"))
+	assert.Equal(t, DefaultPriority, parsePriority("9"))
+	assert.Equal(t, DefaultPriority, parsePriority("loud"))
+}
+
+func TestPublishError(t *testing.T) {
+	standIn := newNtfyStandIn(t)
+
+	err := publish(context.Background(), standIn.URL+"/protected", "", notification{Topic: "reviews", Message: "hello"})
+
+	assert.EqualError(t, err, `ntfy server returned 403: {"code":40301,"http":403,"error":"forbidden"}`)
+}
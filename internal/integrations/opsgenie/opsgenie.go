@@ -0,0 +1,220 @@
+package opsgenie
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// DefaultMessage is the default template of the alert message
+const DefaultMessage = `Review needed on {{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}{{ if .PRTitle }}: {{.PRTitle}}{{ end }}`
+
+// DefaultDescription is the default template of the alert description
+const DefaultDescription = `PR #{{.PRNumber}} in {{.RepoOwner}}/{{.RepoName}} is waiting for review: {{.PRURL}}
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultPriority is the priority of the alerts
+const DefaultPriority = "P3"
+
+// DefaultAPIURL is the root of the Opsgenie API. Accounts in the EU region use https://api.eu.opsgenie.com.
+const DefaultAPIURL = "https://api.opsgenie.com"
+
+// service names the alerts of this integration in the state
+const service = "opsgenie"
+
+// Longest message and description Opsgenie accepts
+const (
+	maxMessageLength     = 130
+	maxDescriptionLength = 15000
+)
+
+// priorities are the priorities Opsgenie accepts
+var priorities = map[string]bool{"P1": true, "P2": true, "P3": true, "P4": true, "P5": true}
+
+// alert is the request creating an alert
+type alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Responders  []responder       `json:"responders,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+type responder struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// closeRequest is the request closing an alert
+type closeRequest struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Run creates an alert for the pull request. Every run creates it again, which Opsgenie counts as
+// an occurrence of the same alert since its alias only depends on the pull request, until Resolve
+// closes it.
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "opsgenie")
+	if !ok {
+		return
+	}
+
+	// Alerts escalate reviews that are late, not pull requests waiting on their author
+	if n.AuthorNudge {
+		log.Debug().Msg("Opsgenie alerts are only created for reviewers, skipping author nudge")
+		return
+	}
+
+	// The API key is only read from the configuration, so that it can be kept in the environment
+	apiKey := viper.GetString("opsgenie-api-key")
+	if apiKey == "" {
+		log.Error().Msg("No Opsgenie API key found in configuration. Skipping Opsgenie alerts.")
+		return
+	}
+
+	a := alert{Priority: DefaultPriority, Source: "gong", Tags: splitList(n.Params["tags"])}
+	if priority := n.Params["priority"]; priority != "" {
+		if !priorities[strings.ToUpper(priority)] {
+			log.Warn().Msgf("Invalid Opsgenie priority %q, using %s", priority, DefaultPriority)
+		} else {
+			a.Priority = strings.ToUpper(priority)
+		}
+	}
+	for _, team := range splitList(n.Params["responders"]) {
+		a.Responders = append(a.Responders, responder{Type: "team", Name: team})
+	}
+
+	data := n.TemplateData()
+	var err error
+	if a.Message, err = format.FormatTemplate(n.Template(ctx, "message", DefaultMessage), data, mention); err != nil {
+		log.Error().Err(err).Msg("Error formatting Opsgenie message with template")
+		return
+	}
+	if a.Description, err = format.FormatTemplate(n.Template(ctx, "description", DefaultDescription), data, mention); err != nil {
+		log.Error().Err(err).Msg("Error formatting Opsgenie description with template")
+		return
+	}
+	a.Message = truncate(a.Message, maxMessageLength)
+	a.Description = truncate(a.Description, maxDescriptionLength)
+	a.Alias = Alias(n.RepoOwner, n.RepoName, n.PRNumber)
+	a.Entity = fmt.Sprintf("%s/%s", n.RepoOwner, n.RepoName)
+	a.Details = details(data)
+
+	if n.DryRun {
+		n.LogDryRun(fmt.Sprintf("create Opsgenie alert %s (%s)", a.Alias, a.Priority), a.Message)
+		return
+	}
+
+	if err := call(ctx, apiKey, "/v2/alerts", a); err != nil {
+		log.Error().Err(err).Msg("Error creating Opsgenie alert")
+		return
+	}
+
+	// Remember the alert so that a later run closes it
+	store, err := state.FromContext(ctx)
+	if err != nil {
+		log.Warn().Msgf("Could not open the state, Opsgenie alerts will not be closed automatically: %v", err)
+		return
+	}
+	key := state.AlertKey(n.RepoOwner, n.RepoName, n.PRNumber, service)
+	stored, found := store.Alert(key)
+	if !found {
+		stored.OpenedAt = time.Now()
+	}
+	stored.DedupKey, stored.UpdatedAt = a.Alias, time.Now()
+	store.SetAlert(key, stored)
+	if err := store.Save(); err != nil {
+		log.Error().Err(err).Msg("Error saving the Opsgenie alert to the state")
+	}
+}
+
+// Resolve closes the alert created for a pull request, once it was merged, closed or went back to
+// draft, or all of its reviews landed
+func Resolve(ctx context.Context) {
+	notify.ResolveAlert(ctx, service, func(ctx context.Context, alert state.Alert, resolution string) error {
+		apiKey := viper.GetString("opsgenie-api-key")
+		if apiKey == "" {
+			return fmt.Errorf("no Opsgenie API key found in configuration")
+		}
+		path := "/v2/alerts/" + url.PathEscape(alert.DedupKey) + "/close?identifierType=alias"
+		return call(ctx, apiKey, path, closeRequest{Source: "gong", Note: resolution})
+	})
+}
+
+// Alias returns the alias of the alert of a pull request, which Opsgenie deduplicates alerts by
+func Alias(owner, repo, prNumber string) string {
+	return "gong:" + state.PullRequestKey(owner, repo, prNumber)
+}
+
+// mention names a reviewer. Alerts notify the on-call responders, not the reviewers.
+func mention(m format.Mention) string {
+	return m.Name
+}
+
+// details lists the details of the pull request shown in the alert
+func details(data format.TemplateData) map[string]string {
+	d := map[string]string{
+		"pull_request": data.PRURL,
+		"reviewers":    strings.Join(data.ActiveReviewers, ", "),
+	}
+	if data.PRTitle != "" {
+		d["title"] = data.PRTitle
+	}
+	if data.PRAuthor != "" {
+		d["author"] = data.PRAuthor
+	}
+	if data.Age != "" {
+		d["waiting_for"] = data.Age
+	}
+	if len(data.Labels) > 0 {
+		d["labels"] = strings.Join(data.Labels, ", ")
+	}
+	return d
+}
+
+// truncate shortens text to at most max characters
+func truncate(text string, max int) string {
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	return text
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// call posts a request to the Alert API. Opsgenie processes requests asynchronously, so success
+// only means the request was accepted.
+func call(ctx context.Context, apiKey, path string, request interface{}) error {
+	log.Debug().Msgf("Calling Opsgenie %s", path)
+
+	apiURL := viper.GetString("opsgenie-api-url")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	_, err := notify.PostJSON(ctx, "opsgenie", strings.TrimSuffix(apiURL, "/")+path, request, http.Header{"Authorization": {"GenieKey " + apiKey}})
+	return err
+}
@@ -0,0 +1,189 @@
+package opsgenie
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newOpsgenieStandIn starts a stand-in of the Alert API, which knows the API key "secret"
+func newOpsgenieStandIn(t *testing.T) *notifytest.StandIn[map[string]interface{}] {
+	return notifytest.NewStandIn[map[string]interface{}](t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GenieKey secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Could not authenticate","took":0.0,"requestId":"1"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"result":"Request will be processed","took":0.1,"requestId":"2"}`))
+	})
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name         string
+		params       map[string]string
+		apiKey       string
+		isDryRun     bool
+		expectPosted bool
+		expected     map[string]interface{}
+	}{
+		{
+			name:         "Default alert",
+			params:       map[string]string{},
+			apiKey:       "secret",
+			expectPosted: true,
+			expected: map[string]interface{}{
+				"message":     "Review needed on owner/repo#123: Fix CVE",
+				"alias":       "gong:owner/repo#123",
+				"description": "PR #123 in owner/repo is waiting for review: https://github.com/owner/repo/pull/123\nReviewers: alice, bob",
+				"details": map[string]interface{}{
+					"pull_request": "https://github.com/owner/repo/pull/123",
+					"title":        "Fix CVE",
+					"author":       "dave",
+					"reviewers":    "alice, bob",
+					"waiting_for":  "just now",
+					"labels":       "bug",
+				},
+				"entity":   "owner/repo",
+				"source":   "gong",
+				"priority": DefaultPriority,
+			},
+		},
+		{
+			name:         "Priority, responders, tags and templates from parameters",
+			params:       map[string]string{"priority": "p1", "responders": "Security, Platform", "tags": "review,security", "message": "Late review", "description": "{{ .PRURL }}"},
+			apiKey:       "secret",
+			expectPosted: true,
+			expected: map[string]interface{}{
+				"message":     "Late review",
+				"alias":       "gong:owner/repo#123",
+				"description": "https://github.com/owner/repo/pull/123",
+				"responders": []interface{}{
+					map[string]interface{}{"type": "team", "name": "Security"},
+					map[string]interface{}{"type": "team", "name": "Platform"},
+				},
+				"tags":     []interface{}{"review", "security"},
+				"entity":   "owner/repo",
+				"source":   "gong",
+				"priority": "P1",
+			},
+		},
+		{
+			name:     "Dry run",
+			params:   map[string]string{},
+			apiKey:   "secret",
+			isDryRun: true,
+		},
+		{
+			name:   "No API key",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newOpsgenieStandIn(t)
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("opsgenie-api-url", standIn.URL)
+			viper.Set("opsgenie-api-key", tc.apiKey)
+
+			integrations := []ping.Integration{{Type: "opsgenie", Parameters: tc.params}}
+			pingRequests := []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: time.Now(), PRTitle: "Fix CVE", PRAuthor: "dave"}, ShouldPing: true, Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: time.Now(), PRTitle: "Fix CVE", PRAuthor: "dave"}, ShouldPing: true, Integrations: integrations},
+			}
+			ctx, store := notifytest.WithState(notifytest.NewContext(pingRequests, tc.isDryRun), t)
+
+			Run(ctx)
+
+			alert, stored := store.Alert(state.AlertKey("owner", "repo", "123", "opsgenie"))
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				assert.False(t, stored)
+				return
+			}
+			if requests := standIn.Requests(); assert.Len(t, requests, 1) {
+				assert.Equal(t, "/v2/alerts", requests[0].URI)
+				body := requests[0].Body
+				if _, ok := tc.expected["details"]; !ok {
+					delete(body, "details")
+				}
+				assert.Equal(t, tc.expected, body)
+			}
+			if assert.True(t, stored) {
+				assert.Equal(t, "gong:owner/repo#123", alert.DedupKey)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	standIn := newOpsgenieStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("opsgenie-api-url", standIn.URL)
+	viper.Set("opsgenie-api-key", "secret")
+
+	ctx, store := notifytest.WithState(notifytest.NewContext(nil, false), t)
+	ctx = context.WithValue(ctx, "resolution", "All reviews on PR #123 landed")
+	key := state.AlertKey("owner", "repo", "123", "opsgenie")
+	store.SetAlert(key, state.Alert{DedupKey: "gong:owner/repo#123"})
+
+	Resolve(ctx)
+	// Once closed, the alert is forgotten and not closed again
+	Resolve(ctx)
+
+	if requests := standIn.Requests(); assert.Len(t, requests, 1) {
+		assert.Equal(t, "/v2/alerts/gong:owner%2Frepo%23123/close?identifierType=alias", requests[0].URI)
+		assert.Equal(t, map[string]interface{}{"source": "gong", "note": "All reviews on PR #123 landed"}, requests[0].Body)
+	}
+	_, stillOpen := store.Alert(key)
+	assert.False(t, stillOpen)
+}
+
+func TestResolveDryRun(t *testing.T) {
+	standIn := newOpsgenieStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("opsgenie-api-url", standIn.URL)
+	viper.Set("opsgenie-api-key", "secret")
+
+	ctx, store := notifytest.WithState(notifytest.NewContext(nil, true), t)
+	key := state.AlertKey("owner", "repo", "123", "opsgenie")
+	store.SetAlert(key, state.Alert{DedupKey: "gong:owner/repo#123"})
+
+	Resolve(ctx)
+
+	assert.Empty(t, standIn.Requests())
+	_, stillOpen := store.Alert(key)
+	assert.True(t, stillOpen)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "héllo wo…", truncate("héllo world", 9))
+	assert.Len(t, []rune(truncate(strings.Repeat("a", 200), maxMessageLength)), maxMessageLength)
+}
+
+func TestCallError(t *testing.T) {
+	standIn := newOpsgenieStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("opsgenie-api-url", standIn.URL)
+
+	err := call(context.Background(), "wrong", "/v2/alerts", alert{Message: "hello"})
+
+	assert.EqualError(t, err, `opsgenie returned 401: {"message":"Could not authenticate","took":0.0,"requestId":"1"}`)
+}
@@ -0,0 +1,207 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// DefaultSummary is the default template of the alert summary
+const DefaultSummary = `Review needed on {{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}{{ if .PRTitle }}: {{.PRTitle}}{{ end }}{{ if .Mentions }} (waiting on {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}){{ end }}`
+
+// DefaultSeverity is the severity of the alerts
+const DefaultSeverity = "warning"
+
+// DefaultEventsURL is the endpoint of the PagerDuty Events API v2
+const DefaultEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Event actions of the Events API
+const (
+	ActionTrigger = "trigger"
+	ActionResolve = "resolve"
+)
+
+// service names the alerts of this integration in the state
+const service = "pagerduty"
+
+// maxSummaryLength is the longest summary PagerDuty accepts
+const maxSummaryLength = 1024
+
+// severities are the severities PagerDuty accepts
+var severities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// event is an event of the Events API v2
+type event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key"`
+	Payload     *payload `json:"payload,omitempty"`
+	Links       []link   `json:"links,omitempty"`
+	Client      string   `json:"client,omitempty"`
+	ClientURL   string   `json:"client_url,omitempty"`
+}
+
+type payload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type link struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Run triggers an alert for the pull request. Every run triggers the same alert again, since its
+// deduplication key only depends on the pull request, until Resolve resolves it.
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "pagerduty")
+	if !ok {
+		return
+	}
+
+	// Alerts escalate reviews that are late, not pull requests waiting on their author
+	if n.AuthorNudge {
+		log.Debug().Msg("PagerDuty alerts are only triggered for reviewers, skipping author nudge")
+		return
+	}
+
+	routingKey := n.Param("routing_key", "pagerduty-routing-key")
+	if routingKey == "" {
+		log.Error().Msg("No PagerDuty routing key found in configuration. Skipping PagerDuty alerts.")
+		return
+	}
+
+	p := payload{
+		Severity:  DefaultSeverity,
+		Source:    n.RepoOwner + "/" + n.RepoName,
+		Component: n.Params["component"],
+		Group:     n.Params["group"],
+		Class:     "review",
+	}
+	if severity := n.Params["severity"]; severity != "" {
+		if !severities[strings.ToLower(severity)] {
+			log.Warn().Msgf("Invalid PagerDuty severity %q, using %s", severity, DefaultSeverity)
+		} else {
+			p.Severity = strings.ToLower(severity)
+		}
+	}
+
+	data := n.TemplateData()
+	summary, err := format.FormatTemplate(n.Template(ctx, "summary", DefaultSummary), data, mention)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting PagerDuty summary with template")
+		return
+	}
+	if runes := []rune(summary); len(runes) > maxSummaryLength {
+		summary = string(runes[:maxSummaryLength-1]) + "…"
+	}
+	p.Summary = summary
+	p.CustomDetails = customDetails(data)
+
+	e := event{
+		RoutingKey:  routingKey,
+		EventAction: ActionTrigger,
+		DedupKey:    DedupKey(n.RepoOwner, n.RepoName, n.PRNumber),
+		Payload:     &p,
+		Links:       []link{{Href: n.PRURL, Text: fmt.Sprintf("%s/%s#%s", n.RepoOwner, n.RepoName, n.PRNumber)}},
+		Client:      "gong",
+		ClientURL:   n.PRURL,
+	}
+
+	if n.DryRun {
+		n.LogDryRun(fmt.Sprintf("trigger PagerDuty alert %s (%s)", e.DedupKey, p.Severity), summary)
+		return
+	}
+
+	if err := sendEvent(ctx, eventsURL(), e); err != nil {
+		log.Error().Err(err).Msg("Error triggering PagerDuty alert")
+		return
+	}
+
+	// Remember the alert, and where it was sent, so that a later run resolves it
+	store, err := state.FromContext(ctx)
+	if err != nil {
+		log.Warn().Msgf("Could not open the state, PagerDuty alerts will not be resolved automatically: %v", err)
+		return
+	}
+	key := state.AlertKey(n.RepoOwner, n.RepoName, n.PRNumber, service)
+	alert, found := store.Alert(key)
+	if !found {
+		alert.OpenedAt = time.Now()
+	}
+	alert.DedupKey, alert.RoutingKey, alert.UpdatedAt = e.DedupKey, routingKey, time.Now()
+	store.SetAlert(key, alert)
+	if err := store.Save(); err != nil {
+		log.Error().Err(err).Msg("Error saving the PagerDuty alert to the state")
+	}
+}
+
+// Resolve resolves the alert triggered for a pull request, once it was merged, closed or went back
+// to draft, or all of its reviews landed
+func Resolve(ctx context.Context) {
+	notify.ResolveAlert(ctx, service, func(ctx context.Context, alert state.Alert, resolution string) error {
+		// The alert is resolved with the routing key it was triggered with
+		routingKey := alert.RoutingKey
+		if routingKey == "" {
+			routingKey = viper.GetString("pagerduty-routing-key")
+		}
+		return sendEvent(ctx, eventsURL(), event{RoutingKey: routingKey, EventAction: ActionResolve, DedupKey: alert.DedupKey})
+	})
+}
+
+// DedupKey returns the deduplication key of the alert of a pull request
+func DedupKey(owner, repo, prNumber string) string {
+	return "gong:" + state.PullRequestKey(owner, repo, prNumber)
+}
+
+// mention names a reviewer. Alerts notify the on-call responders, not the reviewers.
+func mention(m format.Mention) string {
+	return m.Name
+}
+
+// customDetails lists the details of the pull request shown in the alert
+func customDetails(data format.TemplateData) map[string]interface{} {
+	details := map[string]interface{}{
+		"pull_request": data.PRURL,
+		"reviewers":    data.ActiveReviewers,
+	}
+	if data.PRTitle != "" {
+		details["title"] = data.PRTitle
+	}
+	if data.PRAuthor != "" {
+		details["author"] = data.PRAuthor
+	}
+	if data.Age != "" {
+		details["waiting_for"] = data.Age
+	}
+	if len(data.Labels) > 0 {
+		details["labels"] = data.Labels
+	}
+	return details
+}
+
+func eventsURL() string {
+	if url := viper.GetString("pagerduty-events-url"); url != "" {
+		return url
+	}
+	return DefaultEventsURL
+}
+
+func sendEvent(ctx context.Context, url string, e event) error {
+	log.Debug().Msgf("Sending PagerDuty %s event for %s", e.EventAction, e.DedupKey)
+
+	_, err := notify.PostJSON(ctx, "pagerduty", url, e, nil)
+	return err
+}
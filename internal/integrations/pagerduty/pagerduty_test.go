@@ -0,0 +1,201 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPagerDutyStandIn starts a stand-in of the Events API answering every event with status
+func newPagerDutyStandIn(t *testing.T, status int) *notifytest.StandIn[event] {
+	return notifytest.NewStandIn[event](t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		w.WriteHeader(status)
+		if status == http.StatusAccepted {
+			_, _ = w.Write([]byte(`{"status":"success","message":"Event processed"}`))
+		} else {
+			_, _ = w.Write([]byte(`{"status":"invalid event","message":"Event object is invalid","errors":["Length of 'routing_key' is incorrect (should be 32 characters)"]}`))
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name             string
+		params           map[string]string
+		globalKey        string
+		isDryRun         bool
+		expectPosted     bool
+		expectRoutingKey string
+		expectSummary    string
+		expectSeverity   string
+		expectComponent  string
+	}{
+		{
+			name:             "Default alert",
+			params:           map[string]string{},
+			globalKey:        "R0UTING",
+			expectPosted:     true,
+			expectRoutingKey: "R0UTING",
+			expectSummary:    "Review needed on owner/repo#123: Fix CVE (waiting on alice, org/security (team))",
+			expectSeverity:   DefaultSeverity,
+		},
+		{
+			name:             "Routing key, severity and summary from parameters",
+			params:           map[string]string{"routing_key": "SECUR1TY", "severity": "Critical", "component": "api", "summary": "{{ len .Mentions }} reviews late on #{{ .PRNumber }}"},
+			globalKey:        "R0UTING",
+			expectPosted:     true,
+			expectRoutingKey: "SECUR1TY",
+			expectSummary:    "2 reviews late on #123",
+			expectSeverity:   "critical",
+			expectComponent:  "api",
+		},
+		{
+			name:      "Dry run",
+			params:    map[string]string{},
+			globalKey: "R0UTING",
+			isDryRun:  true,
+		},
+		{
+			name:   "No routing key",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newPagerDutyStandIn(t, http.StatusAccepted)
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("pagerduty-events-url", standIn.URL)
+			viper.Set("pagerduty-routing-key", tc.globalKey)
+
+			integrations := []ping.Integration{{Type: "pagerduty", Parameters: tc.params}}
+			pingRequests := []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: time.Now(), PRTitle: "Fix CVE", PRAuthor: "dave"}, ShouldPing: true, Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "org/security", IsTeam: true, On: time.Now(), PRTitle: "Fix CVE", PRAuthor: "dave"}, ShouldPing: true, Integrations: integrations},
+			}
+			ctx, store := notifytest.WithState(notifytest.NewContext(pingRequests, tc.isDryRun), t)
+
+			Run(ctx)
+
+			alert, stored := store.Alert(state.AlertKey("owner", "repo", "123", "pagerduty"))
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				assert.False(t, stored)
+				return
+			}
+			if events := standIn.Bodies(); assert.Len(t, events, 1) {
+				e := events[0]
+				assert.Equal(t, tc.expectRoutingKey, e.RoutingKey)
+				assert.Equal(t, ActionTrigger, e.EventAction)
+				assert.Equal(t, "gong:owner/repo#123", e.DedupKey)
+				assert.Equal(t, []link{{Href: "https://github.com/owner/repo/pull/123", Text: "owner/repo#123"}}, e.Links)
+				if assert.NotNil(t, e.Payload) {
+					assert.Equal(t, tc.expectSummary, e.Payload.Summary)
+					assert.Equal(t, tc.expectSeverity, e.Payload.Severity)
+					assert.Equal(t, tc.expectComponent, e.Payload.Component)
+					assert.Equal(t, "owner/repo", e.Payload.Source)
+					assert.Equal(t, "dave", e.Payload.CustomDetails["author"])
+					assert.Equal(t, []interface{}{"alice", "org/security (team)"}, e.Payload.CustomDetails["reviewers"])
+				}
+			}
+			if assert.True(t, stored) {
+				assert.Equal(t, "gong:owner/repo#123", alert.DedupKey)
+				assert.Equal(t, tc.expectRoutingKey, alert.RoutingKey)
+			}
+		})
+	}
+}
+
+func TestRunSkipsAuthorNudge(t *testing.T) {
+	standIn := newPagerDutyStandIn(t, http.StatusAccepted)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("pagerduty-events-url", standIn.URL)
+	viper.Set("pagerduty-routing-key", "R0UTING")
+
+	nudge := ping.PingRequest{
+		Req:          githubclient.ReviewRequest{From: "dave", On: time.Now()},
+		ShouldPing:   true,
+		AuthorNudge:  true,
+		Integrations: []ping.Integration{{Type: "pagerduty"}},
+	}
+	ctx, _ := notifytest.WithState(notifytest.NewContext([]ping.PingRequest{nudge}, false), t)
+
+	Run(ctx)
+
+	assert.Empty(t, standIn.Requests())
+}
+
+func TestResolve(t *testing.T) {
+	testCases := []struct {
+		name          string
+		alert         *state.Alert
+		isDryRun      bool
+		status        int
+		expectEvent   bool
+		expectDeleted bool
+	}{
+		{
+			name:          "Open alert",
+			alert:         &state.Alert{DedupKey: "gong:owner/repo#123", RoutingKey: "SECUR1TY"},
+			status:        http.StatusAccepted,
+			expectEvent:   true,
+			expectDeleted: true,
+		},
+		{
+			name:        "Failure keeps the alert to retry",
+			alert:       &state.Alert{DedupKey: "gong:owner/repo#123", RoutingKey: "SECUR1TY"},
+			status:      http.StatusBadRequest,
+			expectEvent: true,
+		},
+		{
+			name:     "Dry run",
+			alert:    &state.Alert{DedupKey: "gong:owner/repo#123", RoutingKey: "SECUR1TY"},
+			isDryRun: true,
+			status:   http.StatusAccepted,
+		},
+		{
+			name:   "No alert",
+			status: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newPagerDutyStandIn(t, tc.status)
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("pagerduty-events-url", standIn.URL)
+			viper.Set("pagerduty-routing-key", "R0UTING")
+
+			ctx, store := notifytest.WithState(notifytest.NewContext(nil, tc.isDryRun), t)
+			ctx = context.WithValue(ctx, "resolution", "PR #123 was merged")
+			key := state.AlertKey("owner", "repo", "123", "pagerduty")
+			if tc.alert != nil {
+				store.SetAlert(key, *tc.alert)
+			}
+
+			Resolve(ctx)
+
+			if !tc.expectEvent {
+				assert.Empty(t, standIn.Requests())
+			} else if events := standIn.Bodies(); assert.Len(t, events, 1) {
+				// The alert is resolved with the routing key it was triggered with
+				assert.Equal(t, event{RoutingKey: "SECUR1TY", EventAction: ActionResolve, DedupKey: "gong:owner/repo#123"}, events[0])
+			}
+			_, stillOpen := store.Alert(key)
+			assert.Equal(t, tc.alert != nil && !tc.expectDeleted, stillOpen)
+		})
+	}
+}
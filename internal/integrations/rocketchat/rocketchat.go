@@ -0,0 +1,157 @@
+package rocketchat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTemplate is the default template used for Rocket.Chat messages
+const DefaultTemplate = `PR #{{.PRNumber}} is waiting for review: [{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}]({{.PRURL}})
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template used for Rocket.Chat messages nudging the PR author
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} is waiting on its author: [{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}]({{.PRURL}})
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
+// Attachment colors of review requests and author nudges
+const (
+	ColorReview = "#1D74F5"
+	ColorAuthor = "#FFD21F"
+)
+
+// payload is the message accepted by Rocket.Chat incoming webhooks
+type payload struct {
+	Text        string       `json:"text"`
+	Channel     string       `json:"channel,omitempty"`
+	Alias       string       `json:"alias,omitempty"`
+	Avatar      string       `json:"avatar,omitempty"`
+	Emoji       string       `json:"emoji,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+// attachment is a Rocket.Chat message attachment holding the details of the pull request
+type attachment struct {
+	Title     string  `json:"title,omitempty"`
+	TitleLink string  `json:"title_link,omitempty"`
+	Color     string  `json:"color,omitempty"`
+	Fields    []field `json:"fields,omitempty"`
+}
+
+type field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// response is the answer of Rocket.Chat, which reports some errors with a success status
+type response struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "rocketchat")
+	if !ok {
+		return
+	}
+
+	webhookURL := n.Param("webhook", "rocketchat-webhook")
+	if webhookURL == "" {
+		log.Error().Msg("No Rocket.Chat webhook URL found in configuration. Skipping Rocket.Chat notifications.")
+		return
+	}
+
+	data := n.TemplateData()
+	text, err := format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Rocket.Chat message with template")
+		return
+	}
+
+	// Rocket.Chat names the poster an alias, and its icon an avatar URL or an emoji
+	message := payload{
+		Text:        text,
+		Channel:     channel(n.Params["channel"]),
+		Alias:       n.Params["alias"],
+		Avatar:      n.Params["avatar"],
+		Emoji:       n.Params["emoji"],
+		Attachments: []attachment{newAttachment(data)},
+	}
+
+	if n.DryRun {
+		n.LogDryRun("send Rocket.Chat notification", text)
+		return
+	}
+
+	if err := sendMessage(ctx, webhookURL, message); err != nil {
+		log.Error().Err(err).Msg("Error sending Rocket.Chat notification")
+	}
+}
+
+// mention formats a reviewer for Rocket.Chat, whose chat ID is their username or, for teams, the
+// name of a Rocket.Chat team. Reviewers missing from the identity directory are only named.
+func mention(m format.Mention) string {
+	if m.ChatID == "" {
+		return m.Name
+	}
+	return "@" + strings.TrimPrefix(m.ChatID, "@")
+}
+
+// channel normalizes a channel override: Rocket.Chat expects "#channel", or "@username" for a
+// direct message
+func channel(name string) string {
+	if name == "" || strings.HasPrefix(name, "#") || strings.HasPrefix(name, "@") {
+		return name
+	}
+	return "#" + name
+}
+
+// newAttachment lists the pull request title, author, waiting time and labels
+func newAttachment(data format.TemplateData) attachment {
+	a := attachment{
+		Title:     fmt.Sprintf("%s/%s#%s", data.RepoOwner, data.RepoName, data.PRNumber),
+		TitleLink: data.PRURL,
+		Color:     ColorReview,
+	}
+	if data.Author != "" {
+		a.Color = ColorAuthor
+	}
+	if data.PRTitle != "" {
+		a.Title += " " + data.PRTitle
+	}
+
+	if data.PRAuthor != "" {
+		a.Fields = append(a.Fields, field{Title: "Author", Value: data.PRAuthor, Short: true})
+	}
+	if data.Age != "" {
+		a.Fields = append(a.Fields, field{Title: "Waiting for", Value: data.Age, Short: true})
+	}
+	if len(data.Labels) > 0 {
+		a.Fields = append(a.Fields, field{Title: "Labels", Value: strings.Join(data.Labels, ", "), Short: true})
+	}
+
+	return a
+}
+
+// sendMessage posts a message, checking the answer of Rocket.Chat for errors reported with a
+// success status
+func sendMessage(ctx context.Context, webhookURL string, message payload) error {
+	log.Debug().Msg("Sending Rocket.Chat notification via webhook")
+
+	resp, err := notify.PostJSON(ctx, "rocket.chat webhook", webhookURL, message, nil)
+	if err != nil {
+		return err
+	}
+
+	var result response
+	if err := json.Unmarshal(resp.Body, &result); err == nil && !result.Success {
+		return fmt.Errorf("rocket.chat webhook failed: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package rocketchat
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		params        map[string]string
+		globalWebhook bool
+		isDryRun      bool
+		expectPosted  bool
+		expected      payload
+	}{
+		{
+			name:          "Default message",
+			params:        map[string]string{},
+			globalWebhook: true,
+			expectPosted:  true,
+			expected: payload{
+				Text: "PR #123 is waiting for review: [owner/repo#123](https://github.com/owner/repo/pull/123)\nReviewers: @alice, bob",
+			},
+		},
+		{
+			name: "Channel, alias and avatar overrides",
+			params: map[string]string{
+				"webhook":  "stand-in",
+				"channel":  "reviews",
+				"alias":    "gong",
+				"avatar":   "https://example.com/gong.png",
+				"template": "Please review PR #{{ .PRNumber }}",
+			},
+			expectPosted: true,
+			expected: payload{
+				Text:    "Please review PR #123",
+				Channel: "#reviews",
+				Alias:   "gong",
+				Avatar:  "https://example.com/gong.png",
+			},
+		},
+		{
+			name:          "Dry run",
+			params:        map[string]string{},
+			globalWebhook: true,
+			isDryRun:      true,
+		},
+		{
+			name:   "No webhook",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := notifytest.NewStandIn[payload](t, notifytest.Answer(http.StatusOK, `{"success": true}`))
+
+			viper.Reset()
+			defer viper.Reset()
+			if tc.globalWebhook {
+				viper.Set("rocketchat-webhook", standIn.URL)
+			}
+			params := make(map[string]string)
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			if params["webhook"] == "stand-in" {
+				params["webhook"] = standIn.URL
+			}
+
+			integrations := []ping.Integration{{Type: "rocketchat", Parameters: params}}
+			ctx := context.WithValue(notifytest.NewContext(nil, tc.isDryRun), "labels", []string{"bug", "backend"})
+			Run(context.WithValue(ctx, "pingRequests", []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-3 * time.Hour), PRTitle: "Add Rocket.Chat", PRAuthor: "dave"}, ShouldPing: true, ChatID: "alice", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+			}))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			messages := standIn.Bodies()
+			if !assert.Len(t, messages, 1) {
+				return
+			}
+
+			tc.expected.Attachments = []attachment{{
+				Title:     "owner/repo#123 Add Rocket.Chat",
+				TitleLink: "https://github.com/owner/repo/pull/123",
+				Color:     ColorReview,
+				Fields: []field{
+					{Title: "Author", Value: "dave", Short: true},
+					{Title: "Waiting for", Value: "3h", Short: true},
+					{Title: "Labels", Value: "bug, backend", Short: true},
+				},
+			}}
+			assert.Equal(t, tc.expected, messages[0])
+		})
+	}
+}
+
+func TestChannel(t *testing.T) {
+	assert.Equal(t, "", channel(""))
+	assert.Equal(t, "#reviews", channel("reviews"))
+	assert.Equal(t, "#reviews", channel("#reviews"))
+	assert.Equal(t, "@alice", channel("@alice"))
+}
+
+func TestSendMessageError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  int
+		answer  string
+		message string
+	}{
+		{name: "Error status", status: http.StatusBadRequest, answer: `{"success": false}`, message: "400"},
+		{name: "Unsuccessful answer", status: http.StatusOK, answer: `{"success": false, "error": "invalid-channel"}`, message: "invalid-channel"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := notifytest.NewStandIn[payload](t, notifytest.Answer(tc.status, tc.answer))
+
+			err := sendMessage(context.Background(), standIn.URL, payload{Text: "hello"})
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.message)
+		})
+	}
+}
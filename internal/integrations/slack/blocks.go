@@ -0,0 +1,149 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/slack-go/slack"
+)
+
+// maxBlocks is the maximum number of blocks Slack accepts in a message
+const maxBlocks = 50
+
+// rendered is a message ready to be sent: the text is used in notifications and thread replies,
+// the blocks are what the message displays
+type rendered struct {
+	Text   string
+	Blocks []slack.Block
+}
+
+// defaultBlocks lays a rendered message out with Block Kit: the title, the message, the author,
+// how long the review has been waiting, the labels of the pull request, a link to it and actions
+func defaultBlocks(title, message string, data format.TemplateData, actions ...slack.BlockElement) []slack.Block {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, title, true, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, message, false, false), nil, nil, slack.SectionBlockOptionBlockID("message")),
+	}
+
+	var fields []*slack.TextBlockObject
+	if data.PRTitle != "" {
+		fields = append(fields, field("Pull request", fmt.Sprintf("<%s|%s>", data.PRURL, escape(data.PRTitle))))
+	}
+	if data.PRAuthor != "" {
+		fields = append(fields, field("Author", escape(data.PRAuthor)))
+	}
+	if data.Age != "" {
+		fields = append(fields, field("Waiting for", data.Age))
+	}
+	if len(data.Labels) > 0 {
+		labels := make([]string, len(data.Labels))
+		for i, label := range data.Labels {
+			labels[i] = "`" + escape(label) + "`"
+		}
+		fields = append(fields, field("Labels", strings.Join(labels, " ")))
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, slack.NewSectionBlock(nil, fields, nil))
+	}
+
+	return append(blocks, prButton(data.PRURL, actions...), footer())
+}
+
+// resolvedBlocks lays out a message struck through once its pull request no longer needs reviews
+func resolvedBlocks(prURL, text, resolution string) []slack.Block {
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, strikethrough(text), false, false), nil, nil, slack.SectionBlockOptionBlockID("message")),
+		slack.NewContextBlock("resolution", slack.NewTextBlockObject(slack.MarkdownType, "✅ "+resolution, false, false)),
+		prButton(prURL),
+	}
+}
+
+// renderBlocks renders a blocks_template into Block Kit blocks. The template must render to a
+// JSON array of blocks, or to an object with a "blocks" array like the Block Kit Builder exports.
+// The rendered JSON is validated so that a broken template is reported instead of sent.
+func renderBlocks(templateStr string, data format.TemplateData) ([]slack.Block, error) {
+	tmpl, err := template.New("slack-blocks").Funcs(template.FuncMap{
+		"mention": mention,
+		"json":    jsonString,
+	}).Parse(templateStr)
+	if err != nil {
+		return nil, fmt.Errorf("blocks template parsing error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("blocks template execution error: %w", err)
+	}
+
+	return parseBlocks(buf.Bytes())
+}
+
+// parseBlocks decodes and validates rendered Block Kit JSON
+func parseBlocks(data []byte) ([]slack.Block, error) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("blocks template did not render valid JSON: %s", data)
+	}
+
+	if len(data) > 0 && data[0] == '{' {
+		var payload struct {
+			Blocks json.RawMessage `json:"blocks"`
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("invalid blocks: %w", err)
+		}
+		data = payload.Blocks
+	}
+
+	var blocks slack.Blocks
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil, fmt.Errorf("invalid blocks: %w", err)
+	}
+
+	switch {
+	case len(blocks.BlockSet) == 0:
+		return nil, fmt.Errorf("blocks template rendered no blocks")
+	case len(blocks.BlockSet) > maxBlocks:
+		return nil, fmt.Errorf("blocks template rendered %d blocks, Slack accepts at most %d", len(blocks.BlockSet), maxBlocks)
+	}
+	for i, block := range blocks.BlockSet {
+		if unknown, ok := block.(*slack.UnknownBlock); ok {
+			return nil, fmt.Errorf("block %d has unknown type %q", i, unknown.Type)
+		}
+	}
+
+	return blocks.BlockSet, nil
+}
+
+func field(name, value string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, "*"+name+"*\n"+value, false, false)
+}
+
+func prButton(prURL string, actions ...slack.BlockElement) slack.Block {
+	button := slack.NewButtonBlockElement("open_pr", prURL, slack.NewTextBlockObject(slack.PlainTextType, "View pull request", false, false))
+	button.URL = prURL
+	button.WithStyle(slack.StylePrimary)
+	return slack.NewActionBlock("actions", append([]slack.BlockElement{button}, actions...)...)
+}
+
+func footer() slack.Block {
+	return slack.NewContextBlock("footer", slack.NewTextBlockObject(slack.MarkdownType, "Sent via <https://github.com/Djiit/gong|🛎️ gong>", false, false))
+}
+
+// escape escapes the characters Slack gives a meaning to in mrkdwn text
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// jsonString quotes a value as a JSON string, so that blocks templates can embed any text safely
+func jsonString(v interface{}) (string, error) {
+	data, err := json.Marshal(fmt.Sprint(v))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
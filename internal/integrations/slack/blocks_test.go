@@ -0,0 +1,121 @@
+package slack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultBlocks(t *testing.T) {
+	data := format.PrepareTemplateData([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", On: time.Now().Add(-26 * time.Hour), PRTitle: "Fix <script> injection", PRAuthor: "dave"}, ShouldPing: true},
+	}, "owner", "repo", "123", "https://github.com/owner/repo/pull/123", false)
+	data.Labels = []string{"bug", "security"}
+
+	blocks := defaultBlocks("Review requested on PR #123", "Reviewers: alice", data)
+
+	if !assert.Len(t, blocks, 5) {
+		return
+	}
+
+	assert.Equal(t, "Review requested on PR #123", blocks[0].(*slack.HeaderBlock).Text.Text)
+	assert.Equal(t, "Reviewers: alice", blocks[1].(*slack.SectionBlock).Text.Text)
+
+	var fields []string
+	for _, f := range blocks[2].(*slack.SectionBlock).Fields {
+		fields = append(fields, f.Text)
+	}
+	assert.Equal(t, []string{
+		"*Pull request*\n<https://github.com/owner/repo/pull/123|Fix &lt;script&gt; injection>",
+		"*Author*\ndave",
+		"*Waiting for*\n1d 2h",
+		"*Labels*\n`bug` `security`",
+	}, fields)
+
+	button := blocks[3].(*slack.ActionBlock).Elements.ElementSet[0].(*slack.ButtonBlockElement)
+	assert.Equal(t, "View pull request", button.Text.Text)
+	assert.Equal(t, "https://github.com/owner/repo/pull/123", button.URL)
+}
+
+func TestRenderBlocks(t *testing.T) {
+	data := format.TemplateData{
+		PRNumber: "123",
+		PRURL:    "https://github.com/owner/repo/pull/123",
+		PRTitle:  `Quote "this"`,
+		Mentions: []format.Mention{{Name: "alice", ChatID: "U0ALICE"}, {Name: "bob"}},
+	}
+
+	testCases := []struct {
+		name        string
+		template    string
+		expectTypes []slack.MessageBlockType
+		expectError string
+	}{
+		{
+			name: "Array of blocks",
+			template: `[{"type": "section", "text": {"type": "mrkdwn", "text": {{ json .PRTitle }}}},
+{"type": "context", "elements": [{{ range $i, $m := .Mentions }}{{ if $i }},{{ end }}{"type": "mrkdwn", "text": {{ json (mention $m) }}}{{ end }}]}]`,
+			expectTypes: []slack.MessageBlockType{slack.MBTSection, slack.MBTContext},
+		},
+		{
+			name:        "Block Kit Builder payload",
+			template:    `{"blocks": [{"type": "divider"}, {"type": "section", "text": {"type": "mrkdwn", "text": "<{{ .PRURL }}|PR #{{ .PRNumber }}>"}}]}`,
+			expectTypes: []slack.MessageBlockType{slack.MBTDivider, slack.MBTSection},
+		},
+		{
+			name:        "Invalid JSON",
+			template:    `[{"type": "section", "text": {"type": "mrkdwn", "text": "{{ .PRTitle }}"}}]`,
+			expectError: "did not render valid JSON",
+		},
+		{
+			name:        "Unknown block type",
+			template:    `[{"type": "carousel"}]`,
+			expectError: `unknown type "carousel"`,
+		},
+		{
+			name:        "No blocks",
+			template:    `{"blocks": []}`,
+			expectError: "rendered no blocks",
+		},
+		{
+			name:        "Template error",
+			template:    `[{{ .Missing }}]`,
+			expectError: "blocks template execution error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks, err := renderBlocks(tc.template, data)
+
+			if tc.expectError != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectError)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			var types []slack.MessageBlockType
+			for _, block := range blocks {
+				types = append(types, block.BlockType())
+			}
+			assert.Equal(t, tc.expectTypes, types)
+		})
+	}
+}
+
+func TestRenderBlocksMentions(t *testing.T) {
+	blocks, err := renderBlocks(`[{"type": "section", "text": {"type": "mrkdwn", "text": {{ json (mention (index .Mentions 0)) }}}}]`, format.TemplateData{
+		Mentions: []format.Mention{{Name: "alice", ChatID: "U0ALICE"}},
+	})
+
+	if assert.NoError(t, err) && assert.Len(t, blocks, 1) {
+		assert.Equal(t, "<@U0ALICE>", blocks[0].(*slack.SectionBlock).Text.Text)
+	}
+}
@@ -0,0 +1,273 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/rs/zerolog/log"
+	"github.com/slack-go/slack"
+	"github.com/spf13/viper"
+)
+
+// DefaultAPIURL is the root of the Slack Web API used in bot-token mode
+const DefaultAPIURL = "https://slack.com/api/"
+
+// PostedMessage identifies a message posted in bot-token mode
+type PostedMessage struct {
+	Channel   string
+	Timestamp string
+}
+
+// emailLookup is implemented by providers that can return the public email of a user
+type emailLookup interface {
+	UserEmail(ctx context.Context, login string) (string, error)
+}
+
+// botMessage describes what to post in bot-token mode
+type botMessage struct {
+	channel        string // Channel to post to, empty to only send direct messages
+	directMessages bool   // Whether each reviewer also gets a direct message
+	title          string
+	owner          string
+	repo           string
+	prNumber       string
+	store          *state.Store // Where posted messages are remembered, nil to always post new ones
+	// render renders the message reminding requests
+	render func(requests []ping.PingRequest) (rendered, error)
+}
+
+// newBotClient creates a Slack Web API client authenticated with a bot token. The API root can
+// be changed with slack-api-url, e.g. to talk to a local stand-in of the Slack API.
+func newBotClient(token string) *slack.Client {
+	apiURL := viper.GetString("slack-api-url")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+
+	return slack.New(token, slack.OptionAPIURL(apiURL))
+}
+
+// sendBotMessages posts the message to the configured channel with chat.postMessage and, if
+// asked to, sends each reviewer a direct message only mentioning them. When a message was
+// already posted for the pull request in a channel, it is updated in place and the reminder is
+// posted in its thread instead. It returns the messages that were posted or updated.
+func sendBotMessages(ctx context.Context, api *slack.Client, pingRequests []ping.PingRequest, msg botMessage, isDryRun bool) []PostedMessage {
+	var posted []PostedMessage
+
+	if msg.channel != "" {
+		if m, ok := deliver(ctx, api, msg.channel, false, pingRequests, msg, isDryRun); ok {
+			posted = append(posted, m)
+		}
+	}
+
+	if msg.directMessages {
+		for _, req := range pingRequests {
+			if req.Req.IsTeam {
+				log.Warn().Msgf("Cannot send a direct message to team %s, skipping", req.Req.From)
+				continue
+			}
+
+			userID, err := slackUserID(ctx, api, req)
+			if err != nil {
+				log.Warn().Msgf("Cannot send a direct message to %s: %v", req.Req.From, err)
+				continue
+			}
+
+			if m, ok := deliver(ctx, api, userID, true, []ping.PingRequest{req}, msg, isDryRun); ok {
+				posted = append(posted, m)
+			}
+		}
+	}
+
+	if msg.store != nil && len(posted) > 0 {
+		if err := msg.store.Save(); err != nil {
+			log.Error().Err(err).Msg("Error saving Slack messages to the state")
+		}
+	}
+
+	return posted
+}
+
+// deliver sends the message to target, a channel or the user ID of a direct message
+func deliver(ctx context.Context, api *slack.Client, target string, direct bool, pingRequests []ping.PingRequest, msg botMessage, isDryRun bool) (PostedMessage, bool) {
+	message, err := msg.render(pingRequests)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Slack message with template")
+		return PostedMessage{}, false
+	}
+
+	key := state.SlackMessageKey(msg.owner, msg.repo, msg.prNumber, target)
+	var existing state.SlackMessage
+	found := false
+	if msg.store != nil {
+		// A resolved message is brought back too, e.g. once the pull request is out of draft again
+		existing, found = msg.store.SlackMessage(key)
+	}
+
+	if isDryRun {
+		if found {
+			log.Info().Msgf("[DRY RUN] Would update Slack message %s in %s and remind reviewers in its thread", existing.Timestamp, existing.Channel)
+		} else {
+			log.Info().Msgf("[DRY RUN] Would post Slack message to %s with the bot token", target)
+		}
+		log.Info().Msgf("[DRY RUN] Message: %s", message.Text)
+		return PostedMessage{}, false
+	}
+
+	if found {
+		log.Debug().Msgf("Updating Slack message %s in %s", existing.Timestamp, existing.Channel)
+		_, _, _, err := api.UpdateMessageContext(ctx, existing.Channel, existing.Timestamp,
+			slack.MsgOptionText(message.Text, false),
+			slack.MsgOptionBlocks(message.Blocks...),
+		)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error updating Slack message in %s", existing.Channel)
+			return PostedMessage{}, false
+		}
+
+		// Mentions in the thread notify reviewers again without flooding the channel
+		if _, _, err := api.PostMessageContext(ctx, existing.Channel,
+			slack.MsgOptionTS(existing.Timestamp),
+			slack.MsgOptionText(message.Text, false),
+		); err != nil {
+			log.Error().Err(err).Msgf("Error posting reminder in the thread of %s", existing.Timestamp)
+		}
+
+		existing.Title, existing.Text, existing.Resolved, existing.UpdatedAt = msg.title, message.Text, false, time.Now()
+		msg.store.SetSlackMessage(key, existing)
+		return PostedMessage{Channel: existing.Channel, Timestamp: existing.Timestamp}, true
+	}
+
+	channel := target
+	if direct {
+		im, _, _, err := api.OpenConversationContext(ctx, &slack.OpenConversationParameters{Users: []string{target}})
+		if err != nil {
+			log.Error().Err(err).Msgf("Error opening a direct message with %s", target)
+			return PostedMessage{}, false
+		}
+		channel = im.ID
+	}
+
+	log.Debug().Msgf("Posting Slack message to %s with the bot token", channel)
+	channelID, ts, err := api.PostMessageContext(ctx, channel,
+		slack.MsgOptionText(message.Text, false),
+		slack.MsgOptionBlocks(message.Blocks...),
+	)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error posting Slack message to %s", channel)
+		return PostedMessage{}, false
+	}
+	log.Debug().Msgf("Posted Slack message %s in %s", ts, channelID)
+
+	if msg.store != nil {
+		msg.store.SetSlackMessage(key, state.SlackMessage{
+			Channel:   channelID,
+			Timestamp: ts,
+			Title:     msg.title,
+			Text:      message.Text,
+			UpdatedAt: time.Now(),
+		})
+	}
+	return PostedMessage{Channel: channelID, Timestamp: ts}, true
+}
+
+// Resolve marks the Slack messages posted for a pull request in bot-token mode as resolved, once
+// it was merged or closed or all of its reviews landed. The "resolution" context value tells why.
+// Messages stay resolved until reviews are requested again, which updates them back in place.
+func Resolve(ctx context.Context) {
+	token := viper.GetString("slack-token")
+	if token == "" {
+		return
+	}
+
+	store, err := state.FromContext(ctx)
+	if err != nil {
+		log.Warn().Msgf("Could not open the state, Slack messages will not be updated in place: %v", err)
+		return
+	}
+
+	repoOwner := ctx.Value("repoOwner").(string)
+	repoName := ctx.Value("repoName").(string)
+	prNumber := ctx.Value("pr").(string)
+	isDryRun := ctx.Value("dry-run").(bool)
+	resolution, _ := ctx.Value("resolution").(string)
+	prURL, _ := ctx.Value("prURL").(string)
+
+	api := newBotClient(token)
+	changed := false
+
+	for key, m := range store.SlackMessages(repoOwner, repoName, prNumber) {
+		if m.Resolved {
+			continue
+		}
+
+		if isDryRun {
+			log.Info().Msgf("[DRY RUN] Would mark Slack message %s in %s as resolved (%s)", m.Timestamp, m.Channel, resolution)
+			continue
+		}
+
+		_, _, _, err := api.UpdateMessageContext(ctx, m.Channel, m.Timestamp,
+			slack.MsgOptionText("✅ "+strikethrough(m.Title), false),
+			slack.MsgOptionBlocks(resolvedBlocks(prURL, m.Text, resolution)...),
+		)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error marking Slack message in %s as resolved", m.Channel)
+			continue
+		}
+
+		m.Resolved, m.UpdatedAt = true, time.Now()
+		store.SetSlackMessage(key, m)
+		changed = true
+	}
+
+	if changed {
+		if err := store.Save(); err != nil {
+			log.Error().Err(err).Msg("Error saving Slack messages to the state")
+		}
+	}
+}
+
+// strikethrough strikes every line of a Slack message through
+func strikethrough(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = "~" + line + "~"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// slackUserID returns the Slack user ID of a reviewer, from the identity directory or by looking
+// up their public email on the code host with users.lookupByEmail
+func slackUserID(ctx context.Context, api *slack.Client, req ping.PingRequest) (string, error) {
+	if req.ChatID != "" && !req.Req.IsTeam {
+		return req.ChatID, nil
+	}
+
+	lookup, ok := ctx.Value("provider").(emailLookup)
+	if !ok {
+		return "", fmt.Errorf("no Slack user ID known")
+	}
+
+	email, err := lookup.UserEmail(ctx, req.Req.From)
+	if err != nil {
+		return "", err
+	}
+	if email == "" {
+		return "", fmt.Errorf("no Slack user ID known and no public email")
+	}
+
+	user, err := api.GetUserByEmailContext(ctx, email)
+	if err != nil {
+		return "", fmt.Errorf("looking up %s: %w", email, err)
+	}
+	return user.ID, nil
+}
@@ -0,0 +1,223 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEmailLookup map[string]string
+
+func (f fakeEmailLookup) UserEmail(ctx context.Context, login string) (string, error) {
+	return f[login], nil
+}
+
+// slackAPIStandIn records the Web API calls it receives and answers them like Slack would
+type slackAPIStandIn struct {
+	mu       sync.Mutex
+	posts    []map[string]string
+	updates  []map[string]string
+	lookedUp []string
+}
+
+func newSlackAPIStandIn(t *testing.T) *slackAPIStandIn {
+	api := &slackAPIStandIn{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Failed to parse Slack API request: %v", err)
+		}
+		if r.Form.Get("token") != "xoxb-test" && r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Missing bot token on %s", r.URL.Path)
+		}
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			post := blockTexts(r.Form.Get("blocks"))
+			post["channel"], post["text"], post["thread_ts"] = r.Form.Get("channel"), r.Form.Get("text"), r.Form.Get("thread_ts")
+			post["blocks"] = r.Form.Get("blocks")
+			api.posts = append(api.posts, post)
+			fmt.Fprintf(w, `{"ok": true, "channel": %q, "ts": "1700000000.%06d"}`, r.Form.Get("channel"), len(api.posts))
+		case "/chat.update":
+			update := blockTexts(r.Form.Get("blocks"))
+			update["channel"], update["ts"], update["text"] = r.Form.Get("channel"), r.Form.Get("ts"), r.Form.Get("text")
+			api.updates = append(api.updates, update)
+			fmt.Fprintf(w, `{"ok": true, "channel": %q, "ts": %q}`, r.Form.Get("channel"), r.Form.Get("ts"))
+		case "/conversations.open":
+			fmt.Fprintf(w, `{"ok": true, "channel": {"id": "D%s"}}`, r.Form.Get("users"))
+		case "/users.lookupByEmail":
+			api.lookedUp = append(api.lookedUp, r.Form.Get("email"))
+			if r.Form.Get("email") == "bob@example.com" {
+				fmt.Fprint(w, `{"ok": true, "user": {"id": "U0BOB"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok": false, "error": "users_not_found"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("slack-token", "xoxb-test")
+	viper.Set("slack-api-url", server.URL)
+	viper.Set("state-file", filepath.Join(t.TempDir(), "state.json"))
+
+	return api
+}
+
+// blockTexts returns the text of the header, message and resolution blocks of a message
+func blockTexts(blocks string) map[string]string {
+	texts := make(map[string]string)
+
+	var decoded []struct {
+		Type    string `json:"type"`
+		BlockID string `json:"block_id"`
+		Text    *struct {
+			Text string `json:"text"`
+		} `json:"text"`
+		Elements []struct {
+			Text string `json:"text"`
+		} `json:"elements"`
+	}
+	_ = json.Unmarshal([]byte(blocks), &decoded)
+
+	for _, block := range decoded {
+		switch {
+		case block.Type == "header" && block.Text != nil:
+			texts["header"] = block.Text.Text
+		case block.BlockID == "message" && block.Text != nil:
+			texts["message"] = block.Text.Text
+		case block.BlockID == "resolution" && len(block.Elements) > 0:
+			texts["resolution"] = block.Elements[0].Text
+		}
+	}
+	return texts
+}
+
+func TestRunWithBotToken(t *testing.T) {
+	api := newSlackAPIStandIn(t)
+	now := time.Now()
+
+	integrations := []ping.Integration{{Type: "slack", Parameters: map[string]string{"channel": "C0REVIEWS", "dm": "true"}}}
+	pingRequests := []ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-2 * time.Hour)}, ShouldPing: true, ChatID: "U0ALICE", Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "carol", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "backend", On: now.Add(-2 * time.Hour), IsTeam: true}, ShouldPing: true, Integrations: integrations},
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "pingRequests", pingRequests)
+	ctx = context.WithValue(ctx, "repoOwner", "owner")
+	ctx = context.WithValue(ctx, "repoName", "repo")
+	ctx = context.WithValue(ctx, "pr", "123")
+	ctx = context.WithValue(ctx, "dry-run", false)
+	ctx = context.WithValue(ctx, "provider", fakeEmailLookup{"bob": "bob@example.com", "carol": "carol@example.com"})
+
+	Run(ctx)
+
+	assert.Equal(t, []string{"bob@example.com", "carol@example.com"}, api.lookedUp)
+	if assert.Len(t, api.posts, 3) {
+		assert.Equal(t, "C0REVIEWS", api.posts[0]["channel"])
+		assert.Equal(t, "Review requested on PR #123", api.posts[0]["header"])
+		assert.Contains(t, api.posts[0]["text"], "Reviewers: <@U0ALICE>")
+		assert.Contains(t, api.posts[0]["message"], "Reviewers: <@U0ALICE>, bob, carol, backend (team)")
+
+		// Direct messages only mention their recipient
+		assert.Equal(t, "DU0ALICE", api.posts[1]["channel"])
+		assert.True(t, strings.HasSuffix(api.posts[1]["message"], "Reviewers: <@U0ALICE>"))
+		assert.Equal(t, "DU0BOB", api.posts[2]["channel"])
+		assert.True(t, strings.HasSuffix(api.posts[2]["message"], "Reviewers: bob"))
+	}
+}
+
+func TestSendBotMessagesReturnsTimestamps(t *testing.T) {
+	newSlackAPIStandIn(t)
+
+	posted := sendBotMessages(context.Background(), newBotClient("xoxb-test"), []ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice"}, ShouldPing: true},
+	}, botMessage{
+		channel: "C0REVIEWS",
+		title:   "Review requested",
+		render:  func([]ping.PingRequest) (rendered, error) { return rendered{Text: "hello"}, nil },
+	}, false)
+
+	assert.Equal(t, []PostedMessage{{Channel: "C0REVIEWS", Timestamp: "1700000000.000001"}}, posted)
+}
+
+func newBotTestContext(pingRequests []ping.PingRequest) context.Context {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "pingRequests", pingRequests)
+	ctx = context.WithValue(ctx, "repoOwner", "owner")
+	ctx = context.WithValue(ctx, "repoName", "repo")
+	ctx = context.WithValue(ctx, "pr", "123")
+	ctx = context.WithValue(ctx, "prURL", "https://github.com/owner/repo/pull/123")
+	ctx = context.WithValue(ctx, "dry-run", false)
+	return ctx
+}
+
+func TestRunUpdatesMessagesInPlace(t *testing.T) {
+	api := newSlackAPIStandIn(t)
+	integrations := []ping.Integration{{Type: "slack", Parameters: map[string]string{"channel": "C0REVIEWS"}}}
+
+	Run(newBotTestContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", On: time.Now()}, ShouldPing: true, ChatID: "U0ALICE", Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "bob", On: time.Now()}, ShouldPing: true, Integrations: integrations},
+	}))
+
+	// Alice reviewed, only Bob is still pending on the next run
+	Run(newBotTestContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "bob", On: time.Now()}, ShouldPing: true, Integrations: integrations},
+	}))
+
+	if assert.Len(t, api.posts, 2) && assert.Len(t, api.updates, 1) {
+		assert.Equal(t, "", api.posts[0]["thread_ts"])
+		assert.Equal(t, "1700000000.000001", api.updates[0]["ts"])
+		assert.True(t, strings.HasSuffix(api.updates[0]["message"], "Reviewers: bob"))
+
+		// The reminder is a reply in the thread of the first message
+		assert.Equal(t, "1700000000.000001", api.posts[1]["thread_ts"])
+		assert.True(t, strings.HasSuffix(api.posts[1]["text"], "Reviewers: bob"))
+	}
+
+	ctx := context.WithValue(newBotTestContext(nil), "resolution", "PR #123 was merged")
+	Resolve(ctx)
+	Resolve(ctx)
+
+	if assert.Len(t, api.updates, 2) {
+		assert.Equal(t, "✅ ~Review requested on PR #123~", api.updates[1]["text"])
+		assert.True(t, strings.HasSuffix(api.updates[1]["message"], "~Reviewers: bob~"))
+		assert.Equal(t, "✅ PR #123 was merged", api.updates[1]["resolution"])
+	}
+
+	// A resolved message is updated again rather than replaced, e.g. once the PR is out of draft
+	Run(newBotTestContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "carol", On: time.Now()}, ShouldPing: true, Integrations: integrations},
+	}))
+	if assert.Len(t, api.posts, 3) && assert.Len(t, api.updates, 3) {
+		assert.Equal(t, "1700000000.000001", api.updates[2]["ts"])
+		assert.True(t, strings.HasSuffix(api.updates[2]["message"], "Reviewers: carol"))
+		assert.Equal(t, "1700000000.000001", api.posts[2]["thread_ts"])
+	}
+
+	// And resolved again once its reviews landed
+	Resolve(context.WithValue(newBotTestContext(nil), "resolution", "All reviews on PR #123 landed"))
+	assert.Len(t, api.updates, 4)
+}
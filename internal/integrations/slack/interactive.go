@@ -0,0 +1,249 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/identity"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/rs/zerolog/log"
+	"github.com/slack-go/slack"
+)
+
+// Action IDs of the interactive buttons
+const (
+	ActionSnooze      = "gong_snooze"
+	ActionAcknowledge = "gong_ack"
+)
+
+// Default periods of the interactive buttons
+const (
+	DefaultSnoozeFor      = 4 * time.Hour
+	DefaultAcknowledgeFor = 24 * time.Hour
+)
+
+// maxPayloadSize bounds the size of the interaction requests read from Slack
+const maxPayloadSize = 1 << 20
+
+// interactionTimeout bounds the time spent recording an interaction and replying to it
+const interactionTimeout = 30 * time.Second
+
+// buttons are the snooze and acknowledge buttons added to messages when interactivity is enabled
+type buttons struct {
+	snoozeFor      time.Duration
+	acknowledgeFor time.Duration
+}
+
+// interaction is the value of an interactive button: which reviewers of which pull request to
+// leave alone, and for how long
+type interaction struct {
+	Owner     string   `json:"o"`
+	Repo      string   `json:"r"`
+	PR        string   `json:"pr"`
+	Reviewers []string `json:"rv"` // Users or teams the message reminds, as requested on the pull request
+	For       string   `json:"for"`
+}
+
+// elements returns the buttons of a message about a pull request reminding reviewers
+func (b *buttons) elements(owner, repo, prNumber string, reviewers []string) []slack.BlockElement {
+	if b == nil {
+		return nil
+	}
+
+	snooze := slack.NewButtonBlockElement(ActionSnooze, b.value(owner, repo, prNumber, reviewers, b.snoozeFor),
+		slack.NewTextBlockObject(slack.PlainTextType, "Snooze "+format.FormatDuration(b.snoozeFor), false, false))
+	acknowledge := slack.NewButtonBlockElement(ActionAcknowledge, b.value(owner, repo, prNumber, reviewers, b.acknowledgeFor),
+		slack.NewTextBlockObject(slack.PlainTextType, "I'm on it", false, false))
+
+	return []slack.BlockElement{snooze, acknowledge}
+}
+
+func (b *buttons) value(owner, repo, prNumber string, reviewers []string, d time.Duration) string {
+	value, _ := json.Marshal(interaction{Owner: owner, Repo: repo, PR: prNumber, Reviewers: reviewers, For: d.String()})
+	return string(value)
+}
+
+// parseButtons reads the interactive button parameters of the integration. Buttons are only added
+// with interactive set to "true", since they need gong serve to be reachable by Slack.
+func parseButtons(params map[string]string) *buttons {
+	if params["interactive"] != "true" {
+		return nil
+	}
+
+	return &buttons{
+		snoozeFor:      parseDurationParam(params, "snooze_for", DefaultSnoozeFor),
+		acknowledgeFor: parseDurationParam(params, "ack_for", DefaultAcknowledgeFor),
+	}
+}
+
+func parseDurationParam(params map[string]string, key string, fallback time.Duration) time.Duration {
+	value, ok := params[key]
+	if !ok || value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Warn().Msgf("Invalid Slack %s parameter %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// InteractionHandler handles the interactive payloads Slack sends when a snooze or acknowledge
+// button is clicked, and records them in the state so that later runs leave the reviewer alone
+type InteractionHandler struct {
+	SigningSecret string              // Signing secret of the Slack app, used to verify requests
+	Directory     *identity.Directory // Maps the Slack users who click onto the reviewers they are
+	// OpenState opens the state shared with the ping runs. It is reopened for every interaction
+	// so that messages saved by runs in between are not overwritten.
+	OpenState func() (*state.Store, error)
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+func (h *InteractionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "error reading request", http.StatusBadRequest)
+		return
+	}
+
+	verifier, err := slack.NewSecretsVerifier(r.Header, h.SigningSecret)
+	if err == nil {
+		_, _ = verifier.Write(body)
+		err = verifier.Ensure()
+	}
+	if err != nil {
+		log.Warn().Msgf("Rejected Slack interaction: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Slack expects an answer within 3 seconds, so the interaction is acknowledged right away and
+	// handled in the background, the outcome being reported through the response URL
+	w.WriteHeader(http.StatusOK)
+
+	if callback.Type != slack.InteractionTypeBlockActions {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), interactionTimeout)
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer cancel()
+		h.handleCallback(ctx, callback)
+	}()
+}
+
+// Wait waits for the interactions being handled in the background
+func (h *InteractionHandler) Wait() {
+	h.wg.Wait()
+}
+
+// handleCallback handles the button clicks of an interaction and replies to the user who clicked
+func (h *InteractionHandler) handleCallback(ctx context.Context, callback slack.InteractionCallback) {
+	for _, action := range callback.ActionCallback.BlockActions {
+		if action.ActionID != ActionSnooze && action.ActionID != ActionAcknowledge {
+			continue
+		}
+
+		reply := h.handleAction(callback.User.ID, action)
+		if callback.ResponseURL == "" {
+			continue
+		}
+		if err := slack.PostWebhookContext(ctx, callback.ResponseURL, &slack.WebhookMessage{
+			Text:         reply,
+			ResponseType: "ephemeral", // Only shown to the user who clicked
+		}); err != nil {
+			log.Error().Err(err).Msg("Error replying to Slack interaction")
+		}
+	}
+}
+
+// handleAction records a snooze or acknowledgement and returns the reply shown to the user
+func (h *InteractionHandler) handleAction(userID string, action *slack.BlockAction) string {
+	var value interaction
+	if err := json.Unmarshal([]byte(action.Value), &value); err != nil {
+		log.Warn().Msgf("Invalid Slack button value %q: %v", action.Value, err)
+		return "Sorry, gong could not read this button."
+	}
+
+	period, err := time.ParseDuration(value.For)
+	if err != nil || period <= 0 {
+		log.Warn().Msgf("Invalid period in Slack button value %q", action.Value)
+		return "Sorry, gong could not read this button."
+	}
+
+	// Someone clicking in a message reminding several reviewers only answers for themselves if
+	// they are one of them, and for all of them otherwise, e.g. on behalf of a requested team
+	reviewers := value.Reviewers
+	if h.Directory != nil && len(reviewers) > 1 {
+		if login, ok := h.Directory.Login(userID); ok && slices.Contains(reviewers, login) {
+			reviewers = []string{login}
+		}
+	}
+	if len(reviewers) == 0 {
+		log.Warn().Msgf("No reviewer in Slack button value %q", action.Value)
+		return "Sorry, gong could not read this button."
+	}
+
+	now := time.Now()
+	snooze := state.Snooze{
+		Until:        now.Add(period),
+		Acknowledged: action.ActionID == ActionAcknowledge,
+		By:           userID,
+		At:           now,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	store, err := h.OpenState()
+	if err == nil {
+		for _, reviewer := range reviewers {
+			store.SetSnooze(state.ReviewerKey(value.Owner, value.Repo, value.PR, reviewer), snooze)
+		}
+		err = store.Save()
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Error saving Slack interaction to the state")
+		return "Sorry, gong could not save this, please try again."
+	}
+
+	pr := fmt.Sprintf("%s/%s#%s", value.Owner, value.Repo, value.PR)
+	reviewer := strings.Join(reviewers, ", ")
+	log.Info().Msgf("%s asked gong to leave %s alone on %s for %s", userID, reviewer, pr, period)
+
+	if snooze.Acknowledged {
+		return fmt.Sprintf("Thanks! gong will not remind %s about %s for %s.", reviewer, pr, format.FormatDuration(period))
+	}
+	return fmt.Sprintf("Snoozed: gong will not remind %s about %s before %s.", reviewer, pr, snooze.Until.Format(time.RFC1123))
+}
@@ -0,0 +1,230 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/identity"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// signedInteraction builds an interactive payload request signed like Slack does
+func signedInteraction(t *testing.T, secret string, payload map[string]interface{}) *http.Request {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := url.Values{"payload": {string(encoded)}}.Encode()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/interactive", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func blockActionsPayload(userID, responseURL, actionID string, value interaction) map[string]interface{} {
+	encoded, _ := json.Marshal(value)
+	return map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": userID},
+		"response_url": responseURL,
+		"actions":      []map[string]string{{"block_id": "actions", "action_id": actionID, "value": string(encoded)}},
+	}
+}
+
+// newResponseURL returns a stand-in of the response URL of an interaction and the replies it got
+func newResponseURL(t *testing.T) (string, func() []map[string]interface{}) {
+	var mu sync.Mutex
+	var replies []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reply map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&reply)
+		mu.Lock()
+		replies = append(replies, reply)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL, func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return replies
+	}
+}
+
+func newTestInteractionHandler(t *testing.T) (*InteractionHandler, string) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	directory := identity.New()
+	directory.Users["alice"] = "U0ALICE"
+
+	return &InteractionHandler{
+		SigningSecret: testSigningSecret,
+		Directory:     directory,
+		OpenState:     func() (*state.Store, error) { return state.Open(path) },
+	}, path
+}
+
+func TestInteractionHandler(t *testing.T) {
+	responseURL, replies := newResponseURL(t)
+	value := interaction{Owner: "owner", Repo: "repo", PR: "123", Reviewers: []string{"alice", "org/backend"}, For: "4h"}
+
+	testCases := []struct {
+		name         string
+		secret       string
+		userID       string
+		actionID     string
+		value        interaction
+		expectStatus int
+		expectKeys   []string
+		expectAck    bool
+		expectReply  string
+	}{
+		{
+			name:         "Snooze from a channel by one of the reviewers",
+			secret:       testSigningSecret,
+			userID:       "U0ALICE",
+			actionID:     ActionSnooze,
+			value:        value,
+			expectStatus: http.StatusOK,
+			expectKeys:   []string{"owner/repo#123:alice"},
+			expectReply:  "Snoozed: gong will not remind alice about owner/repo#123 before",
+		},
+		{
+			name:         "Snooze from a channel on behalf of the reviewers",
+			secret:       testSigningSecret,
+			userID:       "U0CAROL",
+			actionID:     ActionSnooze,
+			value:        value,
+			expectStatus: http.StatusOK,
+			expectKeys:   []string{"owner/repo#123:alice", "owner/repo#123:org/backend"},
+			expectReply:  "Snoozed: gong will not remind alice, org/backend about owner/repo#123 before",
+		},
+		{
+			name:         "Acknowledge from a direct message",
+			secret:       testSigningSecret,
+			userID:       "U0BOB",
+			actionID:     ActionAcknowledge,
+			value:        interaction{Owner: "owner", Repo: "repo", PR: "123", Reviewers: []string{"bob"}, For: "24h"},
+			expectStatus: http.StatusOK,
+			expectKeys:   []string{"owner/repo#123:bob"},
+			expectAck:    true,
+			expectReply:  "Thanks! gong will not remind bob about owner/repo#123 for 1d.",
+		},
+		{
+			name:         "Button without reviewers",
+			secret:       testSigningSecret,
+			userID:       "U0ALICE",
+			actionID:     ActionSnooze,
+			value:        interaction{Owner: "owner", Repo: "repo", PR: "123", For: "4h"},
+			expectStatus: http.StatusOK,
+			expectReply:  "gong could not read this button",
+		},
+		{
+			name:         "Invalid signature",
+			secret:       "not-the-secret",
+			userID:       "U0ALICE",
+			actionID:     ActionSnooze,
+			value:        value,
+			expectStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, path := newTestInteractionHandler(t)
+			before := len(replies())
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, signedInteraction(t, tc.secret, blockActionsPayload(tc.userID, responseURL, tc.actionID, tc.value)))
+			handler.Wait()
+
+			assert.Equal(t, tc.expectStatus, rec.Code)
+
+			store, err := state.Open(path)
+			assert.NoError(t, err)
+			for _, reviewer := range tc.value.Reviewers {
+				snooze, active := store.ActiveSnooze("owner/repo#123:"+reviewer, time.Now())
+				expected := slices.Contains(tc.expectKeys, "owner/repo#123:"+reviewer)
+				assert.Equal(t, expected, active, reviewer)
+				if expected {
+					assert.Equal(t, tc.expectAck, snooze.Acknowledged)
+					assert.Equal(t, tc.userID, snooze.By)
+				}
+			}
+
+			if tc.expectReply == "" {
+				assert.Len(t, replies(), before)
+				return
+			}
+			if assert.Len(t, replies(), before+1) {
+				reply := replies()[before]
+				assert.Contains(t, reply["text"], tc.expectReply)
+				assert.Equal(t, "ephemeral", reply["response_type"])
+			}
+		})
+	}
+}
+
+func TestRunWithInteractiveButtons(t *testing.T) {
+	api := newSlackAPIStandIn(t)
+	integrations := []ping.Integration{{Type: "slack", Parameters: map[string]string{
+		"channel": "C0REVIEWS", "dm": "true", "interactive": "true", "snooze_for": "2h",
+	}}}
+
+	Run(newBotTestContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", On: time.Now()}, ShouldPing: true, ChatID: "U0ALICE", Integrations: integrations},
+	}))
+
+	if !assert.Len(t, api.posts, 2) {
+		return
+	}
+
+	values := make(map[string]interaction)
+	for _, post := range api.posts {
+		var blocks slack.Blocks
+		assert.NoError(t, json.Unmarshal([]byte(post["blocks"]), &blocks))
+		for _, block := range blocks.BlockSet {
+			actions, ok := block.(*slack.ActionBlock)
+			if !ok {
+				continue
+			}
+			for _, element := range actions.Elements.ElementSet {
+				if button, ok := element.(*slack.ButtonBlockElement); ok && button.ActionID == ActionSnooze {
+					var value interaction
+					assert.NoError(t, json.Unmarshal([]byte(button.Value), &value))
+					assert.Equal(t, "Snooze 2h", button.Text.Text)
+					values[post["channel"]] = value
+				}
+			}
+		}
+	}
+
+	// Buttons carry the reviewers the message reminds, in channels and direct messages alike
+	assert.Equal(t, interaction{Owner: "owner", Repo: "repo", PR: "123", Reviewers: []string{"alice"}, For: "2h0m0s"}, values["C0REVIEWS"])
+	assert.Equal(t, interaction{Owner: "owner", Repo: "repo", PR: "123", Reviewers: []string{"alice"}, For: "2h0m0s"}, values["DU0ALICE"])
+}
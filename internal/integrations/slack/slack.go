@@ -1,13 +1,12 @@
 package slack
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"text/template"
 
 	"github.com/Djiit/gong/internal/format"
 	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
 	"github.com/rs/zerolog/log"
 	"github.com/slack-go/slack"
 	"github.com/spf13/viper"
@@ -15,7 +14,11 @@ import (
 
 // DefaultTemplate is the default template used for Slack output
 const DefaultTemplate = `PR #{{.PRNumber}} is waiting for review: <{{.PRURL}}|{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}>
-{{ if .ActiveReviewers }}Reviewers: {{ range $i, $r := .ActiveReviewers }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}{{ end }}`
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template used for Slack output when nudging the PR author
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} is waiting on its author: <{{.PRURL}}|{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}>
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
 
 func Run(ctx context.Context) {
 	pingRequests := ctx.Value("pingRequests").([]ping.PingRequest)
@@ -30,32 +33,94 @@ func Run(ctx context.Context) {
 
 	// Get template parameter from integrations config
 	var templateStr string
+	var blocksTemplate string
 	var channel string
 	var webhookURL string
+	var directMessages bool
+	var interactive *buttons
+
+	// Author nudges have their own template
+	templateKey, defaultTemplate := "template", DefaultTemplate
+	title := fmt.Sprintf("Review requested on PR #%s", prNumber)
+	if ping.IsAuthorNudge(pingRequests) {
+		templateKey, defaultTemplate = "authorTemplate", DefaultAuthorTemplate
+		title = fmt.Sprintf("PR #%s is waiting on its author", prNumber)
+	}
 
 	// First check if there's a template in the integration parameters
 	if len(pingRequests) > 0 {
 		for _, intg := range pingRequests[0].Integrations {
 			if intg.Type == "slack" {
 				// Look for template parameter
-				if tmpl, ok := intg.Parameters["template"]; ok && tmpl != "" {
+				if tmpl, ok := intg.Parameters[templateKey]; ok && tmpl != "" {
 					templateStr = tmpl
 				}
+				// Look for the Block Kit template, replacing the default layout
+				if tmpl, ok := intg.Parameters["blocks_template"]; ok && tmpl != "" {
+					blocksTemplate = tmpl
+				}
 				// Look for channel parameter
 				if ch, ok := intg.Parameters["channel"]; ok && ch != "" {
 					channel = ch
 				}
+				// Look for the direct messages parameter, only supported in bot-token mode
+				if intg.Parameters["dm"] == "true" {
+					directMessages = true
+				}
+				// Look for the snooze and acknowledge buttons, handled by gong serve
+				interactive = parseButtons(intg.Parameters)
 			}
 		}
 	}
 
 	// If no template found in integration parameters, try context
 	if templateStr == "" {
-		if val, ok := ctx.Value("template").(string); ok && val != "" {
+		if val, ok := ctx.Value(templateKey).(string); ok && val != "" {
 			templateStr = val
 		} else {
-			templateStr = DefaultTemplate
+			templateStr = defaultTemplate
+		}
+	}
+	if blocksTemplate == "" {
+		blocksTemplate, _ = ctx.Value("blocks_template").(string)
+	}
+
+	// Use the PR URL reported by the provider, falling back to GitHub
+	prURL, _ := ctx.Value("prURL").(string)
+	if prURL == "" {
+		prURL = fmt.Sprintf("https://github.com/%s/%s/pull/%s", repoOwner, repoName, prNumber)
+	}
+
+	labels, _ := ctx.Value("labels").([]string)
+	render := func(requests []ping.PingRequest) (rendered, error) {
+		reviewers := make([]string, 0, len(requests))
+		for _, req := range requests {
+			reviewers = append(reviewers, req.Req.From)
 		}
+		return renderMessage(requests, templateStr, blocksTemplate, title, labels, repoOwner, repoName, prNumber, prURL,
+			interactive.elements(repoOwner, repoName, prNumber, reviewers))
+	}
+
+	// A bot token enables the Web API, which can post to any channel and send direct messages
+	if token := viper.GetString("slack-token"); token != "" {
+		if channel == "" && !directMessages {
+			channel = "general"
+		}
+		store, err := state.FromContext(ctx)
+		if err != nil {
+			log.Warn().Msgf("Could not open the state, Slack messages will not be updated in place: %v", err)
+		}
+		sendBotMessages(ctx, newBotClient(token), pingRequests, botMessage{
+			channel:        channel,
+			directMessages: directMessages,
+			title:          title,
+			owner:          repoOwner,
+			repo:           repoName,
+			prNumber:       prNumber,
+			store:          store,
+			render:         render,
+		}, isDryRun)
+		return
 	}
 
 	// If no channel found, use default
@@ -71,10 +136,7 @@ func Run(ctx context.Context) {
 		return
 	}
 
-	// Create PR URL
-	prURL := fmt.Sprintf("https://github.com/%s/%s/pull/%s", repoOwner, repoName, prNumber)
-
-	message, err := formatWithTemplate(pingRequests, templateStr, repoOwner, repoName, prNumber, prURL)
+	message, err := render(pingRequests)
 	if err != nil {
 		log.Error().Err(err).Msg("Error formatting Slack message with template")
 		return
@@ -83,11 +145,11 @@ func Run(ctx context.Context) {
 	if isDryRun {
 		log.Info().Msgf("[DRY RUN] Would send Slack notification to channel %s via webhook for PR #%s in %s/%s",
 			channel, prNumber, repoOwner, repoName)
-		log.Info().Msgf("[DRY RUN] Message: %s", message)
+		log.Info().Msgf("[DRY RUN] Message: %s", message.Text)
 		return
 	}
 
-	sendSlackMessage(channel, webhookURL, prURL, prNumber, message)
+	sendSlackMessage(channel, webhookURL, message)
 }
 
 func formatWithTemplate(pingRequests []ping.PingRequest, templateStr, repoOwner, repoName, prNumber, prURL string) (string, error) {
@@ -98,47 +160,52 @@ func formatWithTemplate(pingRequests []ping.PingRequest, templateStr, repoOwner,
 	// Use the shared template data preparation
 	data := format.PrepareTemplateData(pingRequests, repoOwner, repoName, prNumber, prURL, false)
 
-	// Parse template
-	tmpl, err := template.New("slack").Parse(templateStr)
+	return format.FormatTemplate(templateStr, data, mention)
+}
+
+// renderMessage renders the text of a message with its template, and its blocks with the blocks
+// template or, when there is none, the default layout around the text with the given buttons
+func renderMessage(pingRequests []ping.PingRequest, templateStr, blocksTemplate, title string, labels []string, repoOwner, repoName, prNumber, prURL string, actions []slack.BlockElement) (rendered, error) {
+	message, err := formatWithTemplate(pingRequests, templateStr, repoOwner, repoName, prNumber, prURL)
 	if err != nil {
-		return "", fmt.Errorf("template parsing error: %w", err)
+		return rendered{}, err
 	}
 
-	// Execute template
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("template execution error: %w", err)
+	data := format.PrepareTemplateData(pingRequests, repoOwner, repoName, prNumber, prURL, false)
+	data.Labels = labels
+
+	if blocksTemplate == "" {
+		return rendered{Text: message, Blocks: defaultBlocks(title, message, data, actions...)}, nil
+	}
+
+	blocks, err := renderBlocks(blocksTemplate, data)
+	if err != nil {
+		return rendered{}, err
 	}
+	return rendered{Text: message, Blocks: blocks}, nil
+}
 
-	return buf.String(), nil
+// mention formats a reviewer for Slack: users become user mentions and teams, mapped to user
+// group IDs, group mentions, so that the people involved are actually notified. Reviewers missing
+// from the identity directory are only named.
+func mention(m format.Mention) string {
+	switch {
+	case m.ChatID == "":
+		return m.Name
+	case m.IsTeam:
+		return "<!subteam^" + m.ChatID + ">"
+	}
+	return "<@" + m.ChatID + ">"
 }
 
-func sendSlackMessage(channel, webhookURL, prURL, prNumber, message string) {
+func sendSlackMessage(channel, webhookURL string, message rendered) {
 	log.Debug().Msgf("Sending Slack notification to channel %s via webhook", channel)
 
-	// Create message attachment
-	attachment := slack.Attachment{
-		Color:      "#36a64f",
-		Text:       message,
-		AuthorName: "🛎️ gong",
-		AuthorLink: "https://github.com/Djiit/gong",
-		Footer:     "Sent via gong",
-		FooterIcon: "https://github.com/favicon.ico",
-		Actions: []slack.AttachmentAction{
-			{
-				Type:  "button",
-				Text:  "Voir la PR",
-				URL:   prURL,
-				Style: "primary",
-			},
-		},
-	}
-
-	// Send to Slack using webhook
+	// Send to Slack using webhook, the text being used in notifications
 	slackMessage := &slack.WebhookMessage{
-		Channel:     channel,
-		Text:        fmt.Sprintf("Review requested on PR #%s", prNumber),
-		Attachments: []slack.Attachment{attachment},
+		Channel: channel,
+		Text:    message.Text,
+		Blocks:  &slack.Blocks{BlockSet: message.Blocks},
 	}
 
 	err := slack.PostWebhook(webhookURL, slackMessage)
@@ -169,6 +169,53 @@ func TestRun(t *testing.T) {
 			expectWebhook:  true,
 			expectContains: "reviewer1, reviewer2",
 		},
+		{
+			name: "With blocks template",
+			pingRequests: []ping.PingRequest{
+				{
+					Req:        githubclient.ReviewRequest{From: "reviewer1", On: now.Add(-2 * time.Hour)},
+					Enabled:    true,
+					ShouldPing: true,
+					Integrations: []ping.Integration{
+						{
+							Type: "slack",
+							Parameters: map[string]string{
+								"blocks_template": `[{"type": "section", "text": {"type": "mrkdwn", "text": "Custom block for PR #{{ .PRNumber }}"}}]`,
+							},
+						},
+					},
+				},
+			},
+			repoOwner:      "owner",
+			repoName:       "repo",
+			prNumber:       "123",
+			isDryRun:       false,
+			expectWebhook:  true,
+			expectContains: "Custom block for PR #123",
+		},
+		{
+			name: "With invalid blocks template",
+			pingRequests: []ping.PingRequest{
+				{
+					Req:        githubclient.ReviewRequest{From: "reviewer1", On: now.Add(-2 * time.Hour)},
+					Enabled:    true,
+					ShouldPing: true,
+					Integrations: []ping.Integration{
+						{
+							Type: "slack",
+							Parameters: map[string]string{
+								"blocks_template": `[{"type": "section", "text": "unterminated}]`,
+							},
+						},
+					},
+				},
+			},
+			repoOwner:     "owner",
+			repoName:      "repo",
+			prNumber:      "123",
+			isDryRun:      false,
+			expectWebhook: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -237,6 +284,21 @@ func TestFormatWithTemplate(t *testing.T) {
 			expected:   "PR #123 is waiting for review: <https://github.com/owner/repo/pull/123|owner/repo#123>\nReviewers: reviewer1, team1 (team)",
 			shouldWork: true,
 		},
+		{
+			name: "Reviewers with chat IDs",
+			requests: []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "reviewer1", On: now.Add(-1 * time.Hour)}, Enabled: true, ShouldPing: true, ChatID: "U012AB3CD"},
+				{Req: githubclient.ReviewRequest{From: "team1", On: now.Add(-2 * time.Hour), IsTeam: true}, Enabled: true, ShouldPing: true, ChatID: "S0614TZR7"},
+				{Req: githubclient.ReviewRequest{From: "reviewer2", On: now.Add(-1 * time.Hour)}, Enabled: true, ShouldPing: true},
+			},
+			template:   DefaultTemplate,
+			repoOwner:  "owner",
+			repoName:   "repo",
+			prNumber:   "123",
+			prURL:      "https://github.com/owner/repo/pull/123",
+			expected:   "PR #123 is waiting for review: <https://github.com/owner/repo/pull/123|owner/repo#123>\nReviewers: <@U012AB3CD>, <!subteam^S0614TZR7>, reviewer2",
+			shouldWork: true,
+		},
 		{
 			name: "Disabled reviewers only",
 			requests: []ping.PingRequest{
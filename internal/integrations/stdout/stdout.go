@@ -24,6 +24,9 @@ No pending review requests.
 {{- end -}}
 `
 
+// DefaultAuthorTemplate is the default template used for stdout output when nudging the PR author
+const DefaultAuthorTemplate = `Nudging author: {{ .Author }} (waiting on: {{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
 func Run(ctx context.Context) {
 	pingRequests := ctx.Value("pingRequests").([]ping.PingRequest)
 	isDryRun := ctx.Value("dry-run").(bool)
@@ -31,12 +34,18 @@ func Run(ctx context.Context) {
 	// Get template parameter from integrations config
 	var templateStr string
 
+	// Author nudges have their own template
+	templateKey, defaultTemplate := "template", DefaultTemplate
+	if ping.IsAuthorNudge(pingRequests) {
+		templateKey, defaultTemplate = "authorTemplate", DefaultAuthorTemplate
+	}
+
 	// First check if there's a template in the integration parameters
 	if len(pingRequests) > 0 {
 		for _, intg := range pingRequests[0].Integrations {
 			if intg.Type == "stdout" {
 				// Look for template parameter
-				if tmpl, ok := intg.Parameters["template"]; ok && tmpl != "" {
+				if tmpl, ok := intg.Parameters[templateKey]; ok && tmpl != "" {
 					templateStr = tmpl
 					break
 				}
@@ -46,10 +55,10 @@ func Run(ctx context.Context) {
 
 	// If no template found in integration parameters, try context
 	if templateStr == "" {
-		if val, ok := ctx.Value("template").(string); ok && val != "" {
+		if val, ok := ctx.Value(templateKey).(string); ok && val != "" {
 			templateStr = val
 		} else {
-			templateStr = DefaultTemplate
+			templateStr = defaultTemplate
 		}
 	}
 
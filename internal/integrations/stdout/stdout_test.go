@@ -71,6 +71,15 @@ func TestFormatWithTemplate(t *testing.T) {
 			template: "{{range .PingRequests}}Reviewer: {{.Req.From}}, Delay: {{.Delay}}s{{end}}",
 			expected: "Reviewer: reviewer1, Delay: 3600s",
 		},
+		{
+			name: "Author nudge with default author template",
+			requests: []ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "author", On: now.Add(-1 * time.Hour)}, Enabled: true, ShouldPing: true, AuthorNudge: true,
+					WaitingOnAuthor: []string{githubclient.AuthorReasonChangesRequested, githubclient.AuthorReasonChecksFailing}},
+			},
+			template: DefaultAuthorTemplate,
+			expected: "Nudging author: author (waiting on: changes requested, checks failing)",
+		},
 	}
 
 	for _, tc := range testCases {
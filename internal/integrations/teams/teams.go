@@ -0,0 +1,180 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTemplate is the default template of the text of Teams cards
+const DefaultTemplate = `PR #{{.PRNumber}} is waiting for review: [{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}]({{.PRURL}})
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template of the text of Teams cards nudging the PR author
+const DefaultAuthorTemplate = `PR #{{.PRNumber}} is waiting on its author: [{{.RepoOwner}}/{{.RepoName}}#{{.PRNumber}}]({{.PRURL}})
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} ({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ $r }}{{ end }})`
+
+// payload is the message accepted by Teams incoming webhooks and Workflows webhooks
+type payload struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string `json:"contentType"`
+	Content     card   `json:"content"`
+}
+
+// card is an Adaptive Card
+type card struct {
+	Schema  string        `json:"$schema"`
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Body    []interface{} `json:"body"`
+	Actions []action      `json:"actions,omitempty"`
+	MSTeams *msTeams      `json:"msteams,omitempty"`
+}
+
+type textBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Weight string `json:"weight,omitempty"`
+}
+
+type factSet struct {
+	Type  string `json:"type"`
+	Facts []fact `json:"facts"`
+}
+
+type fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// msTeams holds the Teams specific properties of a card
+type msTeams struct {
+	Width    string   `json:"width,omitempty"`
+	Entities []entity `json:"entities,omitempty"`
+}
+
+// entity declares a <at>Name</at> tag of the card text, so that Teams notifies the person
+type entity struct {
+	Type      string    `json:"type"`
+	Text      string    `json:"text"`
+	Mentioned mentioned `json:"mentioned"`
+}
+
+type mentioned struct {
+	ID   string `json:"id"` // User principal name or Microsoft Entra object ID
+	Name string `json:"name"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "teams")
+	if !ok {
+		return
+	}
+
+	// Teams webhooks post to a single channel, so rules may use their own
+	webhookURL := n.Param("webhook", "teams-webhook")
+	if webhookURL == "" {
+		log.Error().Msg("No Teams webhook URL found in configuration. Skipping Teams notifications.")
+		return
+	}
+
+	title := fmt.Sprintf("Review requested on PR #%s", n.PRNumber)
+	if n.AuthorNudge {
+		title = fmt.Sprintf("PR #%s is waiting on its author", n.PRNumber)
+	}
+
+	data := n.TemplateData()
+	message, err := format.FormatTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), data, mention)
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Teams message with template")
+		return
+	}
+
+	if n.DryRun {
+		n.LogDryRun("send Teams notification", message)
+		return
+	}
+
+	// Incoming webhooks answer 200 and Workflows 202
+	log.Debug().Msg("Sending Teams notification via webhook")
+	if _, err := notify.PostJSON(ctx, "teams webhook", webhookURL, newPayload(title, message, data), nil); err != nil {
+		log.Error().Err(err).Msg("Error sending Teams notification")
+	}
+}
+
+// mention formats a reviewer for Teams. Reviewers with a chat ID, their user principal name or
+// Microsoft Entra object ID, become <at> tags that newPayload declares as mentions.
+func mention(m format.Mention) string {
+	if m.ChatID == "" || m.IsTeam {
+		return m.Name
+	}
+	return "<at>" + m.Name + "</at>"
+}
+
+// newPayload builds the Adaptive Card of a notification: the title, the rendered message, the
+// pull request title, repository, age and reviewers, and a button to open the pull request
+func newPayload(title, message string, data format.TemplateData) payload {
+	facts := []fact{{Title: "Repository", Value: data.RepoOwner + "/" + data.RepoName}}
+	if data.PRTitle != "" {
+		facts = append([]fact{{Title: "Pull request", Value: data.PRTitle}}, facts...)
+	}
+	if data.PRAuthor != "" {
+		facts = append(facts, fact{Title: "Author", Value: data.PRAuthor})
+	}
+	if data.Age != "" {
+		facts = append(facts, fact{Title: "Waiting for", Value: data.Age})
+	}
+	if len(data.ActiveReviewers) > 0 {
+		facts = append(facts, fact{Title: "Reviewers", Value: strings.Join(data.ActiveReviewers, ", ")})
+	}
+	if len(data.Labels) > 0 {
+		facts = append(facts, fact{Title: "Labels", Value: strings.Join(data.Labels, ", ")})
+	}
+
+	c := card{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []interface{}{
+			textBlock{Type: "TextBlock", Text: title, Wrap: true, Size: "Medium", Weight: "Bolder"},
+			// Adaptive Cards need blank lines to break lines in markdown
+			textBlock{Type: "TextBlock", Text: strings.ReplaceAll(message, "\n", "\n\n"), Wrap: true},
+			factSet{Type: "FactSet", Facts: facts},
+		},
+		Actions: []action{{Type: "Action.OpenUrl", Title: "View pull request", URL: data.PRURL}},
+		MSTeams: &msTeams{Width: "Full"},
+	}
+
+	// Only declare the mentions the message actually contains, Teams rejects the others
+	for _, m := range data.Mentions {
+		tag := mention(m)
+		if tag != m.Name && strings.Contains(message, tag) {
+			c.MSTeams.Entities = append(c.MSTeams.Entities, entity{
+				Type:      "mention",
+				Text:      tag,
+				Mentioned: mentioned{ID: m.ChatID, Name: m.Name},
+			})
+		}
+	}
+
+	return payload{
+		Type:        "message",
+		Attachments: []attachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: c}},
+	}
+}
@@ -0,0 +1,129 @@
+package teams
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		params        map[string]string
+		globalWebhook bool
+		isDryRun      bool
+		expectPosted  bool
+		expectText    string
+	}{
+		{
+			name:          "Default card",
+			params:        map[string]string{},
+			globalWebhook: true,
+			expectPosted:  true,
+			expectText:    "PR #123 is waiting for review: [owner/repo#123](https://github.com/owner/repo/pull/123)\n\nReviewers: <at>alice</at>, bob",
+		},
+		{
+			name:         "Webhook from the parameters",
+			params:       map[string]string{"webhook": "stand-in", "template": "Please review PR #{{ .PRNumber }}"},
+			expectPosted: true,
+			expectText:   "Please review PR #123",
+		},
+		{
+			name:          "Dry run",
+			params:        map[string]string{},
+			globalWebhook: true,
+			isDryRun:      true,
+		},
+		{
+			name:   "No webhook",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := notifytest.NewStandIn[payload](t, notifytest.Answer(http.StatusAccepted, ""))
+
+			viper.Reset()
+			defer viper.Reset()
+			if tc.globalWebhook {
+				viper.Set("teams-webhook", standIn.URL)
+			}
+			params := make(map[string]string)
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			if params["webhook"] == "stand-in" {
+				params["webhook"] = standIn.URL
+			}
+
+			integrations := []ping.Integration{{Type: "teams", Parameters: params}}
+			Run(notifytest.NewContext([]ping.PingRequest{
+				{Req: githubclient.ReviewRequest{From: "alice", On: now.Add(-3 * time.Hour), PRTitle: "Add Teams", PRAuthor: "dave"}, ShouldPing: true, ChatID: "alice@example.com", Integrations: integrations},
+				{Req: githubclient.ReviewRequest{From: "bob", On: now.Add(-2 * time.Hour)}, ShouldPing: true, Integrations: integrations},
+			}, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			messages := standIn.Bodies()
+			if !assert.Len(t, messages, 1) {
+				return
+			}
+
+			message := messages[0]
+			assert.Equal(t, "message", message.Type)
+			assert.Equal(t, "application/vnd.microsoft.card.adaptive", message.Attachments[0].ContentType)
+
+			c := message.Attachments[0].Content
+			assert.Equal(t, "AdaptiveCard", c.Type)
+			assert.Equal(t, []action{{Type: "Action.OpenUrl", Title: "View pull request", URL: "https://github.com/owner/repo/pull/123"}}, c.Actions)
+
+			body, _ := json.Marshal(c.Body)
+			var blocks []map[string]interface{}
+			assert.NoError(t, json.Unmarshal(body, &blocks))
+			assert.Equal(t, "Review requested on PR #123", blocks[0]["text"])
+			assert.Equal(t, tc.expectText, blocks[1]["text"])
+
+			facts, _ := json.Marshal(blocks[2]["facts"])
+			assert.JSONEq(t, `[
+				{"title": "Pull request", "value": "Add Teams"},
+				{"title": "Repository", "value": "owner/repo"},
+				{"title": "Author", "value": "dave"},
+				{"title": "Waiting for", "value": "3h"},
+				{"title": "Reviewers", "value": "alice, bob"},
+				{"title": "Labels", "value": "bug"}
+			]`, string(facts))
+		})
+	}
+}
+
+func TestNewPayloadMentions(t *testing.T) {
+	standIn := notifytest.NewStandIn[payload](t, nil)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("teams-webhook", standIn.URL)
+
+	integrations := []ping.Integration{{Type: "teams"}}
+	Run(notifytest.NewContext([]ping.PingRequest{
+		{Req: githubclient.ReviewRequest{From: "alice", On: time.Now()}, ShouldPing: true, ChatID: "alice@example.com", Integrations: integrations},
+		{Req: githubclient.ReviewRequest{From: "backend", On: time.Now(), IsTeam: true}, ShouldPing: true, ChatID: "team-id", Integrations: integrations},
+	}, false))
+
+	if messages := standIn.Bodies(); assert.Len(t, messages, 1) {
+		// Only reviewers mentioned with an <at> tag are declared
+		assert.Equal(t, &msTeams{Width: "Full", Entities: []entity{
+			{Type: "mention", Text: "<at>alice</at>", Mentioned: mentioned{ID: "alice@example.com", Name: "alice"}},
+		}}, messages[0].Attachments[0].Content.MSTeams)
+	}
+}
@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// DefaultTemplate is the default template used for Telegram messages, written in MarkdownV2
+const DefaultTemplate = `PR \#{{ escape .PRNumber }} is waiting for review: [{{ escape .RepoOwner }}/{{ escape .RepoName }}\#{{ escape .PRNumber }}]({{ escapeURL .PRURL }}){{ if .PRTitle }}
+*{{ escape .PRTitle }}*{{ end }}
+{{ if .Mentions }}Reviewers: {{ range $i, $m := .Mentions }}{{ if $i }}, {{ end }}{{ mention $m }}{{ end }}{{ end }}`
+
+// DefaultAuthorTemplate is the default template used for Telegram messages nudging the PR author
+const DefaultAuthorTemplate = `PR \#{{ escape .PRNumber }} is waiting on its author: [{{ escape .RepoOwner }}/{{ escape .RepoName }}\#{{ escape .PRNumber }}]({{ escapeURL .PRURL }})
+Author: {{ range .Mentions }}{{ mention . }}{{ end }} \({{ range $i, $r := .WaitingOnAuthor }}{{ if $i }}, {{ end }}{{ escape $r }}{{ end }}\)`
+
+// DefaultAPIURL is the root of the Telegram Bot API
+const DefaultAPIURL = "https://api.telegram.org"
+
+// markdownReplacer escapes the characters MarkdownV2 reserves, everywhere but in link URLs
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, `_`, `\_`, `*`, `\*`, `[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `~`, `\~`,
+	"`", "\\`", `>`, `\>`, `#`, `\#`, `+`, `\+`, `-`, `\-`, `=`, `\=`, `|`, `\|`,
+	`{`, `\{`, `}`, `\}`, `.`, `\.`, `!`, `\!`,
+)
+
+// urlReplacer escapes the characters MarkdownV2 reserves in link URLs
+var urlReplacer = strings.NewReplacer(`\`, `\\`, `)`, `\)`)
+
+// message is the request of the sendMessage method
+type message struct {
+	ChatID              string `json:"chat_id"`
+	MessageThreadID     int    `json:"message_thread_id,omitempty"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+	LinkPreviewOptions  struct {
+		IsDisabled bool `json:"is_disabled"`
+	} `json:"link_preview_options"`
+}
+
+// response is the answer of the Bot API
+type response struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "telegram")
+	if !ok {
+		return
+	}
+
+	msg := message{ParseMode: "MarkdownV2", ChatID: n.Param("chat_id", "telegram-chat-id")}
+	msg.LinkPreviewOptions.IsDisabled = true
+	// The bot token is only read from the configuration, so that it can be kept in the environment
+	token := viper.GetString("telegram-token")
+	if token == "" || msg.ChatID == "" {
+		log.Error().Msg("No Telegram bot token or chat ID found in configuration. Skipping Telegram notifications.")
+		return
+	}
+	if threadID := n.Params["thread_id"]; threadID != "" {
+		id, err := strconv.Atoi(threadID)
+		if err != nil {
+			log.Warn().Msgf("Invalid Telegram thread ID %q, posting to the main thread", threadID)
+		}
+		msg.MessageThreadID = id
+	}
+	msg.DisableNotification = n.Params["silent"] == "true"
+
+	text, err := formatWithTemplate(n.MessageTemplate(ctx, DefaultTemplate, DefaultAuthorTemplate), n.TemplateData())
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting Telegram message with template")
+		return
+	}
+	msg.Text = text
+
+	if n.DryRun {
+		n.LogDryRun("send Telegram message to chat "+msg.ChatID, text)
+		return
+	}
+
+	apiURL := viper.GetString("telegram-api-url")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if err := sendMessage(ctx, strings.TrimSuffix(apiURL, "/"), token, msg); err != nil {
+		log.Error().Err(err).Msg("Error sending Telegram notification")
+	}
+}
+
+// formatWithTemplate renders a MarkdownV2 template. Templates escape the data themselves with
+// escape, or escapeURL in link URLs, since Telegram rejects messages with unescaped characters.
+func formatWithTemplate(templateStr string, data format.TemplateData) (string, error) {
+	tmpl, err := template.New("telegram").Funcs(template.FuncMap{
+		"escape":    Escape,
+		"escapeURL": urlReplacer.Replace,
+		"mention":   mention,
+	}).Parse(templateStr)
+	if err != nil {
+		return "", fmt.Errorf("template parsing error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("template execution error: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Escape escapes text for MarkdownV2, so that it shows as is
+func Escape(text string) string {
+	return markdownReplacer.Replace(text)
+}
+
+// mention formats a reviewer in MarkdownV2. A numeric chat ID is a Telegram user ID, linked so
+// that the user is notified even without a username. Other chat IDs are usernames.
+func mention(m format.Mention) string {
+	switch {
+	case m.ChatID == "" || m.IsTeam:
+		return Escape(m.Name)
+	case isUserID(m.ChatID):
+		return fmt.Sprintf("[%s](tg://user?id=%s)", Escape(m.Name), m.ChatID)
+	default:
+		return Escape("@" + strings.TrimPrefix(m.ChatID, "@"))
+	}
+}
+
+// isUserID reports whether a chat ID is a numeric Telegram user ID
+func isUserID(chatID string) bool {
+	_, err := strconv.ParseUint(chatID, 10, 64)
+	return err == nil
+}
+
+func sendMessage(ctx context.Context, apiURL, token string, msg message) error {
+	log.Debug().Msg("Sending Telegram notification via the Bot API")
+
+	resp, err := notify.PostJSON(ctx, "telegram", apiURL+"/bot"+token+"/sendMessage", msg, nil)
+	if resp == nil {
+		// The URL holds the bot token, keep it out of the logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("calling the Telegram Bot API: %w", err)
+	}
+
+	var result response
+	if err := json.Unmarshal(resp.Body, &result); err != nil || !result.OK {
+		description := result.Description
+		if description == "" {
+			description = strings.TrimSpace(string(resp.Body))
+		}
+		return fmt.Errorf("telegram returned %d: %s", resp.StatusCode, description)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTelegramStandIn starts a stand-in of the Bot API, which knows the bot token "123:secret"
+func newTelegramStandIn(t *testing.T) *notifytest.StandIn[message] {
+	return notifytest.NewStandIn[message](t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot123:secret/sendMessage" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok": false, "error_code": 401, "description": "Unauthorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "result": {"message_id": 1}}`))
+	})
+}
+
+// newTestContext returns the context of a run on my-org/repo, whose owner needs escaping
+func newTestContext(pingRequests []ping.PingRequest, isDryRun bool) context.Context {
+	ctx := notifytest.NewContext(pingRequests, isDryRun)
+	ctx = context.WithValue(ctx, "repoOwner", "my-org")
+	return context.WithValue(ctx, "prURL", "https://github.com/my-org/repo/pull/123")
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name         string
+		params       map[string]string
+		chatID       string
+		isDryRun     bool
+		expectPosted bool
+		expected     message
+	}{
+		{
+			name:         "Default message",
+			params:       map[string]string{},
+			chatID:       "-100200",
+			expectPosted: true,
+			expected: message{
+				ChatID: "-100200",
+				Text: "PR \\#123 is waiting for review: [my\\-org/repo\\#123](https://github.com/my-org/repo/pull/123)\n" +
+					"*Fix the \\(flaky\\) build\\!*\n" +
+					"Reviewers: [alice](tg://user?id=4242), @bob\\_smith, carol",
+			},
+		},
+		{
+			name:         "Chat, thread and template from parameters",
+			params:       map[string]string{"chat_id": "@reviews", "thread_id": "7", "silent": "true", "template": "Review {{ escape .PRTitle }}"},
+			expectPosted: true,
+			expected: message{
+				ChatID:              "@reviews",
+				MessageThreadID:     7,
+				DisableNotification: true,
+				Text:                "Review Fix the \\(flaky\\) build\\!",
+			},
+		},
+		{
+			name:     "Dry run",
+			params:   map[string]string{},
+			chatID:   "-100200",
+			isDryRun: true,
+		},
+		{
+			name:   "No chat ID",
+			params: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newTelegramStandIn(t)
+			viper.Reset()
+			defer viper.Reset()
+			viper.Set("telegram-api-url", standIn.URL)
+			viper.Set("telegram-token", "123:secret")
+			viper.Set("telegram-chat-id", tc.chatID)
+
+			integrations := []ping.Integration{{Type: "telegram", Parameters: tc.params}}
+			newRequest := func(from, chatID string) ping.PingRequest {
+				return ping.PingRequest{
+					Req:          githubclient.ReviewRequest{From: from, On: time.Now(), PRTitle: "Fix the (flaky) build!"},
+					ShouldPing:   true,
+					ChatID:       chatID,
+					Integrations: integrations,
+				}
+			}
+			pingRequests := []ping.PingRequest{newRequest("alice", "4242"), newRequest("bob", "bob_smith"), newRequest("carol", "")}
+
+			Run(newTestContext(pingRequests, tc.isDryRun))
+
+			if !tc.expectPosted {
+				assert.Empty(t, standIn.Requests())
+				return
+			}
+			if messages := standIn.Bodies(); assert.Len(t, messages, 1) {
+				tc.expected.ParseMode = "MarkdownV2"
+				tc.expected.LinkPreviewOptions.IsDisabled = true
+				assert.Equal(t, tc.expected, messages[0])
+			}
+		})
+	}
+}
+
+func TestRunAuthorNudge(t *testing.T) {
+	standIn := newTelegramStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("telegram-api-url", standIn.URL)
+	viper.Set("telegram-token", "123:secret")
+	viper.Set("telegram-chat-id", "-100200")
+
+	nudge := ping.PingRequest{
+		Req:             githubclient.ReviewRequest{From: "dave", On: time.Now()},
+		ShouldPing:      true,
+		AuthorNudge:     true,
+		ChatID:          "dave",
+		WaitingOnAuthor: []string{"changes requested by alice"},
+		Integrations:    []ping.Integration{{Type: "telegram"}},
+	}
+
+	Run(newTestContext([]ping.PingRequest{nudge}, false))
+
+	if messages := standIn.Bodies(); assert.Len(t, messages, 1) {
+		assert.Equal(t, "PR \\#123 is waiting on its author: [my\\-org/repo\\#123](https://github.com/my-org/repo/pull/123)\n"+
+			"Author: @dave \\(changes requested by alice\\)", messages[0].Text)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\_b\*c\[d\]\(e\)\~f\`+"`"+`g\>h\#i\+j\-k\=l\|m\{n\}o\.p\!q\\r`, Escape("a_b*c[d](e)~f`g>h#i+j-k=l|m{n}o.p!q\\r"))
+	assert.Equal(t, `https://example.com/a_(b\)`, urlReplacer.Replace("https://example.com/a_(b)"))
+	assert.Equal(t, "[a\\_b](tg://user?id=1)", mention(format.Mention{Name: "a_b", ChatID: "1"}))
+}
+
+func TestSendMessageError(t *testing.T) {
+	standIn := newTelegramStandIn(t)
+
+	err := sendMessage(context.Background(), standIn.URL, "123:wrong", message{ChatID: "1", Text: "hello"})
+
+	assert.EqualError(t, err, "telegram returned 401: Unauthorized")
+}
@@ -0,0 +1,372 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Djiit/gong/internal/format"
+	"github.com/Djiit/gong/internal/integrations/notify"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/rs/zerolog/log"
+)
+
+// Version is the version of the JSON document posted by the integration. It only changes when
+// fields are removed or change meaning, new fields can be added to any version.
+const Version = 1
+
+// Events of the documents
+const (
+	EventReviewReminder = "review_reminder"
+	EventAuthorNudge    = "author_nudge"
+)
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-Gong-Event"
+	HeaderDelivery  = "X-Gong-Delivery"
+	HeaderSignature = "X-Gong-Signature-256"
+)
+
+// Defaults of the timeout and retries parameters
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultRetries = 3
+)
+
+// initialBackoff is the wait before the first retry, doubled before each next one
+const initialBackoff = time.Second
+
+// wait pauses until d elapsed, replaced in tests
+var wait = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// document is the JSON document posted to the webhook
+type document struct {
+	Version     int         `json:"version"`
+	Event       string      `json:"event"`
+	DeliveryID  string      `json:"deliveryId"`
+	SentAt      time.Time   `json:"sentAt"`
+	DryRun      bool        `json:"dryRun,omitempty"`
+	PullRequest pullRequest `json:"pullRequest"`
+	Requests    []request   `json:"requests"`
+}
+
+type pullRequest struct {
+	Owner  string   `json:"owner"`
+	Repo   string   `json:"repo"`
+	Number string   `json:"number"`
+	URL    string   `json:"url"`
+	Title  string   `json:"title,omitempty"`
+	Author string   `json:"author,omitempty"`
+	Labels []string `json:"labels"`
+	Checks *checks  `json:"checks,omitempty"`
+}
+
+type checks struct {
+	State          string `json:"state,omitempty"`
+	RequiredState  string `json:"requiredState,omitempty"`
+	MergeableState string `json:"mergeableState,omitempty"`
+}
+
+// request is a review request of the pull request, or the author when nudging them
+type request struct {
+	Reviewer        string     `json:"reviewer"`
+	IsTeam          bool       `json:"isTeam"`
+	Team            string     `json:"team,omitempty"` // Team the reviewer was expanded from
+	ChatID          string     `json:"chatId,omitempty"`
+	RequestedAt     time.Time  `json:"requestedAt"`
+	DelaySeconds    int        `json:"delaySeconds"`
+	Status          string     `json:"status"`
+	Notify          bool       `json:"notify"` // Whether this delivery is about notifying them
+	Rule            string     `json:"rule,omitempty"`
+	ReviewState     string     `json:"reviewState,omitempty"`
+	LastActivity    *time.Time `json:"lastActivity,omitempty"`
+	SnoozedUntil    *time.Time `json:"snoozedUntil,omitempty"`
+	WaitingOnAuthor []string   `json:"waitingOnAuthor,omitempty"`
+}
+
+// templateData is what body templates are rendered with: the data of the other integrations, and
+// the document that is posted by default
+type templateData struct {
+	format.TemplateData
+	Document document
+}
+
+// delivery is a request to send, the same for every attempt
+type delivery struct {
+	url         string
+	body        []byte
+	contentType string
+	headers     http.Header
+}
+
+func Run(ctx context.Context) {
+	n, ok := notify.FromContext(ctx, "webhook")
+	if !ok {
+		return
+	}
+
+	webhookURL := n.Param("url", "webhook-url")
+	if webhookURL == "" {
+		log.Error().Msg("No webhook URL found in configuration. Skipping webhook notifications.")
+		return
+	}
+
+	doc := newDocument(ctx, n)
+
+	d := delivery{url: webhookURL, contentType: n.Params["content_type"], headers: parseHeaders(n.Params["headers"])}
+	if d.contentType == "" {
+		d.contentType = "application/json"
+	}
+
+	var err error
+	if templateStr := n.Params["template"]; templateStr != "" {
+		data := templateData{TemplateData: n.TemplateData(), Document: doc}
+		data.Labels = doc.PullRequest.Labels
+		d.body, err = renderBody(templateStr, data, d.contentType)
+	} else {
+		d.body, err = json.Marshal(doc)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Error formatting webhook body")
+		return
+	}
+
+	d.headers.Set(HeaderEvent, doc.Event)
+	d.headers.Set(HeaderDelivery, doc.DeliveryID)
+	if secret := n.Param("secret", "webhook-secret"); secret != "" {
+		d.headers.Set(HeaderSignature, Sign(secret, d.body))
+	}
+
+	if n.DryRun {
+		n.LogDryRun("post "+doc.Event+" to the webhook", string(d.body))
+		return
+	}
+
+	timeout := DefaultTimeout
+	if val := n.Params["timeout"]; val != "" {
+		if timeout, err = time.ParseDuration(val); err != nil || timeout <= 0 {
+			log.Warn().Msgf("Invalid webhook timeout %q, using %s", val, DefaultTimeout)
+			timeout = DefaultTimeout
+		}
+	}
+	retries := DefaultRetries
+	if val := n.Params["retries"]; val != "" {
+		if retries, err = strconv.Atoi(val); err != nil || retries < 0 {
+			log.Warn().Msgf("Invalid webhook retries %q, using %d", val, DefaultRetries)
+			retries = DefaultRetries
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+	if err := send(ctx, client, d, retries); err != nil {
+		log.Error().Err(err).Msg("Error sending webhook notification")
+	}
+}
+
+// newDocument describes the pull request and its review requests. Review reminders list every
+// review request, including those nobody is notified about, with why they are not.
+func newDocument(ctx context.Context, n *notify.Notification) document {
+	pingRequests := n.PingRequests
+	doc := document{
+		Version:    Version,
+		Event:      EventReviewReminder,
+		DeliveryID: newDeliveryID(),
+		SentAt:     time.Now().UTC(),
+		DryRun:     n.DryRun,
+		PullRequest: pullRequest{
+			Owner:  n.RepoOwner,
+			Repo:   n.RepoName,
+			Number: n.PRNumber,
+			URL:    n.PRURL,
+			Title:  pingRequests[0].Req.PRTitle,
+			Author: pingRequests[0].Req.PRAuthor,
+			Labels: []string{},
+		},
+		Requests: []request{},
+	}
+	if n.Labels != nil {
+		doc.PullRequest.Labels = n.Labels
+	}
+	for _, req := range pingRequests {
+		if req.Checks != nil {
+			doc.PullRequest.Checks = &checks{State: req.Checks.State, RequiredState: req.Checks.RequiredState, MergeableState: req.Checks.MergeableState}
+		}
+	}
+
+	all := pingRequests
+	if n.AuthorNudge {
+		doc.Event = EventAuthorNudge
+	} else if requests, ok := ctx.Value("allPingRequests").([]ping.PingRequest); ok && len(requests) > 0 {
+		all = requests
+	}
+
+	notified := make(map[string]bool)
+	for _, req := range pingRequests {
+		notified[req.Req.From] = true
+	}
+	for _, req := range all {
+		doc.Requests = append(doc.Requests, newRequest(req, notified[req.Req.From]))
+	}
+
+	return doc
+}
+
+func newRequest(req ping.PingRequest, notify bool) request {
+	r := request{
+		Reviewer:        req.Req.From,
+		IsTeam:          req.Req.IsTeam,
+		Team:            req.Req.Team,
+		ChatID:          req.ChatID,
+		RequestedAt:     req.Req.On.UTC(),
+		DelaySeconds:    req.Delay,
+		Status:          req.Status(),
+		Notify:          notify,
+		Rule:            req.Rule,
+		ReviewState:     req.Req.ReviewState,
+		WaitingOnAuthor: req.WaitingOnAuthor,
+	}
+	if !req.Req.LastActivity.IsZero() {
+		lastActivity := req.Req.LastActivity.UTC()
+		r.LastActivity = &lastActivity
+	}
+	if req.Snooze != nil {
+		until := req.Snooze.Until.UTC()
+		r.SnoozedUntil = &until
+	}
+	return r
+}
+
+// renderBody renders a body template. JSON bodies are validated so that a broken template is
+// reported instead of sent.
+func renderBody(templateStr string, data templateData, contentType string) ([]byte, error) {
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": jsonValue}).Parse(templateStr)
+	if err != nil {
+		return nil, fmt.Errorf("template parsing error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("template execution error: %w", err)
+	}
+
+	if strings.Contains(contentType, "json") && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template did not render valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonValue encodes a value as JSON, so that templates can embed any value safely
+func jsonValue(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parseHeaders reads custom headers, one "Name: value" per line
+func parseHeaders(lines string) http.Header {
+	headers := make(http.Header)
+	for _, line := range strings.Split(lines, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			if strings.TrimSpace(line) != "" {
+				log.Warn().Msgf("Ignoring invalid webhook header %q", line)
+			}
+			continue
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers
+}
+
+// Sign returns the signature header of a body: the hex HMAC-SHA256 of the body keyed with the
+// secret, prefixed with "sha256=" like GitHub webhooks
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newDeliveryID returns a random identifier of a delivery, the same for all its attempts so that
+// receivers can ignore duplicates
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// send posts a delivery, retrying with exponential backoff when the webhook cannot be reached,
+// times out, or answers 429 or a server error
+func send(ctx context.Context, client *http.Client, d delivery, retries int) error {
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := post(ctx, client, d)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == retries {
+			return fmt.Errorf("after %d attempts: %w", attempt+1, err)
+		}
+
+		log.Warn().Msgf("Webhook delivery failed (%v), retrying in %s", err, backoff)
+		if err := wait(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a delivery once, and reports whether a failure is worth retrying
+func post(ctx context.Context, client *http.Client, d delivery) (bool, error) {
+	log.Debug().Msg("Sending webhook notification")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	for name, values := range d.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", d.contentType)
+	req.Header.Set("User-Agent", "gong")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Debug().Msgf("Error closing response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return false, nil
+}
@@ -0,0 +1,317 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/integrations/notify/notifytest"
+	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// received is a delivery received by the webhook stand-in
+type received struct {
+	Header http.Header
+	Body   []byte
+}
+
+// webhookStandIn records the deliveries posted to a local webhook. Each response status is taken
+// from statuses in turn, the last one answering all further deliveries.
+type webhookStandIn struct {
+	URL string
+
+	mu         sync.Mutex
+	deliveries []received
+}
+
+func newWebhookStandIn(t *testing.T, statuses ...int) *webhookStandIn {
+	standIn := &webhookStandIn{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		standIn.mu.Lock()
+		standIn.deliveries = append(standIn.deliveries, received{Header: r.Header, Body: body})
+		n := len(standIn.deliveries)
+		standIn.mu.Unlock()
+
+		status := http.StatusNoContent
+		if len(statuses) > 0 {
+			status = statuses[min(n, len(statuses))-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	standIn.URL = server.URL
+
+	return standIn
+}
+
+func (s *webhookStandIn) received() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]received{}, s.deliveries...)
+}
+
+// recordWaits replaces wait for the duration of a test and returns the waits it was asked for
+func recordWaits(t *testing.T) *[]time.Duration {
+	waits := &[]time.Duration{}
+	original := wait
+	wait = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	t.Cleanup(func() { wait = original })
+	return waits
+}
+
+// newTestContext returns the context of a run notifying pingRequests among allPingRequests
+func newTestContext(pingRequests, allPingRequests []ping.PingRequest, isDryRun bool) context.Context {
+	return context.WithValue(notifytest.NewContext(pingRequests, isDryRun), "allPingRequests", allPingRequests)
+}
+
+func TestRun(t *testing.T) {
+	recordWaits(t)
+	standIn := newWebhookStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("webhook-url", standIn.URL)
+	viper.Set("webhook-secret", "s3cr3t")
+
+	requestedAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	integrations := []ping.Integration{{Type: "webhook", Parameters: map[string]string{
+		"headers": "Authorization: Bearer token\nX-Env: prod",
+	}}}
+	alice := ping.PingRequest{
+		Req:   githubclient.ReviewRequest{From: "alice", On: requestedAt, PRTitle: "Add webhooks", PRAuthor: "dave"},
+		Delay: 3600, Enabled: true, ShouldPing: true, ChatID: "U0ALICE", Rule: "leads", Integrations: integrations,
+	}
+	bob := ping.PingRequest{
+		Req:     githubclient.ReviewRequest{From: "bob", On: requestedAt, PRTitle: "Add webhooks", PRAuthor: "dave"},
+		Delay:   3600,
+		Enabled: true,
+		Snooze:  &state.Snooze{Until: requestedAt.Add(4 * time.Hour)},
+	}
+
+	Run(newTestContext([]ping.PingRequest{alice}, []ping.PingRequest{alice, bob}, false))
+
+	deliveries := standIn.received()
+	if !assert.Len(t, deliveries, 1) {
+		return
+	}
+	delivery := deliveries[0]
+
+	assert.Equal(t, "application/json", delivery.Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", delivery.Header.Get("Authorization"))
+	assert.Equal(t, "prod", delivery.Header.Get("X-Env"))
+	assert.Equal(t, EventReviewReminder, delivery.Header.Get(HeaderEvent))
+	assert.Equal(t, Sign("s3cr3t", delivery.Body), delivery.Header.Get(HeaderSignature))
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(delivery.Body, &doc))
+	assert.Equal(t, delivery.Header.Get(HeaderDelivery), doc["deliveryId"])
+	delete(doc, "deliveryId")
+	delete(doc, "sentAt")
+
+	assert.JSONEq(t, `{
+		"version": 1,
+		"event": "review_reminder",
+		"pullRequest": {
+			"owner": "owner", "repo": "repo", "number": "123",
+			"url": "https://github.com/owner/repo/pull/123",
+			"title": "Add webhooks", "author": "dave", "labels": ["bug"]
+		},
+		"requests": [
+			{
+				"reviewer": "alice", "isTeam": false, "chatId": "U0ALICE",
+				"requestedAt": "2025-03-01T09:00:00Z", "delaySeconds": 3600,
+				"status": "due", "notify": true, "rule": "leads"
+			},
+			{
+				"reviewer": "bob", "isTeam": false,
+				"requestedAt": "2025-03-01T09:00:00Z", "delaySeconds": 3600,
+				"status": "snoozed", "notify": false, "snoozedUntil": "2025-03-01T13:00:00Z"
+			}
+		]
+	}`, mustJSON(t, doc))
+}
+
+func TestRunWithTemplate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		params       map[string]string
+		expectPosted bool
+		expectBody   string
+	}{
+		{
+			name:         "JSON template",
+			params:       map[string]string{"template": `{"text": {{ json (printf "Please review %s" .PRURL) }}, "count": {{ len .Document.Requests }}}`},
+			expectPosted: true,
+			expectBody:   `{"text": "Please review https://github.com/owner/repo/pull/123", "count": 1}`,
+		},
+		{
+			name:         "Plain text template",
+			params:       map[string]string{"template": `PR #{{ .PRNumber }} needs {{ range .Mentions }}{{ .Name }}{{ end }}`, "content_type": "text/plain"},
+			expectPosted: true,
+			expectBody:   `PR #123 needs alice`,
+		},
+		{
+			name:   "Invalid JSON",
+			params: map[string]string{"template": `{"text": {{ .PRURL }}}`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := newWebhookStandIn(t)
+			viper.Reset()
+			defer viper.Reset()
+
+			params := map[string]string{"url": standIn.URL}
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			alice := ping.PingRequest{
+				Req:          githubclient.ReviewRequest{From: "alice", On: time.Now()},
+				ShouldPing:   true,
+				Enabled:      true,
+				Integrations: []ping.Integration{{Type: "webhook", Parameters: params}},
+			}
+
+			Run(newTestContext([]ping.PingRequest{alice}, []ping.PingRequest{alice}, false))
+
+			deliveries := standIn.received()
+			if !tc.expectPosted {
+				assert.Empty(t, deliveries)
+				return
+			}
+			if assert.Len(t, deliveries, 1) {
+				assert.Equal(t, tc.expectBody, string(deliveries[0].Body))
+				assert.Empty(t, deliveries[0].Header.Get(HeaderSignature))
+			}
+		})
+	}
+}
+
+func TestRunAuthorNudge(t *testing.T) {
+	standIn := newWebhookStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("webhook-url", standIn.URL)
+
+	reviewer := ping.PingRequest{Req: githubclient.ReviewRequest{From: "alice", On: time.Now()}, Enabled: true}
+	nudge := ping.PingRequest{
+		Req:             githubclient.ReviewRequest{From: "dave", On: time.Now()},
+		Enabled:         true,
+		ShouldPing:      true,
+		AuthorNudge:     true,
+		WaitingOnAuthor: []string{"changes requested by alice"},
+		Integrations:    []ping.Integration{{Type: "webhook"}},
+	}
+
+	Run(newTestContext([]ping.PingRequest{nudge}, []ping.PingRequest{reviewer}, false))
+
+	deliveries := standIn.received()
+	if assert.Len(t, deliveries, 1) {
+		var doc document
+		assert.NoError(t, json.Unmarshal(deliveries[0].Body, &doc))
+		assert.Equal(t, EventAuthorNudge, doc.Event)
+		// Only the author is reported
+		if assert.Len(t, doc.Requests, 1) {
+			assert.Equal(t, "dave", doc.Requests[0].Reviewer)
+			assert.Equal(t, []string{"changes requested by alice"}, doc.Requests[0].WaitingOnAuthor)
+		}
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	standIn := newWebhookStandIn(t)
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("webhook-url", standIn.URL)
+
+	alice := ping.PingRequest{Req: githubclient.ReviewRequest{From: "alice", On: time.Now()}, ShouldPing: true, Integrations: []ping.Integration{{Type: "webhook"}}}
+	Run(newTestContext([]ping.PingRequest{alice}, []ping.PingRequest{alice}, true))
+
+	assert.Empty(t, standIn.received())
+}
+
+func TestSendRetries(t *testing.T) {
+	testCases := []struct {
+		name            string
+		statuses        []int
+		retries         int
+		expectErr       bool
+		expectAttempts  int
+		expectedBackoff []time.Duration
+	}{
+		{
+			name:            "Server errors are retried with backoff",
+			statuses:        []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK},
+			retries:         3,
+			expectAttempts:  3,
+			expectedBackoff: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:            "Giving up",
+			statuses:        []int{http.StatusServiceUnavailable},
+			retries:         2,
+			expectErr:       true,
+			expectAttempts:  3,
+			expectedBackoff: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:            "Client errors are not retried",
+			statuses:        []int{http.StatusBadRequest},
+			retries:         3,
+			expectErr:       true,
+			expectAttempts:  1,
+			expectedBackoff: []time.Duration{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			waits := recordWaits(t)
+			standIn := newWebhookStandIn(t, tc.statuses...)
+			d := delivery{url: standIn.URL, body: []byte(`{}`), contentType: "application/json", headers: http.Header{}}
+
+			err := send(context.Background(), &http.Client{Timeout: time.Second}, d, tc.retries)
+
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, standIn.received(), tc.expectAttempts)
+			assert.Equal(t, tc.expectedBackoff, *waits)
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Computed with: printf '{"a":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494", Sign("secret", []byte(`{"a":1}`)))
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers := parseHeaders("Authorization: Bearer a:b\n\nnot a header\nX-Env:  prod ")
+
+	assert.Equal(t, http.Header{"Authorization": {"Bearer a:b"}, "X-Env": {"prod"}}, headers)
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -2,6 +2,7 @@ package ping
 
 import (
 	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/state"
 )
 
 // Integration represents a single integration configuration for a ping request
@@ -11,9 +12,88 @@ type Integration struct {
 }
 
 type PingRequest struct {
-	Req          githubclient.ReviewRequest
-	Delay        int           // The delay in seconds that applies to this reviewer
-	Enabled      bool          // Whether pinging this reviewer is enabled
-	ShouldPing   bool          // Whether this reviewer should be pinged (based on delay and enabled)
-	Integrations []Integration // List of integrations to use for this reviewer
+	Req                      githubclient.ReviewRequest
+	Delay                    int                        // The delay in seconds that applies to this reviewer
+	Enabled                  bool                       // Whether pinging this reviewer is enabled
+	SkipIfTeamMemberReviewed bool                       // Whether a team is skipped once one of its members reviewed
+	ResetDelayOnActivity     bool                       // Whether the delay restarts when the reviewer reviews or comments
+	RequireChecksPassing     bool                       // Whether all checks must pass before pinging
+	RequireRequiredChecks    bool                       // Whether the checks required by branch protection must pass before pinging
+	SkipIfConflicting        bool                       // Whether pull requests with merge conflicts are skipped
+	Checks                   *githubclient.ChecksStatus // CI status of the pull request, nil when unknown
+	WaitingOnAuthor          []string                   // Why the pull request is waiting on its author, when nudging authors
+	AuthorNudge              bool                       // Whether this request notifies the PR author instead of a reviewer
+	ChatIDs                  map[string]string          // Chat user IDs or group handles of the reviewer, by integration type
+	ChatID                   string                     // Chat ID of the reviewer on the platform of the integration notifying them, empty if unknown
+	Snooze                   *state.Snooze              // Snooze or acknowledgement of the reviewer from chat, nil if none is running
+	Rule                     string                     // Rule that applied to this reviewer, empty when the global settings did
+	ShouldPing               bool                       // Whether this reviewer should be pinged (based on delay and enabled)
+	Integrations             []Integration              // List of integrations to use for this reviewer
+}
+
+// TeamMemberReviewed reports whether this is a team request that a member of the team already
+// reviewed since it was requested, and the rules say to skip such teams
+func (p PingRequest) TeamMemberReviewed() bool {
+	return p.SkipIfTeamMemberReviewed && p.Req.IsTeam &&
+		p.Req.ReviewState != githubclient.ReviewStateNone && p.Req.ReviewState != "" &&
+		!p.Req.LastActivity.Before(p.Req.On)
+}
+
+// ForIntegration returns the ping requests as an integration sees them: with the chat ID of each
+// reviewer on the platform of the integration
+func ForIntegration(pingRequests []PingRequest, integrationType string) []PingRequest {
+	requests := make([]PingRequest, len(pingRequests))
+	for i, req := range pingRequests {
+		req.ChatID = req.ChatIDs[integrationType]
+		requests[i] = req
+	}
+	return requests
+}
+
+// IsAuthorNudge reports whether the given ping requests notify the PR author rather than reviewers
+func IsAuthorNudge(pingRequests []PingRequest) bool {
+	return len(pingRequests) > 0 && pingRequests[0].AuthorNudge
+}
+
+// ChecksBlocking returns why the CI status or mergeability of the pull request prevents pinging
+// this reviewer, or an empty string if it does not
+func (p PingRequest) ChecksBlocking() string {
+	if p.Checks == nil {
+		return ""
+	}
+
+	switch {
+	case p.SkipIfConflicting && p.Checks.Conflicting():
+		return "conflicting"
+	case p.RequireChecksPassing && p.Checks.State == githubclient.CheckStateFailure,
+		p.RequireRequiredChecks && p.Checks.RequiredState == githubclient.CheckStateFailure:
+		return "checks failing"
+	case p.RequireChecksPassing && p.Checks.State == githubclient.CheckStatePending,
+		p.RequireRequiredChecks && p.Checks.RequiredState == githubclient.CheckStatePending:
+		return "checks pending"
+	}
+
+	return ""
+}
+
+// Status returns why this reviewer is or is not pinged: "due" when they are, otherwise the first
+// reason that leaves them alone, or "waiting" while the delay has not elapsed
+func (p PingRequest) Status() string {
+	switch {
+	case p.ShouldPing:
+		return "due"
+	case !p.Enabled:
+		return "disabled"
+	case p.TeamMemberReviewed():
+		return "reviewed"
+	case p.ChecksBlocking() != "":
+		return p.ChecksBlocking()
+	case len(p.WaitingOnAuthor) > 0:
+		return "waiting on author"
+	case p.Snooze != nil && p.Snooze.Acknowledged:
+		return "acknowledged"
+	case p.Snooze != nil:
+		return "snoozed"
+	}
+	return "waiting"
 }
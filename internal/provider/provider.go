@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Djiit/gong/internal/bitbucketclient"
+	"github.com/Djiit/gong/internal/giteaclient"
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/Djiit/gong/internal/gitlabclient"
+	"github.com/spf13/viper"
+)
+
+// NewFromConfig creates the code host provider selected by the provider setting (github by default)
+func NewFromConfig() (githubclient.Provider, error) {
+	opts := githubclient.TransportOptionsFromConfig()
+
+	switch name := viper.GetString("provider"); name {
+	case "", "github":
+		client, _, err := githubclient.NewClientFromConfig()
+		if err != nil {
+			return nil, err
+		}
+		return githubclient.NewGitHubProvider(client), nil
+	case "gitlab":
+		token := firstNonEmpty(viper.GetString("gitlab-token"), os.Getenv("GITLAB_TOKEN"))
+		if token == "" {
+			return nil, fmt.Errorf("no GitLab token found: use --gitlab-token, set GONG_GITLAB_TOKEN or GITLAB_TOKEN")
+		}
+		return gitlabclient.NewClient(viper.GetString("gitlab-url"), token, opts)
+	case "gitea", "forgejo":
+		token := firstNonEmpty(viper.GetString("gitea-token"), os.Getenv("GITEA_TOKEN"), os.Getenv("FORGEJO_TOKEN"))
+		if token == "" {
+			return nil, fmt.Errorf("no Gitea token found: use --gitea-token, set GONG_GITEA_TOKEN, GITEA_TOKEN or FORGEJO_TOKEN")
+		}
+		return giteaclient.NewClient(viper.GetString("gitea-url"), token, opts)
+	case "bitbucket":
+		token := firstNonEmpty(viper.GetString("bitbucket-token"), os.Getenv("BITBUCKET_TOKEN"))
+		if token == "" {
+			return nil, fmt.Errorf("no Bitbucket token found: use --bitbucket-token, set GONG_BITBUCKET_TOKEN or BITBUCKET_TOKEN")
+		}
+		return bitbucketclient.NewClient(viper.GetString("bitbucket-url"), token, opts)
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", name)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
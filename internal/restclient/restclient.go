@@ -0,0 +1,153 @@
+package restclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/rs/zerolog/log"
+)
+
+// Client sends JSON requests to the REST API of a code host. It is shared by the GitLab, Gitea and
+// Bitbucket clients, which only differ by how they authenticate and report errors.
+type Client struct {
+	Service    string   // Name of the code host in errors, e.g. "gitlab"
+	BaseURL    *url.URL // API root, which request paths are resolved against
+	HTTPClient *http.Client
+
+	// Authenticate sets the credentials of a request
+	Authenticate func(req *http.Request)
+	// ErrorMessage extracts the message of an error response, if set
+	ErrorMessage func(body []byte) string
+}
+
+// ErrorResponse is returned when the API answers with an error status
+type ErrorResponse struct {
+	Service    string
+	StatusCode int
+	Message    string
+}
+
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("%s API error %d: %s", e.Service, e.StatusCode, e.Message)
+}
+
+// NewHTTPClient returns an HTTP client going through the transport shared with the GitHub client,
+// which retries reads and enforces the API call budget
+func NewHTTPClient(opts githubclient.TransportOptions) *http.Client {
+	return &http.Client{Transport: githubclient.NewTransport(http.DefaultTransport, opts)}
+}
+
+// Do sends a request to the API and decodes the JSON response into v, if not nil
+func (c *Client) Do(ctx context.Context, method, path string, body interface{}, v interface{}) error {
+	_, data, err := c.Request(ctx, method, path, body)
+	if err != nil || v == nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Request sends a request to the API, encoding body as JSON if not nil, and returns the response
+// along with its body. Error statuses are returned as an *ErrorResponse.
+func (c *Client) Request(ctx context.Context, method, path string, body interface{}) (*http.Response, []byte, error) {
+	u, err := c.BaseURL.Parse(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Authenticate != nil {
+		c.Authenticate(req)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Debug().Msgf("Error closing response body: %v", err)
+		}
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		errResp := &ErrorResponse{Service: c.Service, StatusCode: resp.StatusCode}
+		if c.ErrorMessage != nil {
+			errResp.Message = c.ErrorMessage(data)
+		}
+		return resp, nil, errResp
+	}
+
+	return resp, data, nil
+}
+
+// Paginate requests every page of a list endpoint, starting with path. handle is called with each
+// page and returns the path of the next one, or an empty string after the last page.
+func (c *Client) Paginate(ctx context.Context, path string, handle func(resp *http.Response, data []byte) (string, error)) error {
+	for path != "" {
+		resp, data, err := c.Request(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return err
+		}
+		if path, err = handle(resp, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithQuery appends query parameters to a path which may already have some
+func WithQuery(path, query string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + query
+	}
+	return path + "?" + query
+}
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>\s*;[^,]*\brel="?next"?`)
+
+// NextLink returns the URL of the next page given by the Link header of a response, if any
+func NextLink(resp *http.Response) string {
+	for _, header := range resp.Header.Values("Link") {
+		if m := nextLinkPattern.FindStringSubmatch(header); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// NotFound maps a 404 from the API onto githubclient.ErrPullRequestNotFound
+func NotFound(err error) error {
+	if errResp, ok := err.(*ErrorResponse); ok && errResp.StatusCode == http.StatusNotFound {
+		return githubclient.ErrPullRequestNotFound
+	}
+	return err
+}
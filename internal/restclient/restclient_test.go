@@ -0,0 +1,98 @@
+package restclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/Djiit/gong/internal/githubclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	mockServer := httptest.NewServer(handler)
+	t.Cleanup(mockServer.Close)
+
+	baseURL, _ := url.Parse(mockServer.URL + "/api/")
+	return &Client{
+		Service:      "forge",
+		BaseURL:      baseURL,
+		HTTPClient:   NewHTTPClient(githubclient.TransportOptions{}),
+		Authenticate: func(req *http.Request) { req.Header.Set("Authorization", "token secret") },
+		ErrorMessage: func(body []byte) string { return string(body) },
+	}
+}
+
+func TestDo(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "/api/repos/owner/repo", r.URL.Path)
+		_, _ = w.Write([]byte(`{"name": "repo"}`))
+	})
+
+	var repo struct {
+		Name string `json:"name"`
+	}
+	err := client.Do(context.Background(), http.MethodGet, "repos/owner/repo", nil, &repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", repo.Name)
+}
+
+func TestDoError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("no such pull request"))
+	})
+
+	err := client.Do(context.Background(), http.MethodGet, "repos/owner/repo/pulls/1", nil, nil)
+	assert.EqualError(t, err, "forge API error 404: no such pull request")
+	assert.ErrorIs(t, NotFound(err), githubclient.ErrPullRequestNotFound)
+}
+
+func TestPaginate(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Query().Get("page")))
+	})
+
+	var pages []string
+	err := client.Paginate(context.Background(), "items?page=1", func(resp *http.Response, data []byte) (string, error) {
+		pages = append(pages, string(data))
+		if len(pages) == 3 {
+			return "", nil
+		}
+		return WithQuery("items", "page="+strconv.Itoa(len(pages)+1)), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, pages)
+}
+
+func TestNextLink(t *testing.T) {
+	testCases := []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{name: "No header"},
+		{name: "Next and last", link: `<https://example.com/items?page=2>; rel="next", <https://example.com/items?page=5>; rel="last"`, expected: "https://example.com/items?page=2"},
+		{name: "Previous first", link: `<https://example.com/items?page=1>; rel="prev", <https://example.com/items?page=3>; rel="next"`, expected: "https://example.com/items?page=3"},
+		{name: "Last page", link: `<https://example.com/items?page=1>; rel="first", <https://example.com/items?page=4>; rel="prev"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tc.link != "" {
+				resp.Header.Set("Link", tc.link)
+			}
+			assert.Equal(t, tc.expected, NextLink(resp))
+		})
+	}
+}
+
+func TestWithQuery(t *testing.T) {
+	assert.Equal(t, "items?page=2", WithQuery("items", "page=2"))
+	assert.Equal(t, "items?state=open&page=2", WithQuery("items?state=open", "page=2"))
+}
@@ -2,11 +2,15 @@ package rules
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Djiit/gong/internal/githubclient"
 	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
@@ -16,12 +20,38 @@ var timeNow = time.Now
 
 // Rule represents a rule for matching reviewers with custom delays
 type Rule struct {
+	Name         string // Optional name, reported to integrations
 	MatchName    string
 	MatchTitle   string
 	MatchAuthor  string // Added for matching PR authors
 	Delay        int
 	Enabled      bool
 	Integrations []ping.Integration // List of integrations for this rule
+	// Optional overrides of the global settings, nil when not specified in the rule
+	SkipIfTeamMemberReviewed *bool
+	ResetDelayOnActivity     *bool
+	RequireChecksPassing     *bool
+	RequireRequiredChecks    *bool
+	SkipIfConflicting        *bool
+}
+
+// Label returns the name of the rule or, when it has none, its patterns
+func (r Rule) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	var patterns []string
+	for _, p := range []struct{ key, pattern string }{
+		{"matchName", r.MatchName},
+		{"matchTitle", r.MatchTitle},
+		{"matchAuthor", r.MatchAuthor},
+	} {
+		if p.pattern != "" {
+			patterns = append(patterns, fmt.Sprintf("%s=%s", p.key, p.pattern))
+		}
+	}
+	return strings.Join(patterns, ",")
 }
 
 // Each rule can override the global delay for specific reviewers matching the glob pattern
@@ -44,78 +74,209 @@ func ApplyRules(ctx context.Context, requests []githubclient.ReviewRequest, rule
 			Enabled:      ctx.Value("enabled").(bool),
 			Integrations: make([]ping.Integration, len(globalIntegrations)),
 		}
+		if skip, ok := ctx.Value("skipIfTeamMemberReviewed").(bool); ok {
+			pingReq.SkipIfTeamMemberReviewed = skip
+		}
+		if reset, ok := ctx.Value("resetDelayOnActivity").(bool); ok {
+			pingReq.ResetDelayOnActivity = reset
+		}
+		if require, ok := ctx.Value("requireChecksPassing").(bool); ok {
+			pingReq.RequireChecksPassing = require
+		}
+		if require, ok := ctx.Value("requireRequiredChecks").(bool); ok {
+			pingReq.RequireRequiredChecks = require
+		}
+		if skip, ok := ctx.Value("skipIfConflicting").(bool); ok {
+			pingReq.SkipIfConflicting = skip
+		}
+		if checks, ok := ctx.Value("checks").(*githubclient.ChecksStatus); ok {
+			pingReq.Checks = checks
+		}
+		pingReq.WaitingOnAuthor = waitingOnAuthor(ctx, req)
 
 		// Copy global integrations
 		copy(pingReq.Integrations, globalIntegrations)
 
-		// Check if any rule matches this reviewer
-		for _, rule := range rules {
-			nameMatched := false
-			titleMatched := false
-			authorMatched := false
+		// Apply the first rule matching this reviewer
+		if rule, ok := matchRule(rules, req); ok {
+			pingReq.Rule = rule.Label()
+			pingReq.Delay = rule.Delay
+			pingReq.Enabled = rule.Enabled
 
-			// Check if reviewer name matches the pattern, if a pattern is provided
-			if rule.MatchName != "" {
-				if matched, _ := filepath.Match(rule.MatchName, req.From); matched {
-					nameMatched = true
-				}
+			// Override integrations if specified in the rule
+			if len(rule.Integrations) > 0 {
+				pingReq.Integrations = rule.Integrations
+			}
+			if rule.SkipIfTeamMemberReviewed != nil {
+				pingReq.SkipIfTeamMemberReviewed = *rule.SkipIfTeamMemberReviewed
+			}
+			if rule.ResetDelayOnActivity != nil {
+				pingReq.ResetDelayOnActivity = *rule.ResetDelayOnActivity
+			}
+			if rule.RequireChecksPassing != nil {
+				pingReq.RequireChecksPassing = *rule.RequireChecksPassing
+			}
+			if rule.RequireRequiredChecks != nil {
+				pingReq.RequireRequiredChecks = *rule.RequireRequiredChecks
 			}
+			if rule.SkipIfConflicting != nil {
+				pingReq.SkipIfConflicting = *rule.SkipIfConflicting
+			}
+		}
 
-			// Check if PR title matches the pattern, if a pattern is provided
-			if rule.MatchTitle != "" && req.PRTitle != "" {
-				if matched, _ := filepath.Match(rule.MatchTitle, req.PRTitle); matched {
-					titleMatched = true
-				}
+		// The delay runs from the review request, or from the reviewer's last activity if asked to
+		since := req.On
+		if pingReq.ResetDelayOnActivity && req.LastActivity.After(since) {
+			since = req.LastActivity
+		}
+
+		pingReq.Snooze = activeSnooze(ctx, req.From, now)
+
+		// Determine if we should ping based on delay and enabled status
+		// Reviewers are left alone while the pull request is waiting on its author or they snoozed
+		pingReq.ShouldPing = pingReq.Enabled && !pingReq.TeamMemberReviewed() && pingReq.ChecksBlocking() == "" &&
+			len(pingReq.WaitingOnAuthor) == 0 && pingReq.Snooze == nil &&
+			(pingReq.Delay <= 0 || now.Sub(since).Seconds() >= float64(pingReq.Delay))
+		pingRequests = append(pingRequests, pingReq)
+	}
+
+	return pingRequests
+}
+
+// matchRule returns the first rule matching a review request. When a rule has several patterns,
+// all of them must match.
+func matchRule(rules []Rule, req githubclient.ReviewRequest) (Rule, bool) {
+	for _, rule := range rules {
+		nameMatched := false
+		titleMatched := false
+		authorMatched := false
+
+		// Check if reviewer name matches the pattern, if a pattern is provided.
+		// Members expanded from a team also match on the team name.
+		if rule.MatchName != "" {
+			if matched, _ := filepath.Match(rule.MatchName, req.From); matched {
+				nameMatched = true
+			} else if matched, _ := filepath.Match(rule.MatchName, req.Team); matched && req.Team != "" {
+				nameMatched = true
 			}
+		}
 
-			// Check if PR author matches the pattern, if a pattern is provided
-			log.Debug().Msgf("Checking if PR author %s matches pattern %s", req.PRAuthor, rule.MatchAuthor)
-			if rule.MatchAuthor != "" && req.PRAuthor != "" {
-				log.Debug().Msgf("Checking if PR author %s matches pattern %s", req.PRAuthor, rule.MatchAuthor)
-				if matched, _ := filepath.Match(rule.MatchAuthor, req.PRAuthor); matched {
-					authorMatched = true
-				}
+		// Check if PR title matches the pattern, if a pattern is provided
+		if rule.MatchTitle != "" && req.PRTitle != "" {
+			if matched, _ := filepath.Match(rule.MatchTitle, req.PRTitle); matched {
+				titleMatched = true
 			}
+		}
 
-			// Determine if this rule applies
-			ruleApplies := false
-
-			// All conditions must match if multiple are provided
-			if rule.MatchName != "" && rule.MatchTitle != "" && rule.MatchAuthor != "" {
-				ruleApplies = nameMatched && titleMatched && authorMatched
-			} else if rule.MatchName != "" && rule.MatchTitle != "" {
-				ruleApplies = nameMatched && titleMatched
-			} else if rule.MatchName != "" && rule.MatchAuthor != "" {
-				ruleApplies = nameMatched && authorMatched
-			} else if rule.MatchTitle != "" && rule.MatchAuthor != "" {
-				ruleApplies = titleMatched && authorMatched
-			} else if rule.MatchName != "" {
-				ruleApplies = nameMatched
-			} else if rule.MatchTitle != "" {
-				ruleApplies = titleMatched
-			} else if rule.MatchAuthor != "" {
-				ruleApplies = authorMatched
+		// Check if PR author matches the pattern, if a pattern is provided
+		log.Debug().Msgf("Checking if PR author %s matches pattern %s", req.PRAuthor, rule.MatchAuthor)
+		if rule.MatchAuthor != "" && req.PRAuthor != "" {
+			log.Debug().Msgf("Checking if PR author %s matches pattern %s", req.PRAuthor, rule.MatchAuthor)
+			if matched, _ := filepath.Match(rule.MatchAuthor, req.PRAuthor); matched {
+				authorMatched = true
 			}
+		}
 
-			// Apply the rule if it matches
-			if ruleApplies {
-				pingReq.Delay = rule.Delay
-				pingReq.Enabled = rule.Enabled
+		// Determine if this rule applies
+		ruleApplies := false
+
+		// All conditions must match if multiple are provided
+		if rule.MatchName != "" && rule.MatchTitle != "" && rule.MatchAuthor != "" {
+			ruleApplies = nameMatched && titleMatched && authorMatched
+		} else if rule.MatchName != "" && rule.MatchTitle != "" {
+			ruleApplies = nameMatched && titleMatched
+		} else if rule.MatchName != "" && rule.MatchAuthor != "" {
+			ruleApplies = nameMatched && authorMatched
+		} else if rule.MatchTitle != "" && rule.MatchAuthor != "" {
+			ruleApplies = titleMatched && authorMatched
+		} else if rule.MatchName != "" {
+			ruleApplies = nameMatched
+		} else if rule.MatchTitle != "" {
+			ruleApplies = titleMatched
+		} else if rule.MatchAuthor != "" {
+			ruleApplies = authorMatched
+		}
 
-				// Override integrations if specified in the rule
-				if len(rule.Integrations) > 0 {
-					pingReq.Integrations = rule.Integrations
-				}
-				break
-			}
+		if ruleApplies {
+			return rule, true
 		}
+	}
 
-		// Determine if we should ping based on delay and enabled status
-		pingReq.ShouldPing = pingReq.Enabled && (pingReq.Delay <= 0 || now.Sub(req.On).Seconds() >= float64(pingReq.Delay))
-		pingRequests = append(pingRequests, pingReq)
+	return Rule{}, false
+}
+
+// NudgeAuthor builds the ping request notifying the author of a pull request that is waiting on
+// them since req.On. It is enabled and sent like reviewer pings, through the global settings or
+// the first rule matching the author, but waits for the "authorDelay" from the context.
+func NudgeAuthor(ctx context.Context, req githubclient.ReviewRequest, reasons []string, rules []Rule) ping.PingRequest {
+	pingReq := ping.PingRequest{
+		Req:             req,
+		Delay:           ctx.Value("authorDelay").(int),
+		Enabled:         ctx.Value("enabled").(bool),
+		WaitingOnAuthor: reasons,
+		AuthorNudge:     true,
+	}
+	if intgs, ok := ctx.Value("integrations").([]ping.Integration); ok {
+		pingReq.Integrations = make([]ping.Integration, len(intgs))
+		copy(pingReq.Integrations, intgs)
+	}
+	if checks, ok := ctx.Value("checks").(*githubclient.ChecksStatus); ok {
+		pingReq.Checks = checks
 	}
 
-	return pingRequests
+	if rule, ok := matchRule(rules, req); ok {
+		pingReq.Rule = rule.Label()
+		pingReq.Enabled = rule.Enabled
+		if len(rule.Integrations) > 0 {
+			pingReq.Integrations = rule.Integrations
+		}
+	}
+
+	now := timeNow()
+	pingReq.Snooze = activeSnooze(ctx, req.From, now)
+	pingReq.ShouldPing = pingReq.Enabled && pingReq.Snooze == nil &&
+		(pingReq.Delay <= 0 || now.Sub(req.On).Seconds() >= float64(pingReq.Delay))
+	return pingReq
+}
+
+// waitingOnAuthor returns why a reviewer is left alone while the pull request waits on its author.
+// Unresolved review threads only hold back the reviewers who started them, not everybody else.
+func waitingOnAuthor(ctx context.Context, req githubclient.ReviewRequest) []string {
+	reasons, _ := ctx.Value("waitingOnAuthor").([]string)
+	if !slices.Contains(reasons, githubclient.AuthorReasonUnresolvedThreads) {
+		return reasons
+	}
+
+	threadAuthors, _ := ctx.Value("unresolvedThreadAuthors").([]string)
+	if !req.IsTeam && slices.Contains(threadAuthors, req.From) {
+		return reasons
+	}
+
+	var scoped []string
+	for _, reason := range reasons {
+		if reason != githubclient.AuthorReasonUnresolvedThreads {
+			scoped = append(scoped, reason)
+		}
+	}
+	return scoped
+}
+
+// activeSnooze returns the snooze or acknowledgement recorded from chat for a reviewer of the pull
+// request in the context, if one is running at now
+func activeSnooze(ctx context.Context, reviewer string, now time.Time) *state.Snooze {
+	store, ok := ctx.Value("state").(*state.Store)
+	if !ok || store == nil {
+		return nil
+	}
+	owner, _ := ctx.Value("repoOwner").(string)
+	repo, _ := ctx.Value("repoName").(string)
+	prNumber, _ := ctx.Value("pr").(string)
+
+	snooze, active := store.ActiveSnooze(state.ReviewerKey(owner, repo, prNumber, reviewer), now)
+	if !active {
+		return nil
+	}
+	return &snooze
 }
 
 // ParseIntegration parses a single integration configuration from viper
@@ -141,6 +302,26 @@ func ParseIntegration(intgMap map[string]interface{}) ping.Integration {
 	return integration
 }
 
+// GatesOnChecks reports whether pings depend on the CI status or mergeability of pull requests,
+// either through the global settings in ctx or through one of the rules
+func GatesOnChecks(ctx context.Context, ruleset []Rule) bool {
+	for _, key := range []string{"requireChecksPassing", "requireRequiredChecks", "skipIfConflicting"} {
+		if gate, ok := ctx.Value(key).(bool); ok && gate {
+			return true
+		}
+	}
+
+	for _, rule := range ruleset {
+		for _, gate := range []*bool{rule.RequireChecksPassing, rule.RequireRequiredChecks, rule.SkipIfConflicting} {
+			if gate != nil && *gate {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // ParseRules extracts rules configuration from viper
 func ParseRules() []Rule {
 	var ruleset []Rule
@@ -151,6 +332,10 @@ func ParseRules() []Rule {
 			if ruleMap, ok := r.(map[string]interface{}); ok {
 				rule := Rule{}
 
+				if name, ok := ruleMap["name"].(string); ok {
+					rule.Name = name
+				}
+
 				if matchName, ok := ruleMap["matchname"].(string); ok {
 					rule.MatchName = matchName
 				}
@@ -173,6 +358,26 @@ func ParseRules() []Rule {
 					rule.Enabled = enabled
 				}
 
+				if skip, ok := ruleMap["skipifteammemberreviewed"].(bool); ok {
+					rule.SkipIfTeamMemberReviewed = &skip
+				}
+
+				if reset, ok := ruleMap["resetdelayonactivity"].(bool); ok {
+					rule.ResetDelayOnActivity = &reset
+				}
+
+				if require, ok := ruleMap["requirecheckspassing"].(bool); ok {
+					rule.RequireChecksPassing = &require
+				}
+
+				if require, ok := ruleMap["requirerequiredchecks"].(bool); ok {
+					rule.RequireRequiredChecks = &require
+				}
+
+				if skip, ok := ruleMap["skipifconflicting"].(bool); ok {
+					rule.SkipIfConflicting = &skip
+				}
+
 				// Extract integrations if they exist
 				if integrations, ok := ruleMap["integrations"].([]interface{}); ok {
 					for _, intg := range integrations {
@@ -2,11 +2,13 @@ package rules
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/Djiit/gong/internal/githubclient"
 	"github.com/Djiit/gong/internal/ping"
+	"github.com/Djiit/gong/internal/state"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -293,6 +295,7 @@ func TestParseRules(t *testing.T) {
 			config: map[string]interface{}{
 				"rules": []interface{}{
 					map[string]interface{}{
+						"name":      "test rule",
 						"matchname": "test-user",
 						"delay":     24,
 						"enabled":   true,
@@ -306,6 +309,7 @@ func TestParseRules(t *testing.T) {
 			},
 			expectedRules: []Rule{
 				{
+					Name:      "test rule",
 					MatchName: "test-user",
 					Delay:     24,
 					Enabled:   true,
@@ -394,6 +398,7 @@ func TestParseRules(t *testing.T) {
 
 			if tt.expectedLength > 0 {
 				for i, rule := range tt.expectedRules {
+					assert.Equal(t, rule.Name, result[i].Name)
 					assert.Equal(t, rule.MatchName, result[i].MatchName)
 					assert.Equal(t, rule.Delay, result[i].Delay)
 					assert.Equal(t, rule.Enabled, result[i].Enabled)
@@ -838,3 +843,281 @@ func TestParseRulesWithMatchAuthor(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyRulesWithReviewActivity(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 3600)
+	ctx = context.WithValue(ctx, "enabled", true)
+	ctx = context.WithValue(ctx, "skipIfTeamMemberReviewed", true)
+
+	requests := []githubclient.ReviewRequest{
+		// A member of the team reviewed after the team was requested
+		{From: "backend", IsTeam: true, On: timeNow().Add(-3 * time.Hour), ReviewState: githubclient.ReviewStateApproved, LastActivity: timeNow().Add(-2 * time.Hour)},
+		// Nobody reviewed on behalf of the team
+		{From: "frontend", IsTeam: true, On: timeNow().Add(-3 * time.Hour), ReviewState: githubclient.ReviewStateNone},
+		// Commented recently, the delay restarts from the comment
+		{From: "reviewer1", On: timeNow().Add(-3 * time.Hour), ReviewState: githubclient.ReviewStateCommented, LastActivity: timeNow().Add(-30 * time.Minute)},
+		// Same activity, but the delay still runs from the request
+		{From: "reviewer2", On: timeNow().Add(-3 * time.Hour), ReviewState: githubclient.ReviewStateCommented, LastActivity: timeNow().Add(-30 * time.Minute)},
+	}
+
+	reset := true
+	rules := []Rule{
+		{MatchName: "reviewer1", Delay: 3600, Enabled: true, ResetDelayOnActivity: &reset},
+	}
+
+	result := ApplyRules(ctx, requests, rules)
+
+	assert.Equal(t, 4, len(result))
+	assert.False(t, result[0].ShouldPing)
+	assert.True(t, result[0].TeamMemberReviewed())
+	assert.True(t, result[1].ShouldPing)
+	assert.False(t, result[2].ShouldPing)
+	assert.True(t, result[3].ShouldPing)
+}
+
+func TestParseRulesWithReviewActivityOptions(t *testing.T) {
+	viper.Reset()
+	viper.Set("rules", []interface{}{
+		map[string]interface{}{
+			"matchname":                "backend",
+			"skipifteammemberreviewed": true,
+			"resetdelayonactivity":     false,
+		},
+		map[string]interface{}{
+			"matchname": "frontend",
+		},
+	})
+
+	result := ParseRules()
+
+	assert.Equal(t, 2, len(result))
+	assert.NotNil(t, result[0].SkipIfTeamMemberReviewed)
+	assert.True(t, *result[0].SkipIfTeamMemberReviewed)
+	assert.NotNil(t, result[0].ResetDelayOnActivity)
+	assert.False(t, *result[0].ResetDelayOnActivity)
+	assert.Nil(t, result[1].SkipIfTeamMemberReviewed)
+	assert.Nil(t, result[1].ResetDelayOnActivity)
+}
+
+func TestApplyRulesWithChecks(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 0)
+	ctx = context.WithValue(ctx, "enabled", true)
+	ctx = context.WithValue(ctx, "requireRequiredChecks", true)
+	ctx = context.WithValue(ctx, "checks", &githubclient.ChecksStatus{
+		State:          githubclient.CheckStateFailure,
+		RequiredState:  githubclient.CheckStateSuccess,
+		MergeableState: githubclient.MergeableStateDirty,
+	})
+
+	requests := []githubclient.ReviewRequest{
+		{From: "reviewer1", On: timeNow().Add(-time.Hour)},
+		{From: "reviewer2", On: timeNow().Add(-time.Hour)},
+		{From: "reviewer3", On: timeNow().Add(-time.Hour)},
+	}
+
+	require := true
+	rules := []Rule{
+		{MatchName: "reviewer2", Enabled: true, RequireChecksPassing: &require},
+		{MatchName: "reviewer3", Enabled: true, SkipIfConflicting: &require},
+	}
+
+	result := ApplyRules(ctx, requests, rules)
+
+	assert.Equal(t, 3, len(result))
+	assert.True(t, result[0].ShouldPing)
+	assert.False(t, result[1].ShouldPing)
+	assert.Equal(t, "checks failing", result[1].ChecksBlocking())
+	assert.False(t, result[2].ShouldPing)
+	assert.Equal(t, "conflicting", result[2].ChecksBlocking())
+}
+
+func TestGatesOnChecks(t *testing.T) {
+	enabled, disabled := true, false
+
+	assert.False(t, GatesOnChecks(context.Background(), nil))
+	assert.False(t, GatesOnChecks(context.WithValue(context.Background(), "skipIfConflicting", false), []Rule{
+		{MatchName: "alice", RequireChecksPassing: &disabled},
+	}))
+	assert.True(t, GatesOnChecks(context.WithValue(context.Background(), "requireRequiredChecks", true), nil))
+	assert.True(t, GatesOnChecks(context.Background(), []Rule{
+		{MatchName: "alice"},
+		{MatchName: "bob", SkipIfConflicting: &enabled},
+	}))
+}
+
+func TestNudgeAuthor(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	reasons := []string{githubclient.AuthorReasonChangesRequested}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 0)
+	ctx = context.WithValue(ctx, "enabled", true)
+	ctx = context.WithValue(ctx, "authorDelay", 3600)
+	ctx = context.WithValue(ctx, "waitingOnAuthor", reasons)
+	ctx = context.WithValue(ctx, "integrations", []ping.Integration{{Type: "stdout"}})
+
+	// Reviewers are not pinged while the pull request is waiting on its author
+	result := ApplyRules(ctx, []githubclient.ReviewRequest{{From: "reviewer1", On: timeNow().Add(-time.Hour)}}, nil)
+	assert.False(t, result[0].ShouldPing)
+
+	nudge := NudgeAuthor(ctx, githubclient.ReviewRequest{From: "author", On: timeNow().Add(-2 * time.Hour)}, reasons, nil)
+	assert.True(t, nudge.AuthorNudge)
+	assert.True(t, nudge.ShouldPing)
+	assert.Equal(t, 3600, nudge.Delay)
+	assert.Equal(t, reasons, nudge.WaitingOnAuthor)
+	assert.Equal(t, []ping.Integration{{Type: "stdout"}}, nudge.Integrations)
+
+	nudge = NudgeAuthor(ctx, githubclient.ReviewRequest{From: "author", On: timeNow().Add(-30 * time.Minute)}, reasons, nil)
+	assert.False(t, nudge.ShouldPing)
+
+	// The global enabled setting applies to authors too
+	disabledCtx := context.WithValue(ctx, "enabled", false)
+	nudge = NudgeAuthor(disabledCtx, githubclient.ReviewRequest{From: "author", On: timeNow().Add(-2 * time.Hour)}, reasons, nil)
+	assert.False(t, nudge.ShouldPing)
+}
+
+func TestNudgeAuthorMatchesRules(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	reasons := []string{githubclient.AuthorReasonChangesRequested}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "enabled", true)
+	ctx = context.WithValue(ctx, "authorDelay", 0)
+	ctx = context.WithValue(ctx, "integrations", []ping.Integration{{Type: "stdout"}})
+
+	rules := []Rule{
+		{Name: "bots", MatchAuthor: "bot-*", Enabled: false},
+		{Name: "frontend", MatchName: "alice", Enabled: true, Integrations: []ping.Integration{{Type: "slack"}}},
+	}
+
+	nudge := NudgeAuthor(ctx, githubclient.ReviewRequest{From: "alice", PRAuthor: "alice", On: timeNow()}, reasons, rules)
+	assert.True(t, nudge.ShouldPing)
+	assert.Equal(t, "frontend", nudge.Rule)
+	assert.Equal(t, []ping.Integration{{Type: "slack"}}, nudge.Integrations)
+
+	nudge = NudgeAuthor(ctx, githubclient.ReviewRequest{From: "bot-deps", PRAuthor: "bot-deps", On: timeNow()}, reasons, rules)
+	assert.False(t, nudge.ShouldPing)
+	assert.Equal(t, "bots", nudge.Rule)
+}
+
+func TestApplyRulesScopesUnresolvedThreads(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 0)
+	ctx = context.WithValue(ctx, "enabled", true)
+	ctx = context.WithValue(ctx, "waitingOnAuthor", []string{githubclient.AuthorReasonUnresolvedThreads})
+	ctx = context.WithValue(ctx, "unresolvedThreadAuthors", []string{"alice"})
+
+	result := ApplyRules(ctx, []githubclient.ReviewRequest{
+		{From: "alice", On: timeNow().Add(-time.Hour)},
+		{From: "bob", On: timeNow().Add(-time.Hour)},
+	}, nil)
+
+	// Only the reviewer who started the thread waits for the author to address it
+	assert.False(t, result[0].ShouldPing)
+	assert.Equal(t, []string{githubclient.AuthorReasonUnresolvedThreads}, result[0].WaitingOnAuthor)
+	assert.True(t, result[1].ShouldPing)
+	assert.Empty(t, result[1].WaitingOnAuthor)
+}
+
+func TestApplyRulesMatchesExpandedTeams(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 0)
+	ctx = context.WithValue(ctx, "enabled", true)
+
+	requests := []githubclient.ReviewRequest{
+		{From: "alice", Team: "backend", On: timeNow().Add(-time.Hour)},
+		{From: "bob", Team: "backend", On: timeNow().Add(-time.Hour)},
+		{From: "carol", On: timeNow().Add(-time.Hour)},
+	}
+
+	rules := []Rule{
+		{MatchName: "bob", Delay: 0, Enabled: false},
+		{MatchName: "backend", Delay: 7200, Enabled: true},
+	}
+
+	result := ApplyRules(ctx, requests, rules)
+
+	assert.Equal(t, 3, len(result))
+	assert.Equal(t, 7200, result[0].Delay)
+	assert.False(t, result[0].ShouldPing)
+	assert.False(t, result[1].Enabled)
+	assert.True(t, result[2].ShouldPing)
+}
+
+func TestApplyRulesWithSnoozes(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.json"))
+	assert.NoError(t, err)
+	store.SetSnooze(state.ReviewerKey("owner", "repo", "1", "reviewer1"), state.Snooze{Until: timeNow().Add(4 * time.Hour), At: timeNow()})
+	store.SetSnooze(state.ReviewerKey("owner", "repo", "1", "reviewer2"), state.Snooze{Until: timeNow().Add(-time.Minute), At: timeNow().Add(-time.Hour)})
+	store.SetSnooze(state.ReviewerKey("owner", "repo", "2", "reviewer3"), state.Snooze{Until: timeNow().Add(time.Hour), At: timeNow()})
+	store.SetSnooze(state.ReviewerKey("owner", "repo", "1", "author"), state.Snooze{Until: timeNow().Add(time.Hour), Acknowledged: true, At: timeNow()})
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 0)
+	ctx = context.WithValue(ctx, "enabled", true)
+	ctx = context.WithValue(ctx, "authorDelay", 0)
+	ctx = context.WithValue(ctx, "repoOwner", "owner")
+	ctx = context.WithValue(ctx, "repoName", "repo")
+	ctx = context.WithValue(ctx, "pr", "1")
+	ctx = context.WithValue(ctx, "state", store)
+
+	result := ApplyRules(ctx, []githubclient.ReviewRequest{
+		{From: "reviewer1", On: timeNow().Add(-time.Hour)},
+		{From: "reviewer2", On: timeNow().Add(-time.Hour)},
+		{From: "reviewer3", On: timeNow().Add(-time.Hour)},
+	}, nil)
+
+	assert.False(t, result[0].ShouldPing)
+	assert.NotNil(t, result[0].Snooze)
+	// Snoozes that are over and snoozes on other pull requests do not apply
+	assert.True(t, result[1].ShouldPing)
+	assert.True(t, result[2].ShouldPing)
+
+	nudge := NudgeAuthor(ctx, githubclient.ReviewRequest{From: "author", On: timeNow().Add(-time.Hour)}, nil, nil)
+	assert.False(t, nudge.ShouldPing)
+	assert.True(t, nudge.Snooze.Acknowledged)
+}
+
+func TestApplyRulesRecordsMatchedRule(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, "delay", 0)
+	ctx = context.WithValue(ctx, "enabled", true)
+
+	requests := []githubclient.ReviewRequest{
+		{From: "lead-alice", On: timeNow(), PRTitle: "fix: crash"},
+		{From: "bob", On: timeNow(), PRTitle: "fix: crash"},
+		{From: "carol", On: timeNow(), PRTitle: "feat: search"},
+	}
+	rules := []Rule{
+		{Name: "leads", MatchName: "lead-*", Enabled: true},
+		{MatchName: "b*", MatchTitle: "fix:*", Enabled: true},
+	}
+
+	result := ApplyRules(ctx, requests, rules)
+
+	assert.Equal(t, "leads", result[0].Rule)
+	assert.Equal(t, "matchName=b*,matchTitle=fix:*", result[1].Rule)
+	assert.Equal(t, "", result[2].Rule)
+}
@@ -0,0 +1,46 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockRetryInterval is how often a held lock is tried again
+	lockRetryInterval = 50 * time.Millisecond
+	// lockTimeout is how long Save waits for another process to release the lock
+	lockTimeout = 10 * time.Second
+	// staleLockAge is the age past which a lock is considered left behind by a crashed process
+	staleLockAge = time.Minute
+)
+
+// lockFile takes an exclusive lock on the state file shared with other gong processes, such as
+// gong ping running while gong serve records snoozes. It creates path + ".lock", which works the
+// same on every platform, and returns a function removing it.
+func lockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error locking state: %w", err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the state lock %s", lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
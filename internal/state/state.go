@@ -0,0 +1,304 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SlackMessage is a Slack message gong posted for a pull request in bot-token mode
+type SlackMessage struct {
+	Channel   string    `json:"channel"` // Channel ID returned by Slack
+	Timestamp string    `json:"ts"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	Resolved  bool      `json:"resolved,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Snooze keeps gong from pinging a reviewer on a pull request until a given time, because they
+// snoozed the reminder or said they are on it from chat
+type Snooze struct {
+	Until        time.Time `json:"until"`
+	Acknowledged bool      `json:"acknowledged,omitempty"` // Whether the reviewer said they are on it
+	By           string    `json:"by,omitempty"`           // Chat user who asked for it
+	At           time.Time `json:"at"`
+}
+
+// Alert is an alert gong opened for a pull request in an incident management service, to resolve
+// once the pull request no longer needs reviews
+type Alert struct {
+	DedupKey   string    `json:"dedupKey"`             // Deduplication key, or alias, of the alert
+	RoutingKey string    `json:"routingKey,omitempty"` // Where the alert was sent, when the service needs it to resolve it
+	OpenedAt   time.Time `json:"openedAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type data struct {
+	SlackMessages map[string]SlackMessage `json:"slackMessages,omitempty"`
+	Snoozes       map[string]Snooze       `json:"snoozes,omitempty"`
+	Alerts        map[string]Alert        `json:"alerts,omitempty"`
+}
+
+// changes records the keys set or deleted since the state was read, by section
+type changes struct {
+	slackMessages map[string]bool
+	snoozes       map[string]bool
+	alerts        map[string]bool
+}
+
+// Store holds what gong needs to remember between runs. It is persisted as a JSON file, which
+// several gong processes may share, so only the keys changed through a Store are written back.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	data    data
+	changed changes
+}
+
+// DefaultPath returns the state file used when state-file is not set
+func DefaultPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "gong", "state.json"), nil
+}
+
+// FromContext returns the state store shared by the run, set under "state" in ctx, or opens the
+// one configured with state-file
+func FromContext(ctx context.Context) (*Store, error) {
+	if store, ok := ctx.Value("state").(*Store); ok {
+		return store, nil
+	}
+	return FromConfig()
+}
+
+// FromConfig opens the state file set by state-file, or the default one
+func FromConfig() (*Store, error) {
+	path := viper.GetString("state-file")
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, fmt.Errorf("could not find a state directory, set --state-file: %w", err)
+		}
+	}
+	return Open(path)
+}
+
+// Open reads the state stored at path. A missing file is an empty state.
+func Open(path string) (*Store, error) {
+	d, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path, data: d}, nil
+}
+
+func read(path string) (data, error) {
+	var d data
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return d, fmt.Errorf("error reading state: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &d); err != nil {
+		return d, fmt.Errorf("error parsing state file %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Save writes the changes made since the state was read back to its file. The file is read
+// again under a lock and the changes are merged into it, so that what other processes saved in
+// the meantime, such as snoozes recorded by gong serve during a gong ping run, is kept.
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, err := read(s.path)
+	if err != nil {
+		return err
+	}
+	merge(&d.SlackMessages, s.data.SlackMessages, s.changed.slackMessages)
+	merge(&d.Snoozes, s.data.Snoozes, s.changed.snoozes)
+	merge(&d.Alerts, s.data.Alerts, s.changed.alerts)
+
+	content, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted run does not corrupt the state
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("error writing state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.data = d
+	s.changed = changes{}
+	return nil
+}
+
+// merge applies the keys of src listed in keys onto dst, deleting the ones src no longer has
+func merge[V any](dst *map[string]V, src map[string]V, keys map[string]bool) {
+	for key := range keys {
+		v, ok := src[key]
+		if !ok {
+			delete(*dst, key)
+			continue
+		}
+		if *dst == nil {
+			*dst = make(map[string]V)
+		}
+		(*dst)[key] = v
+	}
+}
+
+// markChanged records that key was set or deleted in a section
+func markChanged(keys *map[string]bool, key string) {
+	if *keys == nil {
+		*keys = make(map[string]bool)
+	}
+	(*keys)[key] = true
+}
+
+// PullRequestKey identifies a pull request in the state
+func PullRequestKey(owner, repo, prNumber string) string {
+	return fmt.Sprintf("%s/%s#%s", owner, repo, prNumber)
+}
+
+// SlackMessageKey identifies the message posted for a pull request in a channel
+func SlackMessageKey(owner, repo, prNumber, channel string) string {
+	return PullRequestKey(owner, repo, prNumber) + "@" + channel
+}
+
+// SlackMessage returns the message stored under key
+func (s *Store) SlackMessage(key string) (SlackMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.data.SlackMessages[key]
+	return m, ok
+}
+
+// SetSlackMessage stores a message under key
+func (s *Store) SetSlackMessage(key string, m SlackMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.SlackMessages == nil {
+		s.data.SlackMessages = make(map[string]SlackMessage)
+	}
+	s.data.SlackMessages[key] = m
+	markChanged(&s.changed.slackMessages, key)
+}
+
+// SlackMessages returns the messages posted for a pull request, by key
+func (s *Store) SlackMessages(owner, repo, prNumber string) map[string]SlackMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := PullRequestKey(owner, repo, prNumber) + "@"
+	messages := make(map[string]SlackMessage)
+	for key, m := range s.data.SlackMessages {
+		if strings.HasPrefix(key, prefix) {
+			messages[key] = m
+		}
+	}
+	return messages
+}
+
+// ReviewerKey identifies a reviewer on a pull request
+func ReviewerKey(owner, repo, prNumber, reviewer string) string {
+	return PullRequestKey(owner, repo, prNumber) + ":" + strings.ToLower(reviewer)
+}
+
+// ActiveSnooze returns the snooze stored under key if it still runs at now
+func (s *Store) ActiveSnooze(key string, now time.Time) (Snooze, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snooze, ok := s.data.Snoozes[key]
+	if !ok || !now.Before(snooze.Until) {
+		return Snooze{}, false
+	}
+	return snooze, true
+}
+
+// SetSnooze stores a snooze under key, dropping the snoozes that are over
+func (s *Store) SetSnooze(key string, snooze Snooze) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Snoozes == nil {
+		s.data.Snoozes = make(map[string]Snooze)
+	}
+	for k, existing := range s.data.Snoozes {
+		if existing.Until.Before(snooze.At) {
+			delete(s.data.Snoozes, k)
+			markChanged(&s.changed.snoozes, k)
+		}
+	}
+	s.data.Snoozes[key] = snooze
+	markChanged(&s.changed.snoozes, key)
+}
+
+// AlertKey identifies the alert opened for a pull request in a service
+func AlertKey(owner, repo, prNumber, service string) string {
+	return PullRequestKey(owner, repo, prNumber) + "@" + service
+}
+
+// Alert returns the open alert stored under key
+func (s *Store) Alert(key string) (Alert, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.data.Alerts[key]
+	return a, ok
+}
+
+// SetAlert stores an open alert under key
+func (s *Store) SetAlert(key string, a Alert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Alerts == nil {
+		s.data.Alerts = make(map[string]Alert)
+	}
+	s.data.Alerts[key] = a
+	markChanged(&s.changed.alerts, key)
+}
+
+// DeleteAlert forgets the alert stored under key, once it was resolved
+func (s *Store) DeleteAlert(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.Alerts, key)
+	markChanged(&s.changed.alerts, key)
+}
@@ -0,0 +1,136 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	store, err := Open(path)
+	assert.NoError(t, err)
+	_, found := store.SlackMessage(SlackMessageKey("owner", "repo", "1", "C1"))
+	assert.False(t, found)
+
+	store.SetSlackMessage(SlackMessageKey("owner", "repo", "1", "C1"), SlackMessage{Channel: "C1", Timestamp: "1.1"})
+	store.SetSlackMessage(SlackMessageKey("owner", "repo", "1", "U1"), SlackMessage{Channel: "D1", Timestamp: "1.2"})
+	store.SetSlackMessage(SlackMessageKey("owner", "repo", "12", "C1"), SlackMessage{Channel: "C1", Timestamp: "1.3"})
+	assert.NoError(t, store.Save())
+
+	reopened, err := Open(path)
+	assert.NoError(t, err)
+
+	m, found := reopened.SlackMessage("owner/repo#1@C1")
+	assert.True(t, found)
+	assert.Equal(t, "1.1", m.Timestamp)
+	assert.Len(t, reopened.SlackMessages("owner", "repo", "1"), 2)
+}
+
+func TestOpenInvalidState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+
+	_, err := Open(path)
+	assert.Error(t, err)
+}
+
+func TestSnoozes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+
+	store, err := Open(path)
+	assert.NoError(t, err)
+	store.SetSnooze(ReviewerKey("owner", "repo", "1", "old"), Snooze{Until: now.Add(-time.Hour), At: now.Add(-5 * time.Hour)})
+	store.SetSnooze(ReviewerKey("owner", "repo", "1", "Alice"), Snooze{Until: now.Add(4 * time.Hour), By: "U0ALICE", At: now})
+	assert.NoError(t, store.Save())
+
+	reopened, err := Open(path)
+	assert.NoError(t, err)
+
+	snooze, active := reopened.ActiveSnooze("owner/repo#1:alice", now)
+	assert.True(t, active)
+	assert.Equal(t, "U0ALICE", snooze.By)
+
+	_, active = reopened.ActiveSnooze("owner/repo#1:alice", now.Add(5*time.Hour))
+	assert.False(t, active)
+
+	// Snoozes that are over are dropped
+	assert.Len(t, reopened.data.Snoozes, 1)
+}
+
+func TestAlerts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	store, err := Open(path)
+	assert.NoError(t, err)
+	store.SetAlert(AlertKey("owner", "repo", "1", "pagerduty"), Alert{DedupKey: "gong:owner/repo#1", RoutingKey: "R0UTING", OpenedAt: now, UpdatedAt: now})
+	store.SetAlert(AlertKey("owner", "repo", "2", "pagerduty"), Alert{DedupKey: "gong:owner/repo#2", OpenedAt: now, UpdatedAt: now})
+	store.DeleteAlert(AlertKey("owner", "repo", "2", "pagerduty"))
+	assert.NoError(t, store.Save())
+
+	reopened, err := Open(path)
+	assert.NoError(t, err)
+
+	alert, found := reopened.Alert("owner/repo#1@pagerduty")
+	assert.True(t, found)
+	assert.Equal(t, Alert{DedupKey: "gong:owner/repo#1", RoutingKey: "R0UTING", OpenedAt: now, UpdatedAt: now}, alert)
+	_, found = reopened.Alert("owner/repo#1@opsgenie")
+	assert.False(t, found)
+	_, found = reopened.Alert("owner/repo#2@pagerduty")
+	assert.False(t, found)
+}
+
+func TestSaveKeepsConcurrentChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+
+	// A ping run loads the state at startup
+	run, err := Open(path)
+	assert.NoError(t, err)
+
+	// The server records a snooze in the meantime
+	server, err := Open(path)
+	assert.NoError(t, err)
+	server.SetSnooze(ReviewerKey("owner", "repo", "1", "alice"), Snooze{Until: now.Add(time.Hour), At: now})
+	server.SetAlert(AlertKey("owner", "repo", "2", "opsgenie"), Alert{DedupKey: "gong:owner/repo#2"})
+	assert.NoError(t, server.Save())
+
+	// The run saves what it changed, without dropping the snooze
+	run.SetSlackMessage(SlackMessageKey("owner", "repo", "1", "C1"), SlackMessage{Channel: "C1", Timestamp: "1.1"})
+	run.DeleteAlert(AlertKey("owner", "repo", "2", "opsgenie"))
+	assert.NoError(t, run.Save())
+
+	reopened, err := Open(path)
+	assert.NoError(t, err)
+	_, active := reopened.ActiveSnooze("owner/repo#1:alice", now)
+	assert.True(t, active)
+	_, found := reopened.SlackMessage("owner/repo#1@C1")
+	assert.True(t, found)
+	_, found = reopened.Alert("owner/repo#2@opsgenie")
+	assert.False(t, found)
+
+	// The run sees what the server saved after its own save
+	_, active = run.ActiveSnooze("owner/repo#1:alice", now)
+	assert.True(t, active)
+	_, err = os.Stat(path + ".lock")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSaveWaitsForLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	unlock, err := lockFile(path)
+	assert.NoError(t, err)
+	time.AfterFunc(100*time.Millisecond, unlock)
+
+	store, err := Open(path)
+	assert.NoError(t, err)
+	store.SetAlert(AlertKey("owner", "repo", "1", "pagerduty"), Alert{DedupKey: "gong:owner/repo#1"})
+	assert.NoError(t, store.Save())
+}